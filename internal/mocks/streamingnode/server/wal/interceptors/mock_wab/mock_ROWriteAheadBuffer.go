@@ -22,6 +22,51 @@ func (_m *MockROWriteAheadBuffer) EXPECT() *MockROWriteAheadBuffer_Expecter {
 	return &MockROWriteAheadBuffer_Expecter{mock: &_m.Mock}
 }
 
+// BufferedBytes provides a mock function with no fields
+func (_m *MockROWriteAheadBuffer) BufferedBytes() int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for BufferedBytes")
+	}
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// MockROWriteAheadBuffer_BufferedBytes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BufferedBytes'
+type MockROWriteAheadBuffer_BufferedBytes_Call struct {
+	*mock.Call
+}
+
+// BufferedBytes is a helper method to define mock.On call
+func (_e *MockROWriteAheadBuffer_Expecter) BufferedBytes() *MockROWriteAheadBuffer_BufferedBytes_Call {
+	return &MockROWriteAheadBuffer_BufferedBytes_Call{Call: _e.mock.On("BufferedBytes")}
+}
+
+func (_c *MockROWriteAheadBuffer_BufferedBytes_Call) Run(run func()) *MockROWriteAheadBuffer_BufferedBytes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockROWriteAheadBuffer_BufferedBytes_Call) Return(_a0 int) *MockROWriteAheadBuffer_BufferedBytes_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockROWriteAheadBuffer_BufferedBytes_Call) RunAndReturn(run func() int) *MockROWriteAheadBuffer_BufferedBytes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ReadFromExclusiveTimeTick provides a mock function with given fields: ctx, timetick
 func (_m *MockROWriteAheadBuffer) ReadFromExclusiveTimeTick(ctx context.Context, timetick uint64) (*wab.WriteAheadBufferReader, error) {
 	ret := _m.Called(ctx, timetick)
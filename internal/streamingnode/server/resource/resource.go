@@ -15,6 +15,7 @@ import (
 	"github.com/milvus-io/milvus/internal/types"
 	"github.com/milvus-io/milvus/internal/util/idalloc"
 	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/v2/util/syncutil"
 	"github.com/milvus-io/milvus/pkg/v2/util/typeutil"
 )
@@ -68,7 +69,26 @@ func Apply(opts ...optResourceInit) {
 // Done finish all initialization of resources.
 func Done() {
 	r.segmentAssignStatsManager = stats.NewStatsManager()
-	r.timeTickInspector = tinspector.NewTimeTickSyncInspector()
+	r.timeTickInspector = tinspector.NewTimeTickSyncInspector(
+		tinspector.OptStallWatchdog(
+			paramtable.Get().StreamingCfg.TimeTickStallThreshold.GetAsDurationByParse(),
+			paramtable.Get().StreamingCfg.TimeTickCancelStalledSync.GetAsBool(),
+		),
+		tinspector.OptMaxConcurrentPersistedSyncs(paramtable.Get().StreamingCfg.TimeTickMaxConcurrentPersistedSyncs.GetAsInt()),
+		tinspector.OptMaxPendingTriggers(paramtable.Get().StreamingCfg.TimeTickMaxPendingTriggers.GetAsInt()),
+		tinspector.OptSyncOnRegistration(paramtable.Get().StreamingCfg.TimeTickSyncOnRegistration.GetAsBool()),
+		tinspector.OptSyncTracing(paramtable.Get().StreamingCfg.TimeTickTraceSync.GetAsBool()),
+		tinspector.OptDryRun(paramtable.Get().StreamingCfg.TimeTickDryRun.GetAsBool()),
+		tinspector.OptAudit(
+			paramtable.Get().StreamingCfg.TimeTickAuditInterval.GetAsDurationByParse(),
+			paramtable.Get().StreamingCfg.TimeTickAuditTolerance.GetAsDurationByParse(),
+		),
+		tinspector.OptTriggerCoalescing(
+			paramtable.Get().StreamingCfg.TimeTickTriggerCoalesceWindow.GetAsDurationByParse(),
+			paramtable.Get().StreamingCfg.TimeTickTriggerCoalesceMaxDelay.GetAsDurationByParse(),
+		),
+		tinspector.OptMaxClockSkew(paramtable.Get().StreamingCfg.TimeTickMaxClockSkew.GetAsDurationByParse()),
+	)
 	r.syncMgr = syncmgr.NewSyncManager(r.chunkManager)
 	r.wbMgr = writebuffer.NewManager(r.syncMgr)
 	r.wbMgr.Start()
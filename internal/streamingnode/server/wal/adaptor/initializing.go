@@ -38,17 +38,21 @@ func buildInterceptorParams(ctx context.Context, underlyingWALImpls walimpls.WAL
 	)
 	mvccManager := mvcc.NewMVCCManager(msg.TimeTick())
 	return &interceptors.InterceptorBuildParam{
-		ChannelInfo:          underlyingWALImpls.Channel(),
-		WAL:                  syncutil.NewFuture[wal.WAL](),
-		InitializedTimeTick:  msg.TimeTick(),
-		InitializedMessageID: msg.MessageID(),
-		WriteAheadBuffer:     writeAheadBuffer,
-		MVCCManager:          mvccManager,
+		ChannelInfo:                 underlyingWALImpls.Channel(),
+		WAL:                         syncutil.NewFuture[wal.WAL](),
+		InitializedTimeTick:         msg.TimeTick(),
+		InitializedMessageID:        msg.MessageID(),
+		InitializedTimeTickSequence: msg.TimeTick(),
+		WriteAheadBuffer:            writeAheadBuffer,
+		MVCCManager:                 mvccManager,
 	}, nil
 }
 
 // sendFirstTimeTick sends the first timetick message to walimpls.
 // It is used to make a fence operation with the underlying walimpls and get the timetick and last message id to recover the wal state.
+// The fence is stamped with its time tick as the sequence, so the sequence of time ticks starts a new epoch at each open of the wal.
+// The time tick of the fence is greater than all the time ticks emitted before, none of which is less than its sequence,
+// so the sequence never goes backwards across the opens even if the non-persisted time ticks are never written into the wal.
 func sendFirstTimeTick(ctx context.Context, underlyingWALImpls walimpls.WALImpls) (message.ImmutableMessage, error) {
 	logger := resource.Resource().Logger()
 	logger.Info("start to sync first time tick")
@@ -95,7 +99,7 @@ func sendFirstTimeTick(ctx context.Context, underlyingWALImpls walimpls.WALImpls
 			lastErr = errors.Wrap(err, "allocate timestamp failed")
 			continue
 		}
-		msg := timetick.NewTimeTickMsg(ts, nil, sourceID, true)
+		msg := timetick.NewSequencedTimeTickMsg(ts, nil, sourceID, ts, true)
 		msgID, err := underlyingWALImpls.Append(ctx, msg)
 		if err != nil {
 			lastErr = errors.Wrap(err, "send first timestamp message failed")
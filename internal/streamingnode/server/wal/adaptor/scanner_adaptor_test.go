@@ -13,6 +13,7 @@ import (
 	"github.com/milvus-io/milvus/internal/mocks/streamingnode/server/wal/interceptors/timetick/mock_inspector"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/resource"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal"
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/inspector"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/metricsutil"
 	"github.com/milvus-io/milvus/pkg/v2/mocks/streaming/mock_walimpls"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/options"
@@ -32,7 +33,7 @@ func TestScannerAdaptorReadError(t *testing.T) {
 	operator.EXPECT().Channel().Return(types.PChannelInfo{})
 	operator.EXPECT().Sync(mock.Anything, mock.Anything).Run(func(ctx context.Context, forcePersisted bool) {
 		sig1.Close()
-	})
+	}).Return(inspector.SyncResult{}, nil)
 	wb := mock_wab.NewMockROWriteAheadBuffer(t)
 	operator.EXPECT().WriteAheadBuffer().Return(wb)
 	resource.Resource().TimeTickInspector().RegisterSyncOperator(operator)
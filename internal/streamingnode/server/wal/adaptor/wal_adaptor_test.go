@@ -18,6 +18,7 @@ import (
 	"github.com/milvus-io/milvus/internal/streamingnode/server/resource"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors"
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/inspector"
 	"github.com/milvus-io/milvus/internal/util/streamingutil/status"
 	"github.com/milvus-io/milvus/pkg/v2/mocks/streaming/mock_walimpls"
 	"github.com/milvus-io/milvus/pkg/v2/mocks/streaming/util/mock_message"
@@ -67,7 +68,7 @@ func TestWalAdaptorReadFail(t *testing.T) {
 	writeAheadBuffer := mock_wab.NewMockROWriteAheadBuffer(t)
 	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
 	operator.EXPECT().Channel().Return(types.PChannelInfo{}).Maybe()
-	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return().Maybe()
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil).Maybe()
 	operator.EXPECT().WriteAheadBuffer().Return(writeAheadBuffer).Maybe()
 	resource.Resource().TimeTickInspector().RegisterSyncOperator(
 		operator,
@@ -98,7 +99,7 @@ func TestWALAdaptor(t *testing.T) {
 
 	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
 	operator.EXPECT().Channel().Return(types.PChannelInfo{})
-	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return()
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil)
 	buffer := mock_wab.NewMockROWriteAheadBuffer(t)
 	operator.EXPECT().WriteAheadBuffer().Return(buffer)
 	resource.Resource().TimeTickInspector().RegisterSyncOperator(operator)
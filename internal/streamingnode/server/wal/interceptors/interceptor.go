@@ -17,12 +17,13 @@ type (
 )
 
 type InterceptorBuildParam struct {
-	ChannelInfo          types.PChannelInfo
-	WAL                  *syncutil.Future[wal.WAL] // The wal final object, can be used after interceptor is ready.
-	InitializedTimeTick  uint64                    // The time tick is initialized, can be used to skip the time tick append.
-	InitializedMessageID message.MessageID         // The message id of the last message in the wal, can be used to skip the message id append.
-	WriteAheadBuffer     *wab.WriteAheadBuffer     // The write ahead buffer for the wal, used to erase the subscription of underlying wal.
-	MVCCManager          *mvcc.MVCCManager         // The MVCC manager for the wal, can be used to get the latest mvcc timetick.
+	ChannelInfo                 types.PChannelInfo
+	WAL                         *syncutil.Future[wal.WAL] // The wal final object, can be used after interceptor is ready.
+	InitializedTimeTick         uint64                    // The time tick is initialized, can be used to skip the time tick append.
+	InitializedMessageID        message.MessageID         // The message id of the last message in the wal, can be used to skip the message id append.
+	InitializedTimeTickSequence uint64                    // The sequence of the first time tick, which starts a new epoch of the sequence at each open of the wal.
+	WriteAheadBuffer            *wab.WriteAheadBuffer     // The write ahead buffer for the wal, used to erase the subscription of underlying wal.
+	MVCCManager                 *mvcc.MVCCManager         // The MVCC manager for the wal, can be used to get the latest mvcc timetick.
 }
 
 // InterceptorBuilder is the interface to build a interceptor.
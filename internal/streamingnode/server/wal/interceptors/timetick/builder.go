@@ -3,7 +3,9 @@ package timetick
 import (
 	"github.com/milvus-io/milvus/internal/streamingnode/server/resource"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors"
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/inspector"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/txn"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
 )
 
 var _ interceptors.InterceptorBuilder = (*interceptorBuilder)(nil)
@@ -22,7 +24,8 @@ type interceptorBuilder struct{}
 func (b *interceptorBuilder) Build(param *interceptors.InterceptorBuildParam) interceptors.Interceptor {
 	operator := newTimeTickSyncOperator(param)
 	// initialize operation can be async to avoid block the build operation.
-	resource.Resource().TimeTickInspector().RegisterSyncOperator(operator)
+	// the time ticks are stamped with the id of the node, so the dual writes can be told apart.
+	resource.Resource().TimeTickInspector().RegisterSyncOperator(operator, inspector.OptSourceID(paramtable.GetNodeID()))
 	return &timeTickAppendInterceptor{
 		operator:   operator,
 		txnManager: txn.NewTxnManager(param.ChannelInfo),
@@ -0,0 +1,132 @@
+package inspector
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// defaultAuditTolerance is the default tolerance of the watermark lagging behind the mvcc of the pchannel.
+const defaultAuditTolerance = 10 * time.Second
+
+// errAuditProbe is the cause of the context to probe the write ahead buffer without blocking.
+var errAuditProbe = errors.New("time tick audit probe")
+
+// AuditFindingKind is the kind of the discrepancy found by the audit.
+type AuditFindingKind string
+
+const (
+	// AuditWatermarkBehindMVCC means the watermark lags behind the mvcc of the pchannel longer than the tolerance,
+	// the time ticks are persisted into the wal but not emitted to the readers.
+	AuditWatermarkBehindMVCC AuditFindingKind = "watermark_behind_mvcc"
+	// AuditWatermarkAheadOfMVCC means the watermark is ahead of the mvcc of the pchannel,
+	// the readers may see a time tick that the wal never confirms.
+	AuditWatermarkAheadOfMVCC AuditFindingKind = "watermark_ahead_of_mvcc"
+	// AuditWatermarkAheadOfBuffer means the watermark is ahead of the last time tick of the write ahead buffer,
+	// the readers of the buffer cannot be served up to the watermark.
+	AuditWatermarkAheadOfBuffer AuditFindingKind = "watermark_ahead_of_buffer"
+)
+
+// AuditFinding is a discrepancy of the emitted watermark of a pchannel found by the audit.
+type AuditFinding struct {
+	Timestamp time.Time        `json:"timestamp"`
+	Channel   string           `json:"channel"`
+	Kind      AuditFindingKind `json:"kind"`
+	Watermark uint64           `json:"watermark"` // the emitted watermark of the pchannel.
+	MVCC      uint64           `json:"mvcc"`      // the mvcc of the pchannel, 0 if it's not audited.
+	Lag       time.Duration    `json:"lag"`       // the physical lag of the watermark behind the mvcc.
+}
+
+// auditor audits all the pchannels at the interval.
+func (s *timeTickSyncInspectorImpl) auditor() {
+	defer s.wg.Done()
+
+	ticker := s.clock.NewTicker(s.auditInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.taskNotifier.Context().Done():
+			return
+		case <-ticker.Chan():
+			s.AuditNow()
+		}
+	}
+}
+
+// AuditNow audits the emitted watermarks of all syncable pchannels against their mvcc and write ahead buffers.
+func (s *timeTickSyncInspectorImpl) AuditNow() []AuditFinding {
+	findings := make([]AuditFinding, 0)
+	now := s.clock.Now()
+	s.channels.Range(func(name string, channel *syncChannel) bool {
+		// the read-only or suspended pchannel doesn't emit time ticks, so it's expected to lag behind.
+		if !channel.IsSyncable() {
+			return true
+		}
+		findings = append(findings, s.auditChannel(now, name, channel)...)
+		return true
+	})
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Channel != findings[j].Channel {
+			return findings[i].Channel < findings[j].Channel
+		}
+		return findings[i].Kind < findings[j].Kind
+	})
+	for _, finding := range findings {
+		metrics.WALTimeTickAuditFindingTotal.WithLabelValues(paramtable.GetStringNodeID(), finding.Channel, string(finding.Kind)).Inc()
+		log.Warn("time tick audit finds a discrepancy of the watermark",
+			zap.String("channel", finding.Channel),
+			zap.String("kind", string(finding.Kind)),
+			zap.Uint64("watermark", finding.Watermark),
+			zap.Time("watermarkTime", WatermarkPhysicalTime(finding.Watermark)),
+			zap.Uint64("mvcc", finding.MVCC),
+			zap.Duration("lag", finding.Lag),
+			zap.Duration("tolerance", s.auditTolerance))
+	}
+	return findings
+}
+
+// auditChannel audits the emitted watermark of the pchannel.
+// The watermark is loaded before the mvcc and the buffer, which are always updated before the watermark by the sync,
+// so a concurrent sync can only make the watermark lag behind, which is covered by the tolerance.
+func (s *timeTickSyncInspectorImpl) auditChannel(now time.Time, name string, channel *syncChannel) []AuditFinding {
+	watermark, ok := s.watermarks.Get(name)
+	if !ok || watermark == 0 {
+		// the watermark is unknown until the first time tick is synced.
+		return nil
+	}
+	newFinding := func(kind AuditFindingKind) AuditFinding {
+		return AuditFinding{Timestamp: now, Channel: name, Kind: kind, Watermark: watermark}
+	}
+
+	var findings []AuditFinding
+	if mvccManager := channel.operator.MVCCManager(); mvccManager != nil {
+		pchannelMVCC := mvccManager.GetMVCCOfPChannel()
+		if pchannelMVCC < watermark {
+			finding := newFinding(AuditWatermarkAheadOfMVCC)
+			finding.MVCC = pchannelMVCC
+			findings = append(findings, finding)
+		} else if lag := WatermarkPhysicalTime(pchannelMVCC).Sub(WatermarkPhysicalTime(watermark)); lag > s.auditTolerance {
+			finding := newFinding(AuditWatermarkBehindMVCC)
+			finding.MVCC = pchannelMVCC
+			finding.Lag = lag
+			findings = append(findings, finding)
+		}
+	}
+	if buffer := channel.operator.WriteAheadBuffer(); buffer != nil {
+		// the read blocks only if the last time tick of the buffer is behind the watermark, so probe it with a canceled context.
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(errAuditProbe)
+		// the evicted or closed buffer cannot be audited, which is not a discrepancy of the watermark.
+		if _, err := buffer.ReadFromExclusiveTimeTick(ctx, watermark-1); errors.Is(err, errAuditProbe) {
+			findings = append(findings, newFinding(AuditWatermarkAheadOfBuffer))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,48 @@
+package inspector
+
+import "sync"
+
+// newBackpressureSignal creates a new inactive backpressure signal.
+func newBackpressureSignal() *backpressureSignal {
+	return &backpressureSignal{
+		ch: make(chan struct{}),
+	}
+}
+
+// backpressureSignal is a level-triggered signal of backpressure, the channel is closed while the signal is active,
+// and replaced by a new one when the signal is cleared.
+type backpressureSignal struct {
+	mu     sync.Mutex
+	active bool
+	ch     chan struct{}
+}
+
+// Set activates or clears the signal, returns true if the signal is changed.
+func (s *backpressureSignal) Set(active bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == active {
+		return false
+	}
+	s.active = active
+	if active {
+		close(s.ch)
+	} else {
+		s.ch = make(chan struct{})
+	}
+	return true
+}
+
+// Active returns whether the signal is active.
+func (s *backpressureSignal) Active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// Chan returns the channel that is closed while the signal is active.
+func (s *backpressureSignal) Chan() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ch
+}
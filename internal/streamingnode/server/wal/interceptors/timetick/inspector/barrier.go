@@ -0,0 +1,153 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+)
+
+// FlushBarrierError is returned by FlushBarrier if some of the pchannels fail to persist their captured watermarks.
+type FlushBarrierError struct {
+	Failures map[string]error // the error of each failed pchannel, keyed by the pchannel name.
+}
+
+func (e *FlushBarrierError) Error() string {
+	names := make([]string, 0, len(e.Failures))
+	for name := range e.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	failures := make([]string, 0, len(names))
+	for _, name := range names {
+		failures = append(failures, fmt.Sprintf("%s: %s", name, e.Failures[name]))
+	}
+	return fmt.Sprintf("flush barrier failed on pchannels [%s]", strings.Join(failures, ", "))
+}
+
+// Unwrap returns the errors of the failed pchannels, so the error can be matched by errors.Is.
+func (e *FlushBarrierError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, err := range e.Failures {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// GlobalWatermarkError is returned by WaitForGlobalWatermark if some of the pchannels don't reach the target before the context is done.
+type GlobalWatermarkError struct {
+	Target   uint64
+	Laggards map[string]uint64 // the watermark of each lagging pchannel, keyed by the pchannel name.
+	Err      error             // the error of context.
+}
+
+func (e *GlobalWatermarkError) Error() string {
+	names := make([]string, 0, len(e.Laggards))
+	for name := range e.Laggards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	laggards := make([]string, 0, len(names))
+	for _, name := range names {
+		laggards = append(laggards, fmt.Sprintf("%s: %d", name, e.Laggards[name]))
+	}
+	return fmt.Sprintf("global watermark doesn't reach %d, laggards [%s]: %s", e.Target, strings.Join(laggards, ", "), e.Err)
+}
+
+// Unwrap returns the error of context, so the error can be matched by errors.Is.
+func (e *GlobalWatermarkError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForGlobalWatermark blocks until the minimum watermark of all registered pchannels reaches the target.
+func (s *timeTickSyncInspectorImpl) WaitForGlobalWatermark(ctx context.Context, target uint64) error {
+	ctx, cancel := s.withInspectorContext(ctx)
+	defer cancel()
+
+	if err := s.watermarks.WaitForMin(ctx, target); err != nil {
+		laggards := s.watermarks.Below(target)
+		log.Warn("WaitForGlobalWatermark failed", zap.Uint64("target", target), zap.Any("laggards", laggards), zap.Error(err))
+		return &GlobalWatermarkError{Target: target, Laggards: laggards, Err: err}
+	}
+	return nil
+}
+
+// FlushBarrier force persists the time ticks of the pchannels up to their watermarks captured at the call.
+func (s *timeTickSyncInspectorImpl) FlushBarrier(ctx context.Context, infos []types.PChannelInfo) (map[string]uint64, error) {
+	ctx, cancel := s.withInspectorContext(ctx)
+	defer cancel()
+
+	// capture the watermarks before any sync is triggered, so the barrier is the moment of the call.
+	captured := make(map[string]uint64, len(infos))
+	failures := make(map[string]error)
+	for _, info := range infos {
+		if watermark, ok := s.watermarks.Get(info.Name); ok {
+			captured[info.Name] = watermark
+		} else {
+			failures[info.Name] = ErrSyncOperatorNotFound
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	persisted := make(map[string]uint64, len(captured))
+	for _, info := range infos {
+		watermark, ok := captured[info.Name]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(info types.PChannelInfo) {
+			defer wg.Done()
+			timeTick, err := s.persistUpTo(ctx, info, watermark)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[info.Name] = err
+				return
+			}
+			persisted[info.Name] = timeTick
+		}(info)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		log.Warn("FlushBarrier failed", zap.Any("captured", captured), zap.Any("persisted", persisted), zap.Any("failures", failures))
+		return persisted, &FlushBarrierError{Failures: failures}
+	}
+	log.Info("FlushBarrier", zap.Any("captured", captured), zap.Any("persisted", persisted))
+	return persisted, nil
+}
+
+// persistUpTo triggers the force persisted syncs of the pchannel until a time tick that is not less than the watermark is persisted,
+// returns the last persisted time tick of the pchannel.
+func (s *timeTickSyncInspectorImpl) persistUpTo(ctx context.Context, info types.PChannelInfo, watermark uint64) (uint64, error) {
+	for {
+		channel, ok := s.channels.Get(info.Name)
+		if !ok {
+			return 0, ErrSyncOperatorNotFound
+		}
+		if channel.IsReadOnly() {
+			return 0, ErrReadOnly
+		}
+		if persisted := channel.SyncState().LastPersistedTimeTick; persisted >= watermark {
+			return persisted, nil
+		}
+		current, ok := s.watermarks.Get(info.Name)
+		if !ok {
+			return 0, ErrSyncOperatorNotFound
+		}
+		// the triggered sync may be merged into an in-flight sync that is not persisted,
+		// so keep triggering until a persisted time tick reaches the watermark.
+		s.TriggerSync(info, true)
+		if err := s.watermarks.WaitForAdvance(ctx, info.Name, current); err != nil {
+			return 0, err
+		}
+	}
+}
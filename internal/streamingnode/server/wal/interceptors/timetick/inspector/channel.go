@@ -0,0 +1,316 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/atomic"
+)
+
+// newSyncChannel creates a new sync channel for the operator.
+func newSyncChannel(operator TimeTickSyncOperator, strategy SyncStrategy) *syncChannel {
+	return &syncChannel{
+		operator: operator,
+		strategy: strategy,
+		weight:   defaultSyncWeight,
+
+		backpressure: newBackpressureSignal(),
+	}
+}
+
+// syncChannel is the sync state of one pchannel that is managed by the inspector.
+type syncChannel struct {
+	operator          TimeTickSyncOperator
+	epoch             uint64      // the registration epoch of the pchannel, increased on every registration.
+	readOnly          atomic.Bool // the time tick sync is stopped forever if the channel is read-only.
+	persistedSyncs    atomic.Int64
+	nonPersistedSyncs atomic.Int64
+	lastSyncTime      atomic.Time // the time of the last sync that sent a timetick message.
+	sourceID          int64       // the source id of the emitted time ticks, immutable after registration.
+	hasSourceID       bool
+
+	stateMu    sync.Mutex
+	syncState  SyncState      // the handover state, only advances.
+	syncErrors SyncErrorStats // the count of failed syncs by category.
+	// the time tick of the oldest emitted timetick message that is not covered by the last persisted one,
+	// 0 if all the emitted time ticks are persisted.
+	oldestUnpersisted uint64
+
+	// the reason of maintenance, the time tick sync is suspended until resumed, nil if not in maintenance.
+	maintenance atomic.Pointer[string]
+
+	// the last rejected watermark regression, nil if the watermark never regresses.
+	lastRegression atomic.Pointer[WatermarkRegression]
+
+	// the backpressure to the producers of the channel, updated by the background goroutine of inspector.
+	backpressure *backpressureSignal
+
+	// the limiter of persisted syncs, replaced when the budget of its tenant is redistributed, nil means no limit.
+	limiter atomic.Pointer[persistedSyncLimiter]
+
+	// the periodic sync schedule, only updated by the background goroutine of inspector.
+	strategy     SyncStrategy
+	nextSyncTime atomic.Time // zero means the channel should be synced at next tick.
+
+	// the weight of the channel in the fair queue of triggered syncs, and the tag of its last queued sync,
+	// only accessed by the background goroutine of inspector.
+	weight            float64
+	virtualFinishTime float64
+
+	// the triggered sync that is deferred by the rate limit, only updated by the background goroutine of inspector.
+	deferredTrigger        atomic.Bool
+	deferredForcePersisted bool
+
+	// the time of the first and the last coalesced triggered sync, zero if there's no coalesced one,
+	// only updated by the background goroutine of inspector.
+	coalescedSince atomic.Time
+	lastCoalesced  time.Time
+
+	// a dispatched persisted sync of the channel is in flight, set by the background goroutine of inspector and cleared once it returns.
+	// The other syncs of the channel are skipped until then, so the time ticks of the channel are still emitted one by one.
+	dispatched atomic.Bool
+
+	// the last observed watermark and the time since when it's unchanged, protected by stateMu.
+	observedWatermark uint64
+	watermarkSince    time.Time
+}
+
+// IsDue returns whether the periodic sync of the channel is due at now.
+// The schedule is rounded to the nearest tick, so the jitter of ticker doesn't skip a tick.
+func (c *syncChannel) IsDue(now time.Time, tickInterval time.Duration) bool {
+	return !now.Before(c.nextSyncTime.Load().Add(-tickInterval / 2))
+}
+
+// Reschedule schedules the next periodic sync by the state of the last periodic sync.
+func (c *syncChannel) Reschedule(state SyncStrategyState) {
+	c.nextSyncTime.Store(c.strategy.NextSyncTime(state))
+}
+
+// NextSyncTime returns the scheduled time of the next periodic sync, zero if the channel should be synced at next tick.
+func (c *syncChannel) NextSyncTime() time.Time {
+	return c.nextSyncTime.Load()
+}
+
+// DeferTrigger defers the triggered sync to the next tick, the force persisted flag is merged.
+func (c *syncChannel) DeferTrigger(forcePersisted bool) {
+	c.deferredTrigger.Store(true)
+	c.deferredForcePersisted = c.deferredForcePersisted || forcePersisted
+}
+
+// TakeDeferredTrigger takes the deferred triggered sync, false if there's no deferred one.
+func (c *syncChannel) TakeDeferredTrigger() (forcePersisted bool, ok bool) {
+	forcePersisted, ok = c.deferredForcePersisted, c.deferredTrigger.Swap(false)
+	c.deferredForcePersisted = false
+	return forcePersisted, ok
+}
+
+// HasDeferredTrigger returns whether there's a deferred triggered sync.
+func (c *syncChannel) HasDeferredTrigger() bool {
+	return c.deferredTrigger.Load()
+}
+
+// CoalesceTrigger coalesces the non-persisted triggered sync at now into the pending coalesced one.
+func (c *syncChannel) CoalesceTrigger(now time.Time) {
+	if c.coalescedSince.Load().IsZero() {
+		c.coalescedSince.Store(now)
+	}
+	c.lastCoalesced = now
+}
+
+// IsCoalescedTriggerDue returns whether the coalesced triggered sync should be performed at now,
+// i.e. no trigger arrives within the window since the last one, or maxDelay has passed since the first one.
+// The deadlines are rounded to the nearest tick like IsDue.
+func (c *syncChannel) IsCoalescedTriggerDue(now time.Time, window time.Duration, maxDelay time.Duration, tickInterval time.Duration) bool {
+	since := c.coalescedSince.Load()
+	if since.IsZero() {
+		return false
+	}
+	now = now.Add(tickInterval / 2)
+	return !now.Before(c.lastCoalesced.Add(window)) || !now.Before(since.Add(maxDelay))
+}
+
+// TakeCoalescedTrigger takes the coalesced triggered sync, false if there's no coalesced one.
+func (c *syncChannel) TakeCoalescedTrigger() bool {
+	ok := c.HasCoalescedTrigger()
+	c.coalescedSince.Store(time.Time{})
+	c.lastCoalesced = time.Time{}
+	return ok
+}
+
+// HasCoalescedTrigger returns whether there's a coalesced triggered sync.
+func (c *syncChannel) HasCoalescedTrigger() bool {
+	return !c.coalescedSince.Load().IsZero()
+}
+
+// ObserveWatermark records the watermark of the channel observed at now,
+// the stable time of the watermark is reset if the watermark changes.
+func (c *syncChannel) ObserveWatermark(now time.Time, watermark uint64) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.watermarkSince.IsZero() || watermark != c.observedWatermark {
+		c.observedWatermark = watermark
+		c.watermarkSince = now
+	}
+}
+
+// WatermarkStableSince returns the time since when the watermark of the channel is unchanged.
+func (c *syncChannel) WatermarkStableSince() time.Time {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.watermarkSince
+}
+
+// SetReadOnly marks the channel as read-only.
+func (c *syncChannel) SetReadOnly() {
+	c.readOnly.Store(true)
+}
+
+// SuspendForMaintenance suspends the time tick sync of the channel for the reason, the reason is replaced if it's already suspended.
+func (c *syncChannel) SuspendForMaintenance(reason string) {
+	c.maintenance.Store(&reason)
+}
+
+// ResumeFromMaintenance resumes the time tick sync of the channel, false if the channel is not in maintenance.
+func (c *syncChannel) ResumeFromMaintenance() bool {
+	return c.maintenance.Swap(nil) != nil
+}
+
+// MaintenanceReason returns the reason of maintenance, false if the channel is not in maintenance.
+func (c *syncChannel) MaintenanceReason() (string, bool) {
+	reason := c.maintenance.Load()
+	if reason == nil {
+		return "", false
+	}
+	return *reason, true
+}
+
+// IsSyncable returns whether the time tick sync of the channel can be performed.
+func (c *syncChannel) IsSyncable() bool {
+	return !c.readOnly.Load() && c.maintenance.Load() == nil
+}
+
+// NextSequence returns the sequence number of the next emitted timetick message.
+func (c *syncChannel) NextSequence() uint64 {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.syncState.LastEmittedSequence + 1
+}
+
+// ObserveSyncResult records the result of a sync operation happened at syncTime, which is assigned with the sequence number.
+// It returns the physical delta of the emitted time tick over the last emitted one,
+// false if there's no time tick emitted before or the time tick doesn't advance.
+func (c *syncChannel) ObserveSyncResult(syncTime time.Time, result SyncResult, sequence uint64) (time.Duration, bool) {
+	if !result.IsSent() {
+		return 0, false
+	}
+	c.lastSyncTime.Store(syncTime)
+	c.stateMu.Lock()
+	lastEmitted := c.syncState.LastEmittedTimeTick
+	c.syncState.LastEmittedTimeTick = max(c.syncState.LastEmittedTimeTick, result.TimeTick)
+	c.syncState.LastEmittedSequence = max(c.syncState.LastEmittedSequence, sequence)
+	if result.Persisted {
+		c.syncState.LastPersistedTimeTick = max(c.syncState.LastPersistedTimeTick, result.TimeTick)
+	} else if c.oldestUnpersisted == 0 && result.TimeTick > c.syncState.LastPersistedTimeTick {
+		c.oldestUnpersisted = result.TimeTick
+	}
+	c.clearPersistedLocked()
+	c.stateMu.Unlock()
+	if result.Persisted {
+		c.persistedSyncs.Inc()
+	} else {
+		c.nonPersistedSyncs.Inc()
+	}
+	if lastEmitted == 0 || result.TimeTick <= lastEmitted {
+		return 0, false
+	}
+	return WatermarkPhysicalTime(result.TimeTick).Sub(WatermarkPhysicalTime(lastEmitted)), true
+}
+
+// ObserveSyncError records a failed sync of the category.
+func (c *syncChannel) ObserveSyncError(category SyncErrorCategory) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.syncErrors.add(category)
+}
+
+// IsReadOnly returns whether the channel is read-only.
+func (c *syncChannel) IsReadOnly() bool {
+	return c.readOnly.Load()
+}
+
+// LastSyncTime returns the time of the last sync that sent a timetick message, zero if there's no sync.
+func (c *syncChannel) LastSyncTime() time.Time {
+	return c.lastSyncTime.Load()
+}
+
+// SyncState returns the handover state of the channel.
+func (c *syncChannel) SyncState() SyncState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.syncState
+}
+
+// ImportSyncState replaces the handover state of the channel, the state should not be below the current one.
+func (c *syncChannel) ImportSyncState(state SyncState) error {
+	if err := state.validate(); err != nil {
+		return err
+	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if state.LastPersistedTimeTick < c.syncState.LastPersistedTimeTick ||
+		state.LastEmittedTimeTick < c.syncState.LastEmittedTimeTick ||
+		state.LastEmittedSequence < c.syncState.LastEmittedSequence {
+		return errors.Wrapf(ErrSyncStateRollback, "import %+v, current %+v", state, c.syncState)
+	}
+	c.syncState = state
+	c.clearPersistedLocked()
+	return nil
+}
+
+// RecoverSyncState raises the handover state of the channel to the last persisted time tick and its sequence number
+// recovered from wal, returns the state after recovery.
+func (c *syncChannel) RecoverSyncState(lastPersistedTimeTick uint64, lastPersistedSequence uint64) SyncState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.syncState.LastPersistedTimeTick = max(c.syncState.LastPersistedTimeTick, lastPersistedTimeTick)
+	c.syncState.LastEmittedTimeTick = max(c.syncState.LastEmittedTimeTick, lastPersistedTimeTick)
+	c.syncState.LastEmittedSequence = max(c.syncState.LastEmittedSequence, lastPersistedSequence)
+	c.clearPersistedLocked()
+	return c.syncState
+}
+
+// OldestUnpersisted returns the time tick of the oldest emitted timetick message that is not persisted yet,
+// false if all the emitted time ticks are persisted.
+func (c *syncChannel) OldestUnpersisted() (uint64, bool) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.oldestUnpersisted, c.oldestUnpersisted != 0
+}
+
+// clearPersistedLocked clears the oldest unpersisted time tick once it's covered by the last persisted one,
+// the emitted time ticks after the persisted one are not tracked until the next non-persisted sync.
+// It should be called with stateMu held.
+func (c *syncChannel) clearPersistedLocked() {
+	if c.oldestUnpersisted <= c.syncState.LastPersistedTimeTick {
+		c.oldestUnpersisted = 0
+	}
+}
+
+// Stats returns the sync statistics of the channel.
+func (c *syncChannel) Stats() SyncStats {
+	reason, _ := c.MaintenanceReason()
+	c.stateMu.Lock()
+	syncState, syncErrors := c.syncState, c.syncErrors
+	c.stateMu.Unlock()
+	return SyncStats{
+		PersistedSyncs:    c.persistedSyncs.Load(),
+		NonPersistedSyncs: c.nonPersistedSyncs.Load(),
+		MaintenanceReason: reason,
+		SourceID:          c.sourceID,
+
+		LastEmittedSequence: syncState.LastEmittedSequence,
+
+		Errors: syncErrors,
+	}
+}
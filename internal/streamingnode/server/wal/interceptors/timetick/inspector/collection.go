@@ -0,0 +1,33 @@
+package inspector
+
+import (
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+	"github.com/milvus-io/milvus/pkg/v2/util/funcutil"
+)
+
+// CollectionResolver derives the collection of the pchannel, false if the pchannel doesn't belong to one collection.
+type CollectionResolver func(pchannel types.PChannelInfo) (int64, bool)
+
+// DefaultCollectionResolver derives the collection id from the name in the form of vchannel,
+// e.g. the collection of `by-dev-rootcoord-dml_1_100v0` is 100.
+// The pchannel shared by collections, e.g. `by-dev-rootcoord-dml_1`, doesn't belong to any collection.
+func DefaultCollectionResolver(pchannel types.PChannelInfo) (int64, bool) {
+	collectionID := funcutil.GetCollectionIDFromVChannel(pchannel.Name)
+	return collectionID, collectionID >= 0
+}
+
+// StatsByCollection returns the sync statistics of the registered pchannels aggregated by collection.
+func (s *timeTickSyncInspectorImpl) StatsByCollection() map[int64]CollectionSyncStats {
+	result := make(map[int64]CollectionSyncStats)
+	s.channels.Range(func(_ string, channel *syncChannel) bool {
+		collectionID, ok := s.collections(channel.operator.Channel())
+		if !ok {
+			return true
+		}
+		stats := result[collectionID]
+		stats.add(channel.Stats())
+		result[collectionID] = stats
+		return true
+	})
+	return result
+}
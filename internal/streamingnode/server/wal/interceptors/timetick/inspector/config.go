@@ -0,0 +1,64 @@
+package inspector
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/config"
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// inspectorCounter makes the identifier of config handler unique for every inspector.
+var inspectorCounter = atomic.NewInt64(0)
+
+// getSyncInterval returns the interval of the periodic sync, proxy.timeTickInterval is used if it's not set.
+func getSyncInterval() time.Duration {
+	if interval := paramtable.Get().StreamingCfg.TimeTickSyncInterval.GetAsDurationByParse(); interval > 0 {
+		return interval
+	}
+	return paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+}
+
+// watchConfig watches the sync configuration of inspector,
+// the change is applied by the background goroutine on the next scheduling cycle.
+func (s *timeTickSyncInspectorImpl) watchConfig() {
+	s.configHandler = config.NewHandler(fmt.Sprintf("timetick.inspector.%d", inspectorCounter.Inc()), func(event *config.Event) {
+		if !event.HasUpdated {
+			return
+		}
+		select {
+		case s.configChanged <- struct{}{}:
+		default:
+		}
+	})
+	params := paramtable.Get()
+	params.Watch(params.StreamingCfg.TimeTickSyncInterval.Key, s.configHandler)
+	params.Watch(params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.Key, s.configHandler)
+	params.WatchKeyPrefix(params.StreamingCfg.TimeTickTenantPersistedSyncRate.KeyPrefix, s.configHandler)
+}
+
+// unwatchConfig stops watching the sync configuration of inspector.
+func (s *timeTickSyncInspectorImpl) unwatchConfig() {
+	params := paramtable.Get()
+	params.Unwatch(params.StreamingCfg.TimeTickSyncInterval.Key, s.configHandler)
+	params.Unwatch(params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.Key, s.configHandler)
+	params.Unwatch(params.StreamingCfg.TimeTickTenantPersistedSyncRate.KeyPrefix, s.configHandler)
+}
+
+// applyConfig applies the latest sync configuration, returns true if the sync interval is changed.
+func (s *timeTickSyncInspectorImpl) applyConfig() bool {
+	s.tenants.Refresh()
+
+	interval := getSyncInterval()
+	previous := s.interval.Swap(interval)
+	if previous == interval {
+		return false
+	}
+	log.Info("sync interval of time tick inspector is changed",
+		zap.Duration("previous", previous), zap.Duration("interval", interval))
+	return true
+}
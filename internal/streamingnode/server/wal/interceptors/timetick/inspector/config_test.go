@@ -0,0 +1,88 @@
+package inspector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/milvus/pkg/v2/config"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// counterOperator is an operator that advances its timetick by one on every sync.
+type counterOperator struct {
+	channelOnlyOperator
+	timeTick atomic.Uint64
+}
+
+func (o *counterOperator) Sync(ctx context.Context, forcePersisted bool) (SyncResult, error) {
+	return SyncResult{TimeTick: o.timeTick.Inc(), Persisted: forcePersisted}, nil
+}
+
+func TestInspectorHotReloadConfig(t *testing.T) {
+	paramtable.Init()
+	params := paramtable.Get()
+	interval := params.ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+	assert.Equal(t, interval, getSyncInterval())
+
+	clock := clockwork.NewFakeClock()
+	start := clock.Now()
+	recorder := NewSyncDecisionRecorder()
+	s := NewTimeTickSyncInspector(OptClock(clock), OptSyncDecisionRecorder(recorder)).(*timeTickSyncInspectorImpl)
+	defer s.Close()
+	operator := &counterOperator{channelOnlyOperator: channelOnlyOperator{pchannel: types.PChannelInfo{Name: "test_1", Term: 1}}}
+	s.RegisterSyncOperator(operator)
+	defer s.UnregisterSyncOperator(operator)
+	channel, _ := s.channels.Get("test_1")
+
+	waitDecisions := func(n int) {
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == n
+		}, 5*time.Second, time.Millisecond)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(interval)
+	waitDecisions(1)
+
+	// the new interval is applied on the next scheduling cycle.
+	params.Save(params.StreamingCfg.TimeTickSyncInterval.Key, (3 * interval).String())
+	defer params.Reset(params.StreamingCfg.TimeTickSyncInterval.Key)
+	params.Save(params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.Key, "10")
+	defer params.Reset(params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.Key)
+	assert.Nil(t, channel.limiter.Load())
+	s.configHandler.OnEvent(&config.Event{Key: params.StreamingCfg.TimeTickSyncInterval.Key, HasUpdated: true})
+	assert.Eventually(t, func() bool {
+		return s.interval.Load() == 3*interval
+	}, 5*time.Second, time.Millisecond)
+	assert.NotNil(t, channel.limiter.Load())
+
+	// no sync at the old interval, the stopped ticker keeps its pending sleeper until the old interval is passed.
+	clock.BlockUntil(2)
+	clock.Advance(2 * interval)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, recorder.Decisions(), 1)
+	clock.Advance(interval)
+	waitDecisions(2)
+	clock.BlockUntil(1)
+	clock.Advance(3 * interval)
+	waitDecisions(3)
+
+	decisions := recorder.Decisions()
+	assert.Equal(t, start.Add(interval), decisions[0].Timestamp)
+	assert.Equal(t, start.Add(4*interval), decisions[1].Timestamp)
+	assert.Equal(t, start.Add(7*interval), decisions[2].Timestamp)
+	for _, decision := range decisions {
+		assert.Equal(t, SyncCauseTimeTick, decision.Cause)
+	}
+
+	// the event without update is ignored.
+	params.Save(params.StreamingCfg.TimeTickSyncInterval.Key, interval.String())
+	s.configHandler.OnEvent(&config.Event{Key: params.StreamingCfg.TimeTickSyncInterval.Key})
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 3*interval, s.interval.Load())
+}
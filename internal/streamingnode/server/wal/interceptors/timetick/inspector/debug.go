@@ -0,0 +1,32 @@
+package inspector
+
+import "time"
+
+// InspectorDebugState is the snapshot of the time tick sync inspector state.
+type InspectorDebugState struct {
+	Channels      []ChannelDebugState `json:"channels"`        // the registered pchannels in order of name.
+	GlobalMinMVCC uint64              `json:"global_min_mvcc"` // 0 if there's no pchannel.
+
+	DroppedSyncFailures uint64 `json:"dropped_sync_failures"` // the count of failure events dropped by a slow consumer.
+	StalledSyncs        uint64 `json:"stalled_syncs"`         // the count of syncs reported as stalled by the watchdog.
+	BufferPressure      bool   `json:"buffer_pressure"`       // the write ahead buffers are under pressure.
+	DroppedSinkTicks    uint64 `json:"dropped_sink_ticks"`    // the count of emitted ticks dropped by the slow tick sinks.
+	ParkedTriggers      int    `json:"parked_triggers"`       // the count of triggered syncs waiting for the registration of their pchannels.
+	PendingTriggers     int    `json:"pending_triggers"`      // the count of triggered syncs not taken by the sync goroutine yet.
+	ShedTriggers        uint64 `json:"shed_triggers"`         // the count of triggered syncs shed by the backlog cap.
+}
+
+// ChannelDebugState is the snapshot of the sync state of one pchannel.
+type ChannelDebugState struct {
+	Channel               string    `json:"channel"`
+	Term                  int64     `json:"term"`
+	ReadOnly              bool      `json:"read_only"`
+	Watermark             uint64    `json:"watermark"`
+	WatermarkTime         time.Time `json:"watermark_time"` // the physical time of the watermark.
+	LastSyncTime          time.Time `json:"last_sync_time"`
+	PendingSync           bool      `json:"pending_sync"` // a triggered sync is waiting to be performed.
+	PendingForcePersisted bool      `json:"pending_force_persisted"`
+	CoalescedSync         bool      `json:"coalesced_sync"` // a coalesced triggered sync is waiting for the window or cap.
+	Backpressure          bool      `json:"backpressure"`   // the producers of the pchannel should be throttled.
+	Stats                 SyncStats `json:"stats"`
+}
@@ -0,0 +1,127 @@
+package inspector
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/milvus/internal/util/streamingutil/status"
+)
+
+// defaultFailureBufferSize is the default buffer size of the sync failure events.
+const defaultFailureBufferSize = 128
+
+var (
+	// ErrSyncBufferFull can be wrapped by the operator if the sync fails because the buffer of the time ticks is full.
+	ErrSyncBufferFull = errors.New("time tick sync buffer is full")
+	// ErrSyncFatal can be wrapped by the operator if the sync fails and never succeeds by retrying.
+	ErrSyncFatal = errors.New("time tick sync is fatal")
+)
+
+// SyncErrorCategory is the category of a failed sync.
+type SyncErrorCategory string
+
+const (
+	SyncErrorBufferFull    SyncErrorCategory = "buffer_full"    // the buffer of the time ticks is full.
+	SyncErrorTimeout       SyncErrorCategory = "timeout"        // the sync is timeout or canceled by the stall watchdog.
+	SyncErrorPersistenceIO SyncErrorCategory = "persistence_io" // the time tick is failed to be written into wal.
+	SyncErrorFatal         SyncErrorCategory = "fatal"          // the sync never succeeds by retrying.
+)
+
+// categorizeSyncError returns the category of the error of a failed sync.
+// The error that is not recognized is categorized as SyncErrorPersistenceIO,
+// because the sync fails mostly when the time tick is written into wal.
+func categorizeSyncError(err error) SyncErrorCategory {
+	var streamingErr *status.StreamingError
+	switch {
+	case errors.Is(err, ErrSyncFatal), errors.As(err, &streamingErr) && streamingErr.IsUnrecoverable():
+		return SyncErrorFatal
+	case errors.Is(err, ErrSyncBufferFull):
+		return SyncErrorBufferFull
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrSyncStalled):
+		return SyncErrorTimeout
+	default:
+		return SyncErrorPersistenceIO
+	}
+}
+
+// SyncErrorStats is the count of failed syncs of one pchannel by category.
+type SyncErrorStats struct {
+	BufferFull    int64 `json:"buffer_full"`
+	Timeout       int64 `json:"timeout"`
+	PersistenceIO int64 `json:"persistence_io"`
+	Fatal         int64 `json:"fatal"`
+}
+
+// add counts a failed sync of the category.
+func (s *SyncErrorStats) add(category SyncErrorCategory) {
+	switch category {
+	case SyncErrorBufferFull:
+		s.BufferFull++
+	case SyncErrorTimeout:
+		s.Timeout++
+	case SyncErrorFatal:
+		s.Fatal++
+	default:
+		s.PersistenceIO++
+	}
+}
+
+// Total returns the count of all failed syncs.
+func (s SyncErrorStats) Total() int64 {
+	return s.BufferFull + s.Timeout + s.PersistenceIO + s.Fatal
+}
+
+// SyncFailureEvent is the event of a failed sync.
+type SyncFailureEvent struct {
+	Timestamp time.Time // the clock time when the sync is performed.
+	Channel   string
+	Category  SyncErrorCategory
+	Err       error
+}
+
+// newFailureNotifier creates a new failure notifier with the buffer size.
+func newFailureNotifier(bufferSize int) *failureNotifier {
+	if bufferSize <= 0 {
+		bufferSize = defaultFailureBufferSize
+	}
+	return &failureNotifier{
+		ch: make(chan SyncFailureEvent, bufferSize),
+	}
+}
+
+// failureNotifier emits the sync failure events into a bounded channel,
+// the oldest event is dropped if the channel is full, so a slow consumer never blocks the sync.
+type failureNotifier struct {
+	ch      chan SyncFailureEvent
+	dropped atomic.Uint64
+}
+
+// Notify emits the failure event, it's only called by the background goroutine of inspector.
+func (n *failureNotifier) Notify(event SyncFailureEvent) {
+	for {
+		select {
+		case n.ch <- event:
+			return
+		default:
+		}
+		// the consumer may receive concurrently, so the drop may find an empty channel.
+		select {
+		case <-n.ch:
+			n.dropped.Inc()
+		default:
+		}
+	}
+}
+
+// Chan returns the channel of the failure events.
+func (n *failureNotifier) Chan() <-chan SyncFailureEvent {
+	return n.ch
+}
+
+// Dropped returns the count of dropped events.
+func (n *failureNotifier) Dropped() uint64 {
+	return n.dropped.Load()
+}
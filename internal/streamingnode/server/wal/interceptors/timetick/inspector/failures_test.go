@@ -0,0 +1,29 @@
+package inspector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureNotifier(t *testing.T) {
+	n := newFailureNotifier(2)
+	for i := 0; i < 5; i++ {
+		n.Notify(SyncFailureEvent{
+			Timestamp: time.Now(),
+			Channel:   fmt.Sprintf("p%d", i),
+			Err:       errors.New("sync failed"),
+		})
+	}
+	// the oldest events are dropped.
+	assert.Equal(t, uint64(3), n.Dropped())
+	assert.Equal(t, "p3", (<-n.Chan()).Channel)
+	assert.Equal(t, "p4", (<-n.Chan()).Channel)
+	assert.Len(t, n.Chan(), 0)
+
+	// the default buffer size is used for a non-positive size.
+	assert.Equal(t, defaultFailureBufferSize, cap(newFailureNotifier(0).ch))
+}
@@ -0,0 +1,93 @@
+package inspector
+
+import (
+	"sync"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+)
+
+// defaultSyncWeight is the default weight of a pchannel in the fair queue of triggered syncs.
+const defaultSyncWeight = 1.0
+
+// newFairQueue creates a new fair queue.
+func newFairQueue() *fairQueue {
+	return &fairQueue{
+		pending: make(map[string]*fairQueueItem),
+	}
+}
+
+// fairQueue schedules the triggered syncs of pchannels by weighted fair queuing.
+// Every sync costs one unit of service, a pending sync is tagged with a virtual finish time
+// that advances by 1/weight from the later of the virtual time of the queue and the last tag of its pchannel,
+// and the pending sync with the smallest tag is served first.
+// So when the sync goroutine is saturated, each pchannel gets syncs in proportion to its weight,
+// and an idle pchannel doesn't accumulate credit to burst later.
+type fairQueue struct {
+	mu          sync.Mutex
+	virtualTime float64 // the tag of the last served sync.
+	pending     map[string]*fairQueueItem
+}
+
+// fairQueueItem is a pending triggered sync of a pchannel.
+type fairQueueItem struct {
+	channel        *syncChannel
+	forcePersisted bool
+	finishTime     float64
+}
+
+// Push adds a triggered sync of the channel, the force persisted flag is merged if the channel is already pending.
+func (q *fairQueue) Push(channel *syncChannel, forcePersisted bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	name := channel.operator.Channel().Name
+	if item, ok := q.pending[name]; ok && item.channel == channel {
+		item.forcePersisted = item.forcePersisted || forcePersisted
+		return
+	}
+	finishTime := max(q.virtualTime, channel.virtualFinishTime) + 1/channel.weight
+	channel.virtualFinishTime = finishTime
+	q.pending[name] = &fairQueueItem{
+		channel:        channel,
+		forcePersisted: forcePersisted,
+		finishTime:     finishTime,
+	}
+}
+
+// Pop removes and returns the pending sync with the smallest virtual finish time, ties are broken by name of pchannel.
+func (q *fairQueue) Pop() (*syncChannel, bool, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var next *fairQueueItem
+	var nextName string
+	for name, item := range q.pending {
+		if next == nil || item.finishTime < next.finishTime || (item.finishTime == next.finishTime && name < nextName) {
+			next, nextName = item, name
+		}
+	}
+	if next == nil {
+		return nil, false, false
+	}
+	delete(q.pending, nextName)
+	q.virtualTime = next.finishTime
+	return next.channel, next.forcePersisted, true
+}
+
+// Len returns the count of pending syncs.
+func (q *fairQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Pending returns the pending syncs and whether they are force persisted.
+func (q *fairQueue) Pending() map[types.PChannelInfo]bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := make(map[types.PChannelInfo]bool, len(q.pending))
+	for _, item := range q.pending {
+		pending[item.channel.operator.Channel()] = item.forcePersisted
+	}
+	return pending
+}
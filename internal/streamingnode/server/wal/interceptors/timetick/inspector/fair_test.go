@@ -0,0 +1,73 @@
+package inspector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+)
+
+// channelOnlyOperator is an operator that only reports its pchannel.
+type channelOnlyOperator struct {
+	TimeTickSyncOperator
+	pchannel types.PChannelInfo
+}
+
+func (o *channelOnlyOperator) Channel() types.PChannelInfo {
+	return o.pchannel
+}
+
+func newWeightedChannel(name string, weight float64) *syncChannel {
+	channel := newSyncChannel(&channelOnlyOperator{pchannel: types.PChannelInfo{Name: name, Term: 1}}, nil)
+	OptSyncWeight(weight)(channel)
+	return channel
+}
+
+func TestFairQueue(t *testing.T) {
+	q := newFairQueue()
+	_, _, ok := q.Pop()
+	assert.False(t, ok)
+
+	// the pending sync is merged.
+	a := newWeightedChannel("a", 1)
+	q.Push(a, false)
+	q.Push(a, true)
+	assert.Equal(t, 1, q.Len())
+	assert.Equal(t, map[types.PChannelInfo]bool{a.operator.Channel(): true}, q.Pending())
+	channel, forcePersisted, ok := q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, a, channel)
+	assert.True(t, forcePersisted)
+
+	// the non-positive weight is ignored.
+	assert.Equal(t, defaultSyncWeight, newWeightedChannel("z", 0).weight)
+
+	// the saturated channels are served in proportion to their weights.
+	channels := []*syncChannel{newWeightedChannel("b", 1), newWeightedChannel("c", 2), newWeightedChannel("d", 4)}
+	for _, channel := range channels {
+		q.Push(channel, false)
+	}
+	served := make(map[*syncChannel]int)
+	for i := 0; i < 700; i++ {
+		channel, _, ok := q.Pop()
+		assert.True(t, ok)
+		served[channel]++
+		q.Push(channel, false)
+	}
+	assert.InDelta(t, 100, served[channels[0]], 1)
+	assert.InDelta(t, 200, served[channels[1]], 1)
+	assert.InDelta(t, 400, served[channels[2]], 1)
+
+	// an idle channel doesn't accumulate credit to burst, it gets its share from now on.
+	q.Push(a, false)
+	servedA := 0
+	for i := 0; i < 16; i++ {
+		channel, _, _ := q.Pop()
+		if channel == a {
+			servedA++
+		}
+		q.Push(channel, false)
+	}
+	assert.InDelta(t, 2, servedA, 1)
+}
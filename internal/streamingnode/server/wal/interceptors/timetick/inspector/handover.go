@@ -0,0 +1,23 @@
+package inspector
+
+import (
+	"github.com/cockroachdb/errors"
+)
+
+// ErrSyncStateRollback is returned if the imported sync state is below the current one.
+var ErrSyncStateRollback = errors.New("sync state rollback")
+
+// SyncState is the minimal sync state of a pchannel that is carried by the handover between streaming nodes.
+type SyncState struct {
+	LastPersistedTimeTick uint64 // the time tick of the last timetick message persisted into wal.
+	LastEmittedTimeTick   uint64 // the time tick of the last timetick message sent, persisted or not.
+	LastEmittedSequence   uint64 // the sequence number of the last timetick message sent, 0 if there's no one sent.
+}
+
+// validate checks whether the state is self-consistent.
+func (s SyncState) validate() error {
+	if s.LastPersistedTimeTick > s.LastEmittedTimeTick {
+		return errors.Newf("last persisted time tick %d is greater than last emitted time tick %d", s.LastPersistedTimeTick, s.LastEmittedTimeTick)
+	}
+	return nil
+}
@@ -1,92 +1,869 @@
 package inspector
 
 import (
+	"context"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
+	"github.com/milvus-io/milvus/pkg/v2/config"
 	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
 	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
 	"github.com/milvus-io/milvus/pkg/v2/util/syncutil"
 	"github.com/milvus-io/milvus/pkg/v2/util/typeutil"
 )
 
+// closedCh is a closed channel that is always ready to receive.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
 // NewTimeTickSyncInspector creates a new time tick sync inspector.
-func NewTimeTickSyncInspector() TimeTickSyncInspector {
+func NewTimeTickSyncInspector(opts ...InspectorOption) TimeTickSyncInspector {
 	inspector := &timeTickSyncInspectorImpl{
-		taskNotifier: syncutil.NewAsyncTaskNotifier[struct{}](),
-		syncNotifier: newSyncNotifier(),
-		operators:    typeutil.NewConcurrentMap[string, TimeTickSyncOperator](),
+		taskNotifier:    syncutil.NewAsyncTaskNotifier[struct{}](),
+		registerCond:    syncutil.NewContextCond(&sync.Mutex{}),
+		triggers:        newFairQueue(),
+		parked:          newParkedTriggers(),
+		channels:        typeutil.NewConcurrentMap[string, *syncChannel](),
+		dispatchedSyncs: typeutil.NewConcurrentMap[*syncChannel, *inflightSync](),
+		watermarks:      newWatermarkManager(),
+		epochs:          make(map[string]uint64),
+		tenants:         newTenantLimiters(DefaultTenantResolver),
+		collections:     DefaultCollectionResolver,
+		sinks:           newTickSinks(),
+		clock:           clockwork.NewRealClock(),
+		interval:        atomic.NewDuration(getSyncInterval()),
+
+		auditTolerance: defaultAuditTolerance,
+
+		configChanged: make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(inspector)
 	}
+	inspector.syncNotifier = newSyncNotifier(inspector.maxPendingTriggers)
+	inspector.failures = newFailureNotifier(inspector.failureBufferSize)
+	inspector.throughput = newThroughputCounter(inspector.throughputWindow)
+	inspector.persistedSyncs = newPersistedSyncSemaphore(inspector.maxConcurrentPersistedSyncs)
+	inspector.watchConfig()
 	go inspector.background()
+	if inspector.stallThreshold > 0 {
+		inspector.wg.Add(1)
+		go inspector.watchdog()
+	}
+	if inspector.auditInterval > 0 {
+		inspector.wg.Add(1)
+		go inspector.auditor()
+	}
 	return inspector
 }
 
 type timeTickSyncInspectorImpl struct {
 	taskNotifier *syncutil.AsyncTaskNotifier[struct{}]
-	syncNotifier *syncNotifier
-	operators    *typeutil.ConcurrentMap[string, TimeTickSyncOperator]
+	syncNotifier *syncNotifier   // the triggered syncs that are not taken by the background goroutine yet.
+	triggers     *fairQueue      // the triggered syncs that are taken from the notifier but not served yet.
+	parked       *parkedTriggers // the triggered syncs of the pchannels that are not registered yet.
+	channels     *typeutil.ConcurrentMap[string, *syncChannel]
+	watermarks   *watermarkManager
+	tenants      *tenantLimiters
+	collections  CollectionResolver // derive the collection of pchannels for the aggregated stats.
+	sinks        *tickSinks
+	clock        clockwork.Clock
+	interval     *atomic.Duration      // the tick interval, which is the resolution of the periodic sync.
+	recorder     *SyncDecisionRecorder // record the sync decisions, only used in test.
+
+	failureBufferSize int
+	failures          *failureNotifier
+
+	throughputWindow time.Duration
+	throughput       *throughputCounter // count the syncs of all pchannels over the sliding window.
+
+	registerCond *syncutil.ContextCond // broadcast when a sync operator is registered.
+
+	epochMu sync.Mutex
+	epochs  map[string]uint64 // the last registration epoch of pchannels, kept after unregistration.
+
+	configHandler config.EventHandler // watch the sync configuration.
+	configChanged chan struct{}       // notified when the sync configuration is changed.
+
+	stallThreshold    time.Duration // the watchdog is disabled if it's not positive.
+	cancelStalledSync bool
+	inflight          atomic.Pointer[inflightSync]                         // the in-flight sync of the background goroutine, nil if there's no sync in flight.
+	dispatchedSyncs   *typeutil.ConcurrentMap[*syncChannel, *inflightSync] // the in-flight dispatched persisted syncs.
+	stalledSyncs      atomic.Uint64                                        // the count of the stalled syncs reported by the watchdog.
+	wg                sync.WaitGroup                                       // wait for the watchdog, the auditor and the dispatched syncs.
+
+	bufferPressure atomic.Bool // the write ahead buffers of all pchannels are under pressure.
+
+	maxConcurrentPersistedSyncs int
+	persistedSyncs              *persistedSyncSemaphore // limit the concurrent persisted syncs, nil if unlimited and the persisted syncs are performed one by one.
+
+	maxPendingTriggers int           // the cap of the triggered syncs in the notifier, 0 means unlimited.
+	shedTriggers       atomic.Uint64 // the count of the triggered syncs shed by the backlog cap.
+
+	auditInterval  time.Duration // the periodic audit is disabled if it's not positive.
+	auditTolerance time.Duration // the tolerated lag of the watermark behind the mvcc.
+
+	coalesceWindow time.Duration // the coalescing of triggered syncs is disabled if it's not positive.
+	coalesceCap    time.Duration // the max delay of the coalesced sync since its first trigger.
+
+	maxClockSkew time.Duration // the synced time tick ahead of the clock is clamped, disabled if it's not positive.
+
+	syncOnRegistration bool // trigger a sync once the pchannel is registered, so the readers get a baseline time tick.
+	traceSync          bool // trace each performed sync with a span.
+	dryRun             bool // report the would-be syncs without performing them.
 }
 
 func (s *timeTickSyncInspectorImpl) TriggerSync(pChannelInfo types.PChannelInfo, persisted bool) {
-	s.syncNotifier.AddAndNotify(pChannelInfo, persisted)
+	if shed, ok := s.syncNotifier.AddAndNotify(pChannelInfo, persisted); ok {
+		s.reportShedTrigger(shed)
+	}
+}
+
+// reportShedTrigger reports the triggered sync that is shed by the backlog cap.
+func (s *timeTickSyncInspectorImpl) reportShedTrigger(shed shedTrigger) {
+	syncType := "memory"
+	if shed.persisted {
+		syncType = "persistent"
+	}
+	total := s.shedTriggers.Inc()
+	metrics.WALTimeTickShedTriggerTotal.WithLabelValues(paramtable.GetStringNodeID(), syncType).Inc()
+	log.RatedWarn(10, "time tick sync backlog is full, the triggered sync is shed",
+		zap.String("channel", shed.info.Name),
+		zap.Int64("term", shed.info.Term),
+		zap.Bool("forcePersisted", shed.persisted),
+		zap.Int("maxPendingTriggers", s.maxPendingTriggers),
+		zap.Uint64("shedTriggers", total))
 }
 
 // GetOperator gets the operator by pchannel info.
 func (s *timeTickSyncInspectorImpl) MustGetOperator(pChannelInfo types.PChannelInfo) TimeTickSyncOperator {
-	operator, ok := s.operators.Get(pChannelInfo.Name)
+	channel, ok := s.channels.Get(pChannelInfo.Name)
 	if !ok {
 		panic("sync operator not found, critical bug in code")
 	}
-	return operator
+	return channel.operator
+}
+
+// SetReadOnly marks the pchannel as read-only.
+func (s *timeTickSyncInspectorImpl) SetReadOnly(pChannelInfo types.PChannelInfo) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		log.Warn("SetReadOnly on a sync operator that is not registered", zap.String("channel", pChannelInfo.Name))
+		return
+	}
+	log.Info("SetReadOnly", zap.String("channel", pChannelInfo.Name))
+	channel.SetReadOnly()
+}
+
+// SuspendForMaintenance suspends the time tick sync of the pchannel for maintenance.
+func (s *timeTickSyncInspectorImpl) SuspendForMaintenance(pChannelInfo types.PChannelInfo, reason string) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		log.Warn("SuspendForMaintenance on a sync operator that is not registered", zap.String("channel", pChannelInfo.Name), zap.String("reason", reason))
+		return
+	}
+	log.Info("SuspendForMaintenance", zap.String("channel", pChannelInfo.Name), zap.String("reason", reason))
+	channel.SuspendForMaintenance(reason)
+}
+
+// ResumeFromMaintenance resumes the time tick sync of the pchannel and triggers a catch-up sync.
+func (s *timeTickSyncInspectorImpl) ResumeFromMaintenance(pChannelInfo types.PChannelInfo) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		log.Warn("ResumeFromMaintenance on a sync operator that is not registered", zap.String("channel", pChannelInfo.Name))
+		return
+	}
+	if !channel.ResumeFromMaintenance() {
+		return
+	}
+	log.Info("ResumeFromMaintenance", zap.String("channel", pChannelInfo.Name))
+	// the catch-up sync is force persisted, so the force persisted syncs dropped during the maintenance are covered.
+	s.TriggerSync(pChannelInfo, true)
+}
+
+// OnBecomeLeader emits a force persisted catch-up time tick of the pchannel at the recovered watermark.
+func (s *timeTickSyncInspectorImpl) OnBecomeLeader(pChannelInfo types.PChannelInfo) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		// the trigger is parked and served once the pchannel is registered, which recovers the watermark by itself.
+		log.Info("OnBecomeLeader before the sync operator is registered", zap.String("channel", pChannelInfo.Name))
+		s.TriggerSync(pChannelInfo, true)
+		return
+	}
+	if channel.IsReadOnly() {
+		log.Warn("OnBecomeLeader on a read-only sync operator, ignored", zap.String("channel", pChannelInfo.Name))
+		return
+	}
+	// the previous leader may persist time ticks after the registration, so recover again before the catch-up sync.
+	// the live watermark is kept if it's already ahead of the wal, the recovery only moves it forward.
+	timeTick, sequence, recoverable, err := s.lastPersisted(channel)
+	if err != nil {
+		log.Warn("recover sync state from wal on leadership change failed", zap.String("channel", pChannelInfo.Name), zap.Error(err))
+	} else if current, ok := s.watermarks.Get(pChannelInfo.Name); recoverable && (!ok || timeTick > current) {
+		s.recoverSyncState(channel, timeTick, sequence)
+	}
+	log.Info("OnBecomeLeader", zap.String("channel", pChannelInfo.Name), zap.Uint64("recoveredTimeTick", timeTick))
+	s.TriggerSync(pChannelInfo, true)
 }
 
 // RegisterSyncOperator registers a sync operator.
-func (s *timeTickSyncInspectorImpl) RegisterSyncOperator(operator TimeTickSyncOperator) {
+func (s *timeTickSyncInspectorImpl) RegisterSyncOperator(operator TimeTickSyncOperator, opts ...RegisterOption) {
 	log.Info("RegisterSyncOperator", zap.String("channel", operator.Channel().Name))
-	_, loaded := s.operators.GetOrInsert(operator.Channel().Name, operator)
+	channel := newSyncChannel(operator, newDefaultSyncStrategy(s.interval))
+	for _, opt := range opts {
+		opt(channel)
+	}
+	channel.epoch = s.nextEpoch(operator.Channel().Name)
+	_, loaded := s.channels.GetOrInsert(operator.Channel().Name, channel)
 	if loaded {
 		panic("sync operator already exists, critical bug in code")
 	}
+	// the watermark is unknown until the first time tick is synced.
+	s.watermarks.Add(operator.Channel().Name, 0)
+	channel.ObserveWatermark(s.clock.Now(), 0)
+	s.tenants.Add(channel)
+	if _, err := s.RecoverFromWAL(operator.Channel()); err != nil {
+		log.Warn("recover sync state from wal failed, start with a cold state", zap.String("channel", operator.Channel().Name), zap.Error(err))
+	}
+	// serve the trigger that arrives before the registration completes.
+	if forcePersisted, ok := s.parked.Take(operator.Channel()); ok {
+		log.Info("serve the sync triggered before registration", zap.String("channel", operator.Channel().Name), zap.Bool("forcePersisted", forcePersisted))
+		s.TriggerSync(operator.Channel(), forcePersisted)
+	} else if s.syncOnRegistration {
+		// emit the initial time tick without waiting for the first write or the periodic sync.
+		s.TriggerSync(operator.Channel(), false)
+	}
+	// wake up the waiters after the channel is ready to be operated.
+	s.registerCond.LockAndBroadcast()
+	s.registerCond.L.Unlock()
+}
+
+// nextEpoch increases and returns the registration epoch of the pchannel.
+func (s *timeTickSyncInspectorImpl) nextEpoch(pchannel string) uint64 {
+	s.epochMu.Lock()
+	defer s.epochMu.Unlock()
+	s.epochs[pchannel]++
+	return s.epochs[pchannel]
+}
+
+// isCurrentEpoch returns whether the channel is still the current registration of its pchannel.
+func (s *timeTickSyncInspectorImpl) isCurrentEpoch(channel *syncChannel) bool {
+	current, ok := s.channels.Get(channel.operator.Channel().Name)
+	return ok && current.epoch == channel.epoch
+}
+
+// WaitForRegistration waits until the sync operator of the pchannel is registered.
+func (s *timeTickSyncInspectorImpl) WaitForRegistration(ctx context.Context, pChannelInfo types.PChannelInfo) (TimeTickSyncOperator, error) {
+	ctx, cancel := s.withInspectorContext(ctx)
+	defer cancel()
+
+	s.registerCond.L.Lock()
+	for {
+		if channel, ok := s.channels.Get(pChannelInfo.Name); ok {
+			s.registerCond.L.Unlock()
+			return channel.operator, nil
+		}
+		if err := s.registerCond.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// SyncStats returns the sync statistics of the pchannel.
+func (s *timeTickSyncInspectorImpl) SyncStats(pChannelInfo types.PChannelInfo) (SyncStats, error) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return SyncStats{}, ErrSyncOperatorNotFound
+	}
+	return channel.Stats(), nil
 }
 
 // UnregisterSyncOperator unregisters a sync operator.
 func (s *timeTickSyncInspectorImpl) UnregisterSyncOperator(operator TimeTickSyncOperator) {
 	log.Info("UnregisterSyncOperator", zap.String("channel", operator.Channel().Name))
-	_, loaded := s.operators.GetAndRemove(operator.Channel().Name)
+	channel, loaded := s.channels.GetAndRemove(operator.Channel().Name)
 	if !loaded {
 		panic("sync operator not found, critical bug in code")
 	}
+	s.watermarks.Remove(operator.Channel().Name)
+	s.tenants.Remove(channel)
+	metrics.WALTimeTickWatermarkRegressionTotal.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+	metrics.WALTimeTickSyncStallTotal.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+	metrics.WALTimeTickDryRunSyncTotal.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+	metrics.WALTimeTickSyncErrorTotal.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+	metrics.WALTimeTickAuditFindingTotal.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+	metrics.WALTimeTickWatermarkDeltaSeconds.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+	metrics.WALTimeTickClockSkewSeconds.DeletePartialMatch(prometheus.Labels{
+		metrics.WALChannelLabelName: operator.Channel().Name,
+	})
+}
+
+// IsReadable returns whether the timestamp is readable on the pchannel.
+func (s *timeTickSyncInspectorImpl) IsReadable(pChannelInfo types.PChannelInfo, ts uint64) (bool, error) {
+	watermark, ok := s.watermarks.Get(pChannelInfo.Name)
+	if !ok {
+		return false, ErrSyncOperatorNotFound
+	}
+	return watermark >= ts, nil
+}
+
+// RecoverFromWAL recovers the sync state of the pchannel from the last persisted time tick in the wal.
+func (s *timeTickSyncInspectorImpl) RecoverFromWAL(pChannelInfo types.PChannelInfo) (uint64, error) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return 0, ErrSyncOperatorNotFound
+	}
+	timeTick, sequence, recoverable, err := s.lastPersisted(channel)
+	if err != nil || !recoverable {
+		return 0, err
+	}
+	s.recoverSyncState(channel, timeTick, sequence)
+	return timeTick, nil
+}
+
+// lastPersisted returns the last persisted time tick and sequence of the pchannel in the wal,
+// false is returned if the operator is not a WALRecoverableOperator.
+func (s *timeTickSyncInspectorImpl) lastPersisted(channel *syncChannel) (uint64, uint64, bool, error) {
+	recoverable, ok := channel.operator.(WALRecoverableOperator)
+	if !ok {
+		return 0, 0, false, nil
+	}
+	name := channel.operator.Channel().Name
+	timeTick, err := recoverable.LastPersistedTimeTick()
+	if err != nil {
+		return 0, 0, false, errors.Wrapf(err, "get last persisted time tick of pchannel %s", name)
+	}
+	var sequence uint64
+	if sequenceRecoverable, ok := recoverable.(WALSequenceRecoverableOperator); ok {
+		if sequence, err = sequenceRecoverable.LastPersistedSequence(); err != nil {
+			return 0, 0, false, errors.Wrapf(err, "get last persisted sequence of pchannel %s", name)
+		}
+	}
+	return timeTick, sequence, true, nil
+}
+
+// recoverSyncState recovers the sync state of the pchannel from the last persisted time tick and sequence.
+func (s *timeTickSyncInspectorImpl) recoverSyncState(channel *syncChannel, timeTick uint64, sequence uint64) {
+	state := channel.RecoverSyncState(timeTick, sequence)
+	s.advanceWatermark(channel, state.LastEmittedTimeTick, watermarkSourceRecovery)
+	log.Info("RecoverFromWAL", zap.String("channel", channel.operator.Channel().Name), zap.Uint64("lastPersistedTimeTick", timeTick), zap.Any("state", state))
+}
+
+// ExportSyncState exports the handover state of the pchannel.
+func (s *timeTickSyncInspectorImpl) ExportSyncState(pChannelInfo types.PChannelInfo) (SyncState, error) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return SyncState{}, ErrSyncOperatorNotFound
+	}
+	return channel.SyncState(), nil
+}
+
+// ImportSyncState imports the handover state of the pchannel.
+func (s *timeTickSyncInspectorImpl) ImportSyncState(pChannelInfo types.PChannelInfo, state SyncState) error {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return ErrSyncOperatorNotFound
+	}
+	if err := channel.ImportSyncState(state); err != nil {
+		return err
+	}
+	// continue the watermark from the last emitted time tick of the previous node.
+	s.advanceWatermark(channel, state.LastEmittedTimeTick, watermarkSourceHandover)
+	log.Info("ImportSyncState", zap.String("channel", pChannelInfo.Name), zap.Any("state", state))
+	return nil
+}
+
+// SyncForAppend triggers the sync of the pchannel until the watermark reaches appendedTs.
+func (s *timeTickSyncInspectorImpl) SyncForAppend(ctx context.Context, pChannelInfo types.PChannelInfo, appendedTs uint64) error {
+	ctx, cancel := s.withInspectorContext(ctx)
+	defer cancel()
+
+	for {
+		channel, ok := s.channels.Get(pChannelInfo.Name)
+		if !ok {
+			return ErrSyncOperatorNotFound
+		}
+		if channel.IsReadOnly() {
+			return ErrReadOnly
+		}
+		watermark, ok := s.watermarks.Get(pChannelInfo.Name)
+		if !ok {
+			return ErrSyncOperatorNotFound
+		}
+		if watermark >= appendedTs {
+			return nil
+		}
+		// the triggered sync may be merged into an in-flight sync that allocates its time tick before the append,
+		// so keep triggering until the watermark reaches the appended one.
+		s.TriggerSync(pChannelInfo, false)
+		if err := s.watermarks.WaitForAdvance(ctx, pChannelInfo.Name, watermark); err != nil {
+			return err
+		}
+	}
+}
+
+// withInspectorContext returns a context that is also canceled with ErrInspectorClosed when the inspector is closed or aborted.
+func (s *timeTickSyncInspectorImpl) withInspectorContext(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(s.taskNotifier.Context(), func() {
+		cancel(ErrInspectorClosed)
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// LastWatermarkRegression returns the last rejected watermark regression of the pchannel.
+func (s *timeTickSyncInspectorImpl) LastWatermarkRegression(pChannelInfo types.PChannelInfo) (*WatermarkRegression, error) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return nil, ErrSyncOperatorNotFound
+	}
+	return channel.lastRegression.Load(), nil
+}
+
+// OldestUnpersisted returns the time tick of the oldest emitted but not persisted timetick message of the pchannel.
+func (s *timeTickSyncInspectorImpl) OldestUnpersisted(pChannelInfo types.PChannelInfo) (uint64, bool) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return 0, false
+	}
+	return channel.OldestUnpersisted()
+}
+
+// advanceWatermark advances the watermark of the channel, the watermark that goes backwards is rejected and recorded.
+func (s *timeTickSyncInspectorImpl) advanceWatermark(channel *syncChannel, watermark uint64, source string) {
+	name := channel.operator.Channel().Name
+	current, regressed := s.watermarks.Advance(name, watermark)
+	channel.ObserveWatermark(s.clock.Now(), current)
+	if !regressed {
+		return
+	}
+	regression := &WatermarkRegression{
+		Timestamp: s.clock.Now(),
+		Source:    source,
+		Current:   current,
+		Rejected:  watermark,
+	}
+	channel.lastRegression.Store(regression)
+	metrics.WALTimeTickWatermarkRegressionTotal.WithLabelValues(paramtable.GetStringNodeID(), name).Inc()
+	log.Warn("watermark of pchannel goes backwards, rejected",
+		zap.String("channel", name),
+		zap.String("source", source),
+		zap.Uint64("current", current),
+		zap.Time("currentTime", WatermarkPhysicalTime(current)),
+		zap.Uint64("rejected", watermark),
+		zap.Time("rejectedTime", WatermarkPhysicalTime(watermark)))
+}
+
+// GlobalMinMVCC returns the minimum watermark of all registered pchannels.
+func (s *timeTickSyncInspectorImpl) GlobalMinMVCC() (uint64, bool) {
+	return s.watermarks.Min()
 }
 
 // background executes the time tick sync inspector.
 func (s *timeTickSyncInspectorImpl) background() {
 	defer s.taskNotifier.Finish(struct{}{})
 
-	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
-	ticker := time.NewTicker(interval)
+	ticker := s.clock.NewTicker(s.interval.Load())
+	defer func() {
+		ticker.Stop()
+	}()
 	for {
 		select {
 		case <-s.taskNotifier.Context().Done():
 			return
-		case <-ticker.C:
-			s.operators.Range(func(_ string, operator TimeTickSyncOperator) bool {
-				operator.Sync(s.taskNotifier.Context(), false)
+		case <-s.configChanged:
+			if s.applyConfig() {
+				ticker.Stop()
+				ticker = s.clock.NewTicker(s.interval.Load())
+			}
+		case <-ticker.Chan():
+			// sync the due channels in order of name, so the sync decisions are deterministic.
+			now := s.clock.Now()
+			channels := make([]*syncChannel, 0)
+			s.channels.Range(func(_ string, channel *syncChannel) bool {
+				channels = append(channels, channel)
 				return true
 			})
-		case <-s.syncNotifier.WaitChan():
-			signals := s.syncNotifier.Get()
-			for pchannel, persisted := range signals {
-				if operator, ok := s.operators.Get(pchannel.Name); ok {
-					operator.Sync(s.taskNotifier.Context(), persisted)
+			sort.Slice(channels, func(i, j int) bool {
+				return channels[i].operator.Channel().Name < channels[j].operator.Channel().Name
+			})
+			// under buffer pressure, the due syncs are persisted and the pchannels with larger buffers are synced first,
+			// so the buffered messages are confirmed and released as soon as possible.
+			buffered, underPressure := s.observeBufferPressure(channels)
+			if underPressure {
+				sort.SliceStable(channels, func(i, j int) bool {
+					return buffered[channels[i]] > buffered[channels[j]]
+				})
+			}
+			for _, channel := range channels {
+				if s.isStopped() {
+					return
+				}
+				if forcePersisted, ok := channel.TakeDeferredTrigger(); ok {
+					s.doTriggeredSync(channel, forcePersisted)
+				}
+				if channel.IsCoalescedTriggerDue(now, s.coalesceWindow, s.coalesceCap, s.interval.Load()) {
+					channel.TakeCoalescedTrigger()
+					s.doTriggeredSync(channel, false)
 				}
+				if channel.IsDue(now, s.interval.Load()) {
+					decision := s.doSync(channel, SyncCauseTimeTick, underPressure)
+					channel.Reschedule(SyncStrategyState{
+						LastSyncTime: decision.Timestamp,
+						Skipped:      decision.Skipped,
+						Result:       decision.Result,
+						Err:          decision.Err,
+						Dispatched:   decision.Dispatched,
+					})
+				}
+			}
+		case <-s.syncNotifier.WaitChan():
+			s.queueTriggers()
+		case <-s.triggersReady():
+			// serve one triggered sync at a time, so the new triggers and ticks are merged into the schedule.
+			s.queueTriggers()
+			channel, forcePersisted, ok := s.triggers.Pop()
+			if !ok || s.isStopped() {
+				continue
 			}
+			// the channel may be unregistered or re-registered after the sync is queued.
+			if current, ok := s.channels.Get(channel.operator.Channel().Name); ok && current == channel {
+				s.serveTrigger(channel, forcePersisted)
+			}
+		}
+	}
+}
+
+// queueTriggers moves the triggered syncs from the notifier into the fair queue,
+// the triggers of the pchannels that are not registered are parked until the registration.
+func (s *timeTickSyncInspectorImpl) queueTriggers() {
+	for pchannel, forcePersisted := range s.syncNotifier.Get() {
+		var channel *syncChannel
+		if s.parked.ParkIf(pchannel, forcePersisted, func() bool {
+			var ok bool
+			channel, ok = s.channels.Get(pchannel.Name)
+			return !ok
+		}) {
+			continue
+		}
+		s.triggers.Push(channel, forcePersisted)
+	}
+}
+
+// triggersReady returns a closed channel if there are queued triggered syncs, otherwise nil.
+func (s *timeTickSyncInspectorImpl) triggersReady() <-chan struct{} {
+	if s.triggers.Len() == 0 {
+		return nil
+	}
+	return closedCh
+}
+
+// serveTrigger performs the triggered sync, the non-persisted one is coalesced and performed at the tick if the coalescing is enabled.
+func (s *timeTickSyncInspectorImpl) serveTrigger(channel *syncChannel, forcePersisted bool) {
+	if s.coalesceWindow > 0 {
+		if !forcePersisted {
+			channel.CoalesceTrigger(s.clock.Now())
+			return
+		}
+		// the force persisted sync emits a time tick after all the coalesced triggers, so it serves them.
+		channel.TakeCoalescedTrigger()
+	}
+	s.doTriggeredSync(channel, forcePersisted)
+}
+
+// doTriggeredSync performs the triggered sync,
+// it's deferred to the next tick if the channel is rate limited or its dispatched persisted sync is in flight.
+func (s *timeTickSyncInspectorImpl) doTriggeredSync(channel *syncChannel, forcePersisted bool) {
+	if decision := s.doSync(channel, SyncCauseTrigger, forcePersisted); decision.RateLimited || decision.Busy {
+		channel.DeferTrigger(forcePersisted)
+	}
+}
+
+// doSync performs the sync operation of the channel if it's syncable and records the decision.
+func (s *timeTickSyncInspectorImpl) doSync(channel *syncChannel, cause SyncCause, forcePersisted bool) SyncDecision {
+	decision := SyncDecision{
+		Timestamp:      s.clock.Now(),
+		Channel:        channel.operator.Channel().Name,
+		Cause:          cause,
+		ForcePersisted: forcePersisted,
+	}
+	limiter := channel.limiter.Load()
+	_, inMaintenance := channel.MaintenanceReason()
+	switch {
+	case !channel.IsSyncable():
+		decision.Skipped = true
+		decision.Maintenance = inMaintenance && !channel.IsReadOnly()
+	case channel.dispatched.Load():
+		decision.Skipped = true
+		decision.Busy = true
+	case limiter != nil && !limiter.Acquire(decision.Timestamp):
+		// the time tick of the channel is delayed until the budget is refilled.
+		decision.Skipped = true
+		decision.RateLimited = true
+	case s.dryRun:
+		// the would-be sync is reported without calling the operator, so nothing is written into the wal.
+		decision.DryRun = true
+		if limiter != nil {
+			limiter.Release(SyncResult{Persisted: forcePersisted})
 		}
+		s.reportDryRun(decision)
+	case forcePersisted && s.persistedSyncs != nil:
+		// the persisted syncs of pchannels are performed concurrently under the cap,
+		// so a slow persisted sync of one pchannel doesn't halt the time ticks of the others.
+		decision.Dispatched = true
+		s.dispatchSync(channel, decision, limiter)
+		return decision
+	default:
+		ctx, finish := s.startSync(decision.Channel)
+		return s.performSync(ctx, finish, channel, decision, limiter)
 	}
+	return s.completeSync(channel, decision, 0, nil)
+}
+
+// dispatchSync performs the persisted sync of the channel in a new goroutine, which waits for the permit of the persisted syncs first.
+// The background goroutine goes on with the syncs of the other pchannels, the result is recorded once the dispatched sync returns.
+func (s *timeTickSyncInspectorImpl) dispatchSync(channel *syncChannel, decision SyncDecision, limiter *persistedSyncLimiter) {
+	channel.dispatched.Store(true)
+	ctx, finish := s.startDispatchedSync(channel)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer channel.dispatched.Store(false)
+		s.performSync(ctx, finish, channel, decision, limiter)
+	}()
+}
+
+// performSync calls the operator to sync the channel within the context of the sync, finish is called once the operator returns.
+func (s *timeTickSyncInspectorImpl) performSync(ctx context.Context, finish func(), channel *syncChannel, decision SyncDecision, limiter *persistedSyncLimiter) SyncDecision {
+	// the error is already logged by the operator.
+	if channel.hasSourceID {
+		ctx = withSyncSourceID(ctx, channel.sourceID)
+	}
+	sequence := channel.NextSequence()
+	ctx = withSyncSequence(ctx, sequence)
+	ctx, span := s.startSyncSpan(ctx, decision)
+	decision.Result, decision.Err = s.syncOperator(ctx, channel, decision.ForcePersisted)
+	finish()
+	if decision.Err != nil {
+		decision.Result = SyncResult{}
+	}
+	if limiter != nil {
+		limiter.Release(decision.Result)
+	}
+	// the pchannel may be re-registered during the sync,
+	// the result of the stale epoch is discarded so it never advances the watermark of the new registration.
+	if !s.isCurrentEpoch(channel) {
+		log.Info("discard the sync result of a stale registration",
+			zap.String("channel", decision.Channel),
+			zap.Uint64("epoch", channel.epoch),
+			zap.Any("result", decision.Result),
+			zap.Error(decision.Err))
+		decision.Stale = true
+		decision.Result, decision.Err = SyncResult{}, nil
+		span.End(s, decision, syncOutcomeStale)
+		if s.recorder != nil {
+			s.recorder.record(decision)
+		}
+		return decision
+	}
+	return s.completeSync(channel, decision, sequence, span)
+}
+
+// completeSync observes the result of the sync decision and records it.
+func (s *timeTickSyncInspectorImpl) completeSync(channel *syncChannel, decision SyncDecision, sequence uint64, span *syncSpan) SyncDecision {
+	if decision.Err == nil {
+		if delta, ok := channel.ObserveSyncResult(decision.Timestamp, decision.Result, sequence); ok {
+			metrics.WALTimeTickWatermarkDeltaSeconds.WithLabelValues(paramtable.GetStringNodeID(), decision.Channel).Observe(delta.Seconds())
+		}
+		if decision.Result.IsSent() {
+			s.throughput.Record(decision.Timestamp, decision.Result.Persisted)
+			s.advanceWatermark(channel, s.clampSkewedTimeTick(channel, decision.Result.TimeTick), watermarkSourceSync)
+			s.sinks.Emit(emittedTick{
+				info:      channel.operator.Channel(),
+				ts:        decision.Result.TimeTick,
+				persisted: decision.Result.Persisted,
+			})
+		}
+	}
+	span.End(s, decision, syncOutcome(decision))
+	if decision.Err != nil && !s.isStopped() {
+		category := categorizeSyncError(decision.Err)
+		channel.ObserveSyncError(category)
+		metrics.WALTimeTickSyncErrorTotal.WithLabelValues(paramtable.GetStringNodeID(), decision.Channel, string(category)).Inc()
+		s.failures.Notify(SyncFailureEvent{
+			Timestamp: decision.Timestamp,
+			Channel:   decision.Channel,
+			Category:  category,
+			Err:       decision.Err,
+		})
+	}
+	if s.recorder != nil {
+		s.recorder.record(decision)
+	}
+	return decision
+}
+
+// reportDryRun reports the would-be sync in dry-run mode.
+func (s *timeTickSyncInspectorImpl) reportDryRun(decision SyncDecision) {
+	syncType := "memory"
+	if decision.ForcePersisted {
+		syncType = "persistent"
+	}
+	metrics.WALTimeTickDryRunSyncTotal.WithLabelValues(paramtable.GetStringNodeID(), decision.Channel, syncType).Inc()
+	log.RatedInfo(1, "dry-run time tick sync",
+		zap.String("channel", decision.Channel),
+		zap.String("cause", string(decision.Cause)),
+		zap.Time("timestamp", decision.Timestamp),
+		zap.Bool("forcePersisted", decision.ForcePersisted))
+}
+
+// Throughput returns the aggregate sync throughput of all pchannels.
+func (s *timeTickSyncInspectorImpl) Throughput() ThroughputSnapshot {
+	return s.throughput.Snapshot(s.clock.Now())
+}
+
+// IsQuiescent returns whether the pchannel is quiescent, see TimeTickSyncInspector.IsQuiescent.
+func (s *timeTickSyncInspectorImpl) IsQuiescent(pChannelInfo types.PChannelInfo, stableFor time.Duration) (bool, error) {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return false, ErrSyncOperatorNotFound
+	}
+	if inflight := s.inflight.Load(); inflight != nil && inflight.channel == pChannelInfo.Name {
+		return false, nil
+	}
+	if channel.dispatched.Load() {
+		return false, nil
+	}
+	if channel.HasDeferredTrigger() || channel.HasCoalescedTrigger() {
+		return false, nil
+	}
+	if _, ok := s.syncNotifier.Pending()[channel.operator.Channel()]; ok {
+		return false, nil
+	}
+	if _, ok := s.triggers.Pending()[channel.operator.Channel()]; ok {
+		return false, nil
+	}
+	return s.clock.Since(channel.WatermarkStableSince()) >= stableFor, nil
+}
+
+// NextSyncTimes returns the scheduled time of the next periodic sync of each registered pchannel.
+func (s *timeTickSyncInspectorImpl) NextSyncTimes() map[string]time.Time {
+	now := s.clock.Now()
+	result := make(map[string]time.Time)
+	s.channels.Range(func(name string, channel *syncChannel) bool {
+		next := channel.NextSyncTime()
+		if next.Before(now) {
+			next = now
+		}
+		result[name] = next
+		return true
+	})
+	return result
+}
+
+// BackpressureSignal returns the backpressure signal of the pchannel.
+func (s *timeTickSyncInspectorImpl) BackpressureSignal(pChannelInfo types.PChannelInfo) <-chan struct{} {
+	channel, ok := s.channels.Get(pChannelInfo.Name)
+	if !ok {
+		return nil
+	}
+	return channel.backpressure.Chan()
+}
+
+// AddTickSink registers a sink of the emitted time ticks.
+func (s *timeTickSyncInspectorImpl) AddTickSink(sink TickSink) func() {
+	return s.sinks.Add(sink)
+}
+
+// Failures returns the channel of the failed syncs.
+func (s *timeTickSyncInspectorImpl) Failures() <-chan SyncFailureEvent {
+	return s.failures.Chan()
+}
+
+// DebugDump returns a snapshot of the inspector state.
+func (s *timeTickSyncInspectorImpl) DebugDump() InspectorDebugState {
+	pending := s.syncNotifier.Pending()
+	for pchannel, forcePersisted := range s.triggers.Pending() {
+		pending[pchannel] = pending[pchannel] || forcePersisted
+	}
+	state := InspectorDebugState{
+		Channels: make([]ChannelDebugState, 0),
+	}
+	s.channels.Range(func(name string, channel *syncChannel) bool {
+		info := channel.operator.Channel()
+		watermark, _ := s.watermarks.Get(name)
+		forcePersisted, pendingSync := pending[info]
+		state.Channels = append(state.Channels, ChannelDebugState{
+			Channel:               info.Name,
+			Term:                  info.Term,
+			ReadOnly:              channel.IsReadOnly(),
+			Watermark:             watermark,
+			WatermarkTime:         WatermarkPhysicalTime(watermark),
+			LastSyncTime:          channel.LastSyncTime(),
+			PendingSync:           pendingSync,
+			CoalescedSync:         channel.HasCoalescedTrigger(),
+			PendingForcePersisted: forcePersisted,
+			Backpressure:          channel.backpressure.Active(),
+			Stats:                 channel.Stats(),
+		})
+		return true
+	})
+	sort.Slice(state.Channels, func(i, j int) bool {
+		return state.Channels[i].Channel < state.Channels[j].Channel
+	})
+	state.GlobalMinMVCC, _ = s.watermarks.Min()
+	state.DroppedSyncFailures = s.failures.Dropped()
+	state.StalledSyncs = s.stalledSyncs.Load()
+	state.BufferPressure = s.bufferPressure.Load()
+	state.DroppedSinkTicks = s.sinks.Dropped()
+	state.ParkedTriggers = s.parked.Len()
+	state.PendingTriggers = s.syncNotifier.Len()
+	state.ShedTriggers = s.shedTriggers.Load()
+	return state
+}
+
+// isStopped returns true if the inspector is closed or aborted, no more sync should be performed.
+func (s *timeTickSyncInspectorImpl) isStopped() bool {
+	return s.taskNotifier.Context().Err() != nil
+}
+
+// Abort stops all syncs immediately without waiting for the in-flight sync.
+func (s *timeTickSyncInspectorImpl) Abort() {
+	log.Warn("abort the time tick sync inspector, the in-flight sync is cancelled")
+	s.taskNotifier.Cancel()
 }
 
 func (s *timeTickSyncInspectorImpl) Close() {
+	s.unwatchConfig()
 	s.taskNotifier.Cancel()
 	s.taskNotifier.BlockUntilFinish()
+	s.wg.Wait()
+	s.sinks.Close()
 }
@@ -2,12 +2,26 @@ package inspector
 
 import (
 	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
 
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/mvcc"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/wab"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
 )
 
+var (
+	// ErrSyncOperatorNotFound is returned if the sync operator of the pchannel is not registered.
+	ErrSyncOperatorNotFound = errors.New("sync operator not found")
+	// ErrInspectorClosed is returned if the inspector is closed or aborted while waiting.
+	ErrInspectorClosed = errors.New("time tick sync inspector is closed")
+	// ErrReadOnly is returned if the time tick sync of the read-only pchannel is required.
+	ErrReadOnly = errors.New("pchannel is read-only")
+	// ErrSyncStalled is the cause of the context of the in-flight sync that is canceled by the stall watchdog.
+	ErrSyncStalled = errors.New("time tick sync is stalled")
+)
+
 type TimeTickSyncOperator interface {
 	// Channel returns the pchannel info.
 	Channel() types.PChannelInfo
@@ -20,24 +34,251 @@ type TimeTickSyncOperator interface {
 
 	// Sync trigger a sync operation, try to send the timetick message into wal.
 	// Sync operation is a blocking operation, and not thread-safe, will only call in one goroutine.
-	Sync(ctx context.Context, forcePersisted bool)
+	// Return the result of the sent timetick message, a zero result is returned if there's no timetick message sent.
+	Sync(ctx context.Context, forcePersisted bool) (SyncResult, error)
+}
+
+// WALRecoverableOperator is the optional interface of TimeTickSyncOperator,
+// which recovers the sync state of the pchannel from the persisted wal when the operator is registered.
+type WALRecoverableOperator interface {
+	// LastPersistedTimeTick returns the time tick of the last timetick message persisted in the wal.
+	LastPersistedTimeTick() (uint64, error)
+}
+
+// WALSequenceRecoverableOperator is the optional interface of WALRecoverableOperator,
+// which also recovers the sequence number of the emitted time ticks from the persisted wal.
+type WALSequenceRecoverableOperator interface {
+	WALRecoverableOperator
+
+	// LastPersistedSequence returns the sequence number stamped into the last timetick message persisted in the wal.
+	LastPersistedSequence() (uint64, error)
+}
+
+// SyncResult is the result of a sync operation.
+type SyncResult struct {
+	TimeTick  uint64 // the timetick of the sent timetick message, 0 if there's no timetick message sent.
+	Persisted bool   // whether the sent timetick message is persisted into wal.
+}
+
+// IsSent returns whether a timetick message is sent by the sync operation.
+func (r SyncResult) IsSent() bool {
+	return r.TimeTick != 0
 }
 
 // TimeTickSyncInspector is the inspector to sync time tick.
 type TimeTickSyncInspector interface {
 	// TriggerSync adds a pchannel info and notify the sync operation.
 	// manually trigger the sync operation of pchannel.
+	// The trigger of a pchannel that is not registered yet is parked and served once the pchannel of the same term is registered,
+	// so the trigger that races with the registration is never lost.
+	// If the backlog of triggers is capped by OptMaxPendingTriggers and the cap is reached,
+	// the trigger is coalesced into the pending one of another term of the pchannel, or shed if it's non-persisted,
+	// the force persisted trigger replaces a pending non-persisted one, and is only shed if all the pending triggers are persisted.
+	// The shed triggers are logged, counted by metrics and reported in DebugDump.
+	// If the coalescing is enabled by OptTriggerCoalescing, the non-persisted triggers of a pchannel are coalesced into one sync
+	// that is performed at the tick after the window or the max delay.
 	TriggerSync(pChannelInfo types.PChannelInfo, forcePersisted bool)
 
 	// RegisterSyncOperator registers a sync operator.
-	RegisterSyncOperator(operator TimeTickSyncOperator)
+	// The sync state of the pchannel is recovered by RecoverFromWAL on registration, a cold state is used if the recovery fails.
+	// The periodic sync strategy of the pchannel can be set by OptSyncStrategy,
+	// and the weight of the pchannel in the fair scheduling of triggered syncs can be set by OptSyncWeight.
+	RegisterSyncOperator(operator TimeTickSyncOperator, opts ...RegisterOption)
 
 	// MustGetOperator gets the operator by pchannel info, otherwise panic.
 	MustGetOperator(types.PChannelInfo) TimeTickSyncOperator
 
+	// WaitForRegistration blocks until the sync operator of the pchannel is registered and returns it,
+	// the operator is returned immediately if it's already registered.
+	// The error of context is returned if the context is done,
+	// ErrInspectorClosed is returned if the inspector is closed or aborted.
+	WaitForRegistration(ctx context.Context, pChannelInfo types.PChannelInfo) (TimeTickSyncOperator, error)
+
+	// SetReadOnly marks the pchannel as read-only.
+	// The time tick sync of a read-only pchannel is stopped, but the mvcc and write ahead buffer of its operator
+	// can still be queried, so the read-only pchannel reports its frozen watermark (the last synced time tick)
+	// until the consumers catch up.
+	// Different from a pause, a read-only pchannel never resumes the sync until it's unregistered.
+	SetReadOnly(pChannelInfo types.PChannelInfo)
+
+	// SuspendForMaintenance suspends the time tick sync of the pchannel while it's under maintenance, e.g. compaction or flush,
+	// so the emitted time ticks don't interfere with it.
+	// The sync state of the pchannel is kept, the triggered syncs during the maintenance are dropped,
+	// and the reason is reported in SyncStats and DebugDump until the pchannel is resumed.
+	// Different from a read-only pchannel, the sync of a pchannel in maintenance is resumed by ResumeFromMaintenance.
+	SuspendForMaintenance(pChannelInfo types.PChannelInfo, reason string)
+
+	// ResumeFromMaintenance resumes the time tick sync of the pchannel suspended by SuspendForMaintenance,
+	// a force persisted sync is triggered to catch up the time tick, it's a no-op if the pchannel is not in maintenance.
+	ResumeFromMaintenance(pChannelInfo types.PChannelInfo)
+
+	// OnBecomeLeader is called when the node becomes the leader of the pchannel, e.g. after failover,
+	// it recovers the sync state from wal and triggers a force persisted sync immediately,
+	// so the catch-up time tick resumes the read availability of the pchannel without waiting for the periodic sync.
+	// The sync is parked until the registration if the pchannel is not registered yet,
+	// and it's a no-op if the pchannel is read-only.
+	OnBecomeLeader(pChannelInfo types.PChannelInfo)
+
+	// SyncStats returns the sync statistics of the pchannel.
+	// The failed syncs are counted by the category of error, see SyncErrorCategory,
+	// the syncs that fail because the inspector is closed or aborted are not counted.
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	SyncStats(pChannelInfo types.PChannelInfo) (SyncStats, error)
+
+	// StatsByCollection returns the sync statistics of the registered pchannels aggregated by collection,
+	// the collection of a pchannel is derived by the CollectionResolver of inspector.
+	// The pchannels whose collection can't be derived are not reported.
+	StatsByCollection() map[int64]CollectionSyncStats
+
+	// Throughput returns the aggregate sync throughput of all pchannels over the sliding window, for capacity planning.
+	// The syncs of the unregistered pchannels are still counted until they slide out of the window,
+	// and the throughput is slightly underestimated because the last second of the window is not complete yet.
+	Throughput() ThroughputSnapshot
+
+	// NextSyncTimes returns the scheduled time of the next periodic sync of each registered pchannel by the clock of inspector,
+	// for debugging why a pchannel isn't synced.
+	// The schedule is rounded to the nearest tick, and the pchannel that is due at the next tick is reported with the current time.
+	// The triggered syncs are performed immediately and are not reflected.
+	NextSyncTimes() map[string]time.Time
+
+	// RecoverFromWAL recovers the sync state of the pchannel from the last persisted time tick in the wal,
+	// so the restarted node continues the watermark of the pchannel rather than starting cold.
+	// The recovered time tick is returned, 0 is returned if the operator is not a WALRecoverableOperator.
+	// The sequence number of the emitted time ticks is also recovered if the operator is a WALSequenceRecoverableOperator,
+	// so the sequence continues after restart.
+	// The sync state that is already above the recovered time tick is kept.
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	RecoverFromWAL(pChannelInfo types.PChannelInfo) (uint64, error)
+
+	// ExportSyncState exports the handover state of the pchannel, which is carried to the new streaming node
+	// when the pchannel is moved.
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	ExportSyncState(pChannelInfo types.PChannelInfo) (SyncState, error)
+
+	// ImportSyncState imports the handover state exported by the previous streaming node of the pchannel,
+	// so the watermark of the pchannel continues from the previous node.
+	// ErrSyncStateRollback is returned if the state is below the current state of the pchannel,
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	ImportSyncState(pChannelInfo types.PChannelInfo, state SyncState) error
+
+	// SnapshotState serializes the handover states of all registered pchannels into a versioned snapshot,
+	// which is restored by a hot-standby inspector with RestoreState, so it takes over quickly on failover.
+	SnapshotState() []byte
+
+	// RestoreState restores the handover states of the registered pchannels from the snapshot of SnapshotState,
+	// as ImportSyncState does for each pchannel, the pchannels that are not registered are skipped.
+	// ErrSnapshotIncompatible is returned and nothing is restored if the snapshot is malformed or its version is not supported,
+	// ErrSyncStateRollback is returned if the state of some pchannels is below the current one, the other pchannels are still restored.
+	RestoreState(data []byte) error
+
+	// IsReadable returns true if the watermark of the pchannel is not less than the timestamp,
+	// the watermark is read and compared atomically.
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	IsReadable(pChannelInfo types.PChannelInfo, ts uint64) (bool, error)
+
+	// ReplicaWatermark returns the aggregate persisted watermark over the replicas of the same logical channel,
+	// the minimum for strict consistency that every replica can serve, or the maximum so the read sees the most advanced replica.
+	// The persisted watermark of a replica is the time tick of its last timetick message persisted into wal.
+	// ErrSyncOperatorNotFound is returned if any replica is not registered,
+	// and an error is returned if the replica set is empty or the mode is unknown.
+	ReplicaWatermark(infos []types.PChannelInfo, mode MinMax) (uint64, error)
+
+	// LastWatermarkRegression returns the last rejected watermark regression of the pchannel for diagnostics,
+	// nil if the watermark of the pchannel never regresses.
+	// A watermark from a sync result or an imported handover state that is less than the current one is a bug,
+	// it's rejected and counted, so the watermark reported by the inspector never goes backwards.
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	LastWatermarkRegression(pChannelInfo types.PChannelInfo) (*WatermarkRegression, error)
+
+	// OldestUnpersisted returns the time tick of the oldest timetick message of the pchannel that is emitted but not persisted into wal yet,
+	// which bounds the window of time ticks lost on crash, they're recovered from wal only up to the last persisted one.
+	// It's cleared once a persisted sync covers it, false is returned if all the emitted time ticks are persisted
+	// or the pchannel is not registered.
+	OldestUnpersisted(pChannelInfo types.PChannelInfo) (uint64, bool)
+
+	// IsQuiescent returns whether the pchannel is quiescent for safe maintenance,
+	// i.e. there's no pending triggered sync and no in-flight sync of the pchannel,
+	// and its watermark is unchanged for stableFor by the clock of inspector.
+	// It's a snapshot of the tracked state, a sync triggered after the call may break the quiescence at any time.
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered.
+	IsQuiescent(pChannelInfo types.PChannelInfo, stableFor time.Duration) (bool, error)
+
+	// SyncForAppend triggers the sync of the pchannel and blocks until a time tick that is not less than appendedTs
+	// is synced, so the just-completed append is visible to the consumers before returning to the client.
+	// It returns immediately if the watermark of the pchannel already reaches appendedTs.
+	// The error of context is returned if the context is done, ErrInspectorClosed is returned if the inspector is closed or aborted,
+	// ErrSyncOperatorNotFound is returned if the pchannel is not registered or unregistered while waiting,
+	// and ErrReadOnly is returned if the pchannel is read-only, whose watermark never advances.
+	// The pchannel in maintenance is waited until it's resumed.
+	SyncForAppend(ctx context.Context, pChannelInfo types.PChannelInfo, appendedTs uint64) error
+
+	// FlushBarrier captures the watermarks of the pchannels at the call, and triggers the force persisted syncs of the pchannels
+	// until each of them persists a time tick that is not less than its captured watermark, for a consistent cross-pchannel checkpoint.
+	// The last persisted time tick of each succeeded pchannel is returned, which is not less than its captured watermark.
+	// A FlushBarrierError with the error of each failed pchannel is returned along with the succeeded ones if any pchannel fails,
+	// e.g. it's not registered (ErrSyncOperatorNotFound), read-only (ErrReadOnly), or not persisted before the context is done.
+	// The pchannel in maintenance is waited until it's resumed.
+	FlushBarrier(ctx context.Context, infos []types.PChannelInfo) (map[string]uint64, error)
+
+	// WaitForGlobalWatermark blocks until the watermark of every registered pchannel reaches the target, for a node-wide consistency barrier.
+	// It's woken up by the advance of watermarks and the unregistration of pchannels rather than polling, and no sync is triggered by it.
+	// It returns immediately if no pchannel is registered.
+	// A GlobalWatermarkError listing the lagging pchannels is returned if the context is done or the inspector is closed,
+	// which wraps the error of context or ErrInspectorClosed.
+	WaitForGlobalWatermark(ctx context.Context, target uint64) error
+
+	// GlobalMinMVCC returns the minimum watermark over all registered pchannels, false if no pchannel is registered.
+	// The watermark of a pchannel is the time tick of its last synced timetick message,
+	// a pchannel that has not synced any time tick yet contributes 0.
+	// The watermark of each pchannel only advances, and the aggregate is updated atomically with each advance,
+	// so the returned value never exceeds the watermark of any registered pchannel at the time of the call.
+	// But it may be stale if other pchannels are advancing concurrently, and it may move forward
+	// when the slowest pchannel is unregistered.
+	GlobalMinMVCC() (uint64, bool)
+
+	// BackpressureSignal returns a channel that is closed while the pchannel is under backpressure,
+	// so the append path can throttle the producers before the write ahead buffers overflow.
+	// The pchannel is under backpressure while the write ahead buffers of the node are under pressure,
+	// and its buffer holds at least its fair share of the buffered bytes.
+	// The channel is replaced once the backpressure is cleared, so the caller should query the signal again rather than keep the channel.
+	// A nil channel, which is never ready, is returned if the pchannel is not registered.
+	BackpressureSignal(pChannelInfo types.PChannelInfo) <-chan struct{}
+
+	// AddTickSink registers a sink that receives every time tick emitted by the syncs of all pchannels,
+	// and returns the function to remove the sink, which is idempotent.
+	// Each sink is called in order of emission by its own goroutine, off the critical path of the sync,
+	// so the oldest tick of a slow sink is dropped once its buffer is full, and the count of dropped ticks is reported in DebugDump.
+	// The sinks are removed when the inspector is closed.
+	AddTickSink(sink TickSink) (remove func())
+
+	// Failures returns the channel of the failed syncs of all pchannels.
+	// The channel is bounded, the oldest event is dropped if the consumer is slow,
+	// and the count of dropped events is reported in DebugDump.
+	// The syncs that fail because the inspector is closed or aborted are not emitted.
+	// The channel is never closed, so the consumer should stop receiving once the inspector is closed.
+	Failures() <-chan SyncFailureEvent
+
+	// DebugDump returns a serializable snapshot of the inspector state for debugging.
+	// The snapshot is assembled without blocking the syncs, so the state of different pchannels
+	// may be observed at slightly different moments.
+	DebugDump() InspectorDebugState
+
+	// AuditNow audits the emitted watermarks of all syncable pchannels and returns the found discrepancies in order of pchannel.
+	// The watermark is checked against the mvcc and the write ahead buffer of its pchannel, see AuditFindingKind.
+	// Each finding is also logged and counted, the audit is performed periodically if it's enabled by OptAudit.
+	AuditNow() []AuditFinding
+
 	// UnregisterSyncOperator unregisters a sync operator.
 	UnregisterSyncOperator(operator TimeTickSyncOperator)
 
-	// Close closes the inspector.
+	// Abort cancels the context of the in-flight sync and stops all syncs immediately without waiting.
+	// It's used on a hard shutdown, the graceful way is Close.
+	// The aborted time tick may be lost or left half-appended in wal, the watermark of the pchannel is not advanced,
+	// and the pending triggered syncs (including the force persisted ones) are dropped,
+	// so the consumers may not see the latest time tick until the pchannel is recovered on another node.
+	// The operator may still be running its Sync after Abort returns, Close can be called to wait for it.
+	Abort()
+
+	// Close stops all syncs and blocks until the in-flight sync returns.
 	Close()
 }
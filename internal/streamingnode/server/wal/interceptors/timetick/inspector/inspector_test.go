@@ -2,14 +2,33 @@ package inspector_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/atomic"
 
+	"github.com/milvus-io/milvus/internal/mocks/streamingnode/server/wal/interceptors/mock_wab"
 	"github.com/milvus-io/milvus/internal/mocks/streamingnode/server/wal/interceptors/timetick/mock_inspector"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/inspector"
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/mvcc"
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/wab"
+	"github.com/milvus-io/milvus/internal/util/streamingutil/status"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
 	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
 )
@@ -24,7 +43,7 @@ func TestInsepctor(t *testing.T) {
 		Term: 1,
 	}
 	operator.EXPECT().Channel().Return(pchannel)
-	operator.EXPECT().Sync(mock.Anything, mock.Anything).Run(func(ctx context.Context, forcePersisted bool) {})
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Run(func(ctx context.Context, forcePersisted bool) {}).Return(inspector.SyncResult{}, nil)
 
 	i.RegisterSyncOperator(operator)
 	assert.Panics(t, func() {
@@ -44,3 +63,2778 @@ func TestInsepctor(t *testing.T) {
 	})
 	i.Close()
 }
+
+func TestInspectorReadOnly(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	pchannel := types.PChannelInfo{
+		Name: "test-readonly",
+		Term: 1,
+	}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().MVCCManager().Return(mvcc.NewMVCCManager(100))
+	syncCount := atomic.NewInt32(0)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Run(func(ctx context.Context, forcePersisted bool) {
+		syncCount.Inc()
+	}).Return(inspector.SyncResult{}, nil)
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	i.TriggerSync(pchannel, false)
+	assert.Eventually(t, func() bool {
+		return syncCount.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	i.SetReadOnly(pchannel)
+	// SetReadOnly on an unregistered channel should be ignored.
+	i.SetReadOnly(types.PChannelInfo{Name: "not-registered", Term: 1})
+	time.Sleep(20 * time.Millisecond)
+	frozenCount := syncCount.Load()
+
+	i.TriggerSync(pchannel, true)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, frozenCount, syncCount.Load())
+
+	// mvcc is still available and reports the frozen watermark.
+	mvccResult := i.MustGetOperator(pchannel).MVCCManager().GetMVCCOfVChannel("v1")
+	assert.Equal(t, mvcc.VChannelMVCC{Timetick: 100, Confirmed: true}, mvccResult)
+}
+
+func TestInspectorSyncStats(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	pchannel := types.PChannelInfo{
+		Name: "test-stats",
+		Term: 1,
+	}
+	_, err := i.SyncStats(pchannel)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+
+	// 2 persisted syncs, 2 non-persisted syncs, 1 failed sync and 1 sync without timetick sent.
+	results := []inspector.SyncResult{
+		{TimeTick: 101, Persisted: true},
+		{TimeTick: 102, Persisted: false},
+		{},
+		{TimeTick: 103, Persisted: false},
+		{TimeTick: 104, Persisted: false},
+		{TimeTick: 105, Persisted: true},
+	}
+	errs := []error{nil, nil, nil, errors.New("sync failed"), nil, nil}
+	idx := atomic.NewInt32(0)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		n := int(idx.Inc()) - 1
+		if n >= len(results) {
+			return inspector.SyncResult{}, nil
+		}
+		return results[n], errs[n]
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	for j := 0; j < len(results); j++ {
+		i.TriggerSync(pchannel, false)
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Eventually(t, func() bool {
+		stats, err := i.SyncStats(pchannel)
+		return err == nil && stats.TotalSyncs() == 4
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stats, err := i.SyncStats(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stats.PersistedSyncs)
+	assert.Equal(t, int64(2), stats.NonPersistedSyncs)
+	assert.InDelta(t, 0.5, stats.PersistedRatio(), 1e-9)
+	assert.Zero(t, inspector.SyncStats{}.PersistedRatio())
+}
+
+func TestInspectorGlobalMinMVCC(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	_, ok := i.GlobalMinMVCC()
+	assert.False(t, ok)
+
+	// the watermark of each pchannel advances to its target on every sync.
+	targets := make([]*atomic.Uint64, 3)
+	operators := make([]*mock_inspector.MockTimeTickSyncOperator, 3)
+	for j := range operators {
+		target := atomic.NewUint64(0)
+		targets[j] = target
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: fmt.Sprintf("test-watermark-%d", j), Term: 1})
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			return inspector.SyncResult{TimeTick: target.Load()}, nil
+		})
+		operators[j] = operator
+		i.RegisterSyncOperator(operator)
+	}
+
+	// no pchannel synced yet.
+	minMVCC, ok := i.GlobalMinMVCC()
+	assert.True(t, ok)
+	assert.Zero(t, minMVCC)
+
+	assertGlobalMinMVCC := func(expected uint64) {
+		assert.Eventually(t, func() bool {
+			minMVCC, ok := i.GlobalMinMVCC()
+			return ok && minMVCC == expected
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	targets[0].Store(10)
+	targets[1].Store(20)
+	targets[2].Store(30)
+	assertGlobalMinMVCC(10)
+
+	// the global min follows the slowest pchannel.
+	targets[0].Store(25)
+	assertGlobalMinMVCC(20)
+	targets[1].Store(40)
+	assertGlobalMinMVCC(25)
+
+	// unregister the slowest pchannel.
+	i.UnregisterSyncOperator(operators[0])
+	assertGlobalMinMVCC(30)
+	i.UnregisterSyncOperator(operators[1])
+	i.UnregisterSyncOperator(operators[2])
+	_, ok = i.GlobalMinMVCC()
+	assert.False(t, ok)
+}
+
+func TestInspectorWaitForGlobalWatermark(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	// no pchannel is registered.
+	assert.NoError(t, i.WaitForGlobalWatermark(context.Background(), 100))
+
+	// the watermark of each pchannel advances to its target on every sync.
+	targets := make([]*atomic.Uint64, 3)
+	for j := range targets {
+		target := atomic.NewUint64(0)
+		targets[j] = target
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: fmt.Sprintf("test-global-watermark-%d", j), Term: 1})
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			return inspector.SyncResult{TimeTick: target.Load()}, nil
+		}).Maybe()
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+	targets[0].Store(10)
+	targets[1].Store(20)
+	targets[2].Store(30)
+
+	// the laggards are listed on timeout.
+	assert.Eventually(t, func() bool {
+		minMVCC, ok := i.GlobalMinMVCC()
+		return ok && minMVCC == 10
+	}, 5*time.Second, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := i.WaitForGlobalWatermark(ctx, 30)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	watermarkErr := &inspector.GlobalWatermarkError{}
+	assert.ErrorAs(t, err, &watermarkErr)
+	assert.Equal(t, map[string]uint64{"test-global-watermark-0": 10, "test-global-watermark-1": 20}, watermarkErr.Laggards)
+	assert.NoError(t, i.WaitForGlobalWatermark(context.Background(), 10))
+
+	// unblocked once the slowest pchannel advances past the target.
+	done := make(chan error, 1)
+	go func() {
+		done <- i.WaitForGlobalWatermark(context.Background(), 30)
+	}()
+	targets[1].Store(35)
+	assert.Eventually(t, func() bool {
+		minMVCC, ok := i.GlobalMinMVCC()
+		return ok && minMVCC == 10
+	}, 5*time.Second, 10*time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("unblocked before the slowest pchannel advances: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	targets[0].Store(40)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("not unblocked after the slowest pchannel advances")
+	}
+
+	// the waiter is released when the inspector is closed.
+	go func() {
+		done <- i.WaitForGlobalWatermark(context.Background(), 100)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	i.Close()
+	assert.ErrorIs(t, <-done, inspector.ErrInspectorClosed)
+}
+
+func TestInspectorIsReadable(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	pchannel := types.PChannelInfo{
+		Name: "test-readable",
+		Term: 1,
+	}
+	_, err := i.IsReadable(pchannel, 100)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{TimeTick: 100}, nil)
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	assert.Eventually(t, func() bool {
+		readable, err := i.IsReadable(pchannel, 100)
+		return err == nil && readable
+	}, 5*time.Second, 10*time.Millisecond)
+
+	readable, err := i.IsReadable(pchannel, 99)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+	readable, err = i.IsReadable(pchannel, 101)
+	assert.NoError(t, err)
+	assert.False(t, readable)
+}
+
+func TestInspectorSyncDecisionReplay(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	start := clock.Now()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	// the timetick of each pchannel advances by one on every sync.
+	newOperator := func(name string, initial uint64) *mock_inspector.MockTimeTickSyncOperator {
+		timeTick := atomic.NewUint64(initial)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: name, Term: 1})
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: forcePersisted}, nil
+		})
+		return operator
+	}
+	operatorA := newOperator("a", 10)
+	operatorB := newOperator("b", 20)
+	i.RegisterSyncOperator(operatorB)
+	i.RegisterSyncOperator(operatorA)
+	defer i.UnregisterSyncOperator(operatorA)
+	defer i.UnregisterSyncOperator(operatorB)
+
+	waitDecisions := func(n int) {
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == n
+		}, 5*time.Second, time.Millisecond)
+	}
+	// wait for the ticker of inspector to be started.
+	clock.BlockUntil(1)
+
+	// trigger one pchannel.
+	i.TriggerSync(operatorA.Channel(), true)
+	waitDecisions(1)
+
+	// a clock tick syncs all pchannels in order of name.
+	clock.Advance(interval)
+	waitDecisions(3)
+
+	// a read-only pchannel is skipped.
+	i.SetReadOnly(operatorB.Channel())
+	i.TriggerSync(operatorB.Channel(), false)
+	waitDecisions(4)
+	clock.Advance(interval)
+	waitDecisions(6)
+
+	assert.Equal(t, []inspector.SyncDecision{
+		{Timestamp: start, Channel: "a", Cause: inspector.SyncCauseTrigger, ForcePersisted: true, Result: inspector.SyncResult{TimeTick: 11, Persisted: true}},
+		{Timestamp: start.Add(interval), Channel: "a", Cause: inspector.SyncCauseTimeTick, Result: inspector.SyncResult{TimeTick: 12}},
+		{Timestamp: start.Add(interval), Channel: "b", Cause: inspector.SyncCauseTimeTick, Result: inspector.SyncResult{TimeTick: 21}},
+		{Timestamp: start.Add(interval), Channel: "b", Cause: inspector.SyncCauseTrigger, Skipped: true},
+		{Timestamp: start.Add(2 * interval), Channel: "a", Cause: inspector.SyncCauseTimeTick, Result: inspector.SyncResult{TimeTick: 13}},
+		{Timestamp: start.Add(2 * interval), Channel: "b", Cause: inspector.SyncCauseTimeTick, Skipped: true},
+	}, recorder.Decisions())
+
+	minMVCC, ok := i.GlobalMinMVCC()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(13), minMVCC)
+}
+
+func TestInspectorDebugDump(t *testing.T) {
+	paramtable.Init()
+
+	clock := clockwork.NewFakeClock()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock))
+	defer i.Close()
+	assert.Empty(t, i.DebugDump().Channels)
+
+	pchannelA := types.PChannelInfo{Name: "test-dump-a", Term: 1}
+	pchannelB := types.PChannelInfo{Name: "test-dump-b", Term: 2}
+	blocked := make(chan struct{})
+	entered := atomic.NewBool(false)
+	syncCount := atomic.NewInt32(0)
+	operatorA := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operatorA.EXPECT().Channel().Return(pchannelA)
+	operatorA.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		if syncCount.Inc() == 1 {
+			return inspector.SyncResult{TimeTick: 100, Persisted: true}, nil
+		}
+		// block the following syncs.
+		entered.Store(true)
+		<-blocked
+		return inspector.SyncResult{TimeTick: 101}, nil
+	})
+	operatorB := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operatorB.EXPECT().Channel().Return(pchannelB)
+	operatorB.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil).Maybe()
+	i.RegisterSyncOperator(operatorA)
+	i.RegisterSyncOperator(operatorB)
+	defer i.UnregisterSyncOperator(operatorA)
+	defer i.UnregisterSyncOperator(operatorB)
+	i.SetReadOnly(pchannelB)
+
+	i.TriggerSync(pchannelA, true)
+	assert.Eventually(t, func() bool {
+		stats, err := i.SyncStats(pchannelA)
+		return err == nil && stats.TotalSyncs() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the dump is not blocked by the in-progress sync, the trigger of pchannel b is pending.
+	i.TriggerSync(pchannelA, false)
+	assert.Eventually(t, entered.Load, 5*time.Second, 10*time.Millisecond)
+	i.TriggerSync(pchannelB, true)
+	dump := i.DebugDump()
+	close(blocked)
+
+	assert.Equal(t, inspector.InspectorDebugState{
+		Channels: []inspector.ChannelDebugState{
+			{
+				Channel:       pchannelA.Name,
+				Term:          1,
+				Watermark:     100,
+				WatermarkTime: inspector.WatermarkPhysicalTime(100),
+				LastSyncTime:  clock.Now(),
+				Stats:         inspector.SyncStats{PersistedSyncs: 1, LastEmittedSequence: 1},
+			},
+			{
+				Channel:               pchannelB.Name,
+				Term:                  2,
+				ReadOnly:              true,
+				WatermarkTime:         inspector.WatermarkPhysicalTime(0),
+				PendingSync:           true,
+				PendingForcePersisted: true,
+			},
+		},
+		GlobalMinMVCC:   0,
+		PendingTriggers: 1,
+	}, dump)
+
+	data, err := json.Marshal(dump)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"pending_sync":true`)
+	assert.Contains(t, string(data), `"persisted_syncs":1`)
+}
+
+func TestInspectorSyncStrategy(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	start := clock.Now()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	fixed := mock_inspector.NewMockTimeTickSyncOperator(t)
+	fixed.EXPECT().Channel().Return(types.PChannelInfo{Name: "fixed", Term: 1})
+	fixed.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil)
+	// the adaptive pchannel is idle until it's active.
+	active := atomic.NewBool(false)
+	adaptive := mock_inspector.NewMockTimeTickSyncOperator(t)
+	adaptive.EXPECT().Channel().Return(types.PChannelInfo{Name: "adaptive", Term: 1})
+	adaptive.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		if active.Load() {
+			return inspector.SyncResult{TimeTick: uint64(clock.Now().UnixNano())}, nil
+		}
+		return inspector.SyncResult{}, nil
+	})
+	i.RegisterSyncOperator(fixed, inspector.OptSyncStrategy(inspector.NewFixedSyncStrategy(2*interval)))
+	i.RegisterSyncOperator(adaptive, inspector.OptSyncStrategy(inspector.NewAdaptiveSyncStrategy(interval, 4*interval)))
+	defer i.UnregisterSyncOperator(fixed)
+	defer i.UnregisterSyncOperator(adaptive)
+
+	// advance the clock to the k-th tick, and wait for the decisions.
+	tick := 0
+	advanceTo := func(k int, decisions int) {
+		clock.BlockUntil(1)
+		clock.Advance(time.Duration(k-tick) * interval)
+		tick = k
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == decisions
+		}, 5*time.Second, time.Millisecond)
+	}
+	advanceTo(1, 2) // both are synced at the first tick.
+	advanceTo(3, 4) // fixed every 2 ticks, adaptive backs off to 2 ticks.
+	advanceTo(5, 5) // adaptive backs off to 4 ticks.
+	advanceTo(7, 7) // adaptive reaches the max interval.
+	advanceTo(9, 8) // only fixed.
+	active.Store(true)
+	advanceTo(11, 10) // adaptive becomes active and resets to the min interval.
+	advanceTo(12, 11)
+	advanceTo(13, 13)
+
+	type syncAt struct {
+		channel string
+		tick    int
+	}
+	expected := []syncAt{
+		{"adaptive", 1}, {"fixed", 1},
+		{"adaptive", 3}, {"fixed", 3},
+		{"fixed", 5},
+		{"adaptive", 7}, {"fixed", 7},
+		{"fixed", 9},
+		{"adaptive", 11}, {"fixed", 11},
+		{"adaptive", 12},
+		{"adaptive", 13}, {"fixed", 13},
+	}
+	decisions := recorder.Decisions()
+	assert.Len(t, decisions, len(expected))
+	for idx, decision := range decisions {
+		assert.Equal(t, expected[idx].channel, decision.Channel)
+		assert.Equal(t, start.Add(time.Duration(expected[idx].tick)*interval), decision.Timestamp)
+		assert.Equal(t, inspector.SyncCauseTimeTick, decision.Cause)
+	}
+}
+
+func TestInspectorSyncStateHandover(t *testing.T) {
+	paramtable.Init()
+
+	pchannel := types.PChannelInfo{Name: "test-handover", Term: 1}
+	// the old node emits a persisted and a non-persisted time tick.
+	oldInspector := inspector.NewTimeTickSyncInspector()
+	defer oldInspector.Close()
+	_, err := oldInspector.ExportSyncState(pchannel)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+	results := []inspector.SyncResult{{TimeTick: 100, Persisted: true}, {TimeTick: 102}}
+	idx := atomic.NewInt32(0)
+	oldOperator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	oldOperator.EXPECT().Channel().Return(pchannel)
+	oldOperator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		n := int(idx.Inc()) - 1
+		if n >= len(results) {
+			return inspector.SyncResult{}, nil
+		}
+		return results[n], nil
+	})
+	oldInspector.RegisterSyncOperator(oldOperator)
+	expected := inspector.SyncState{LastPersistedTimeTick: 100, LastEmittedTimeTick: 102, LastEmittedSequence: 2}
+	assert.Eventually(t, func() bool {
+		state, err := oldInspector.ExportSyncState(pchannel)
+		return err == nil && state == expected
+	}, 5*time.Second, 10*time.Millisecond)
+	state, err := oldInspector.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	oldInspector.UnregisterSyncOperator(oldOperator)
+
+	// the new node continues from the state of the old node.
+	newInspector := inspector.NewTimeTickSyncInspector()
+	defer newInspector.Close()
+	assert.ErrorIs(t, newInspector.ImportSyncState(pchannel, state), inspector.ErrSyncOperatorNotFound)
+	done := make(chan struct{})
+	newOperator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	newOperator.EXPECT().Channel().Return(pchannel)
+	newOperator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		select {
+		case <-done:
+			return inspector.SyncResult{TimeTick: 103}, nil
+		default:
+			return inspector.SyncResult{}, nil
+		}
+	})
+	newInspector.RegisterSyncOperator(newOperator)
+	defer newInspector.UnregisterSyncOperator(newOperator)
+	assert.NoError(t, newInspector.ImportSyncState(pchannel, state))
+	imported, err := newInspector.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, imported)
+	readable, err := newInspector.IsReadable(pchannel, 102)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+
+	// the state can not roll back, and should be self-consistent.
+	err = newInspector.ImportSyncState(pchannel, inspector.SyncState{LastPersistedTimeTick: 50, LastEmittedTimeTick: 200})
+	assert.ErrorIs(t, err, inspector.ErrSyncStateRollback)
+	err = newInspector.ImportSyncState(pchannel, inspector.SyncState{LastPersistedTimeTick: 100, LastEmittedTimeTick: 102, LastEmittedSequence: 1})
+	assert.ErrorIs(t, err, inspector.ErrSyncStateRollback)
+	err = newInspector.ImportSyncState(pchannel, inspector.SyncState{LastPersistedTimeTick: 300, LastEmittedTimeTick: 200})
+	assert.Error(t, err)
+
+	// the new emitted time tick advances the imported state, and continues the sequence.
+	close(done)
+	assert.Eventually(t, func() bool {
+		state, err := newInspector.ExportSyncState(pchannel)
+		return err == nil && state == inspector.SyncState{LastPersistedTimeTick: 100, LastEmittedTimeTick: 103, LastEmittedSequence: 3}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestInspectorStateSnapshot(t *testing.T) {
+	paramtable.Init()
+
+	pchannels := []types.PChannelInfo{{Name: "test-snapshot-a", Term: 1}, {Name: "test-snapshot-b", Term: 2}}
+	newOperator := func(pchannel types.PChannelInfo, results ...inspector.SyncResult) *mock_inspector.MockTimeTickSyncOperator {
+		idx := atomic.NewInt32(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			n := int(idx.Inc()) - 1
+			if n >= len(results) {
+				return inspector.SyncResult{}, nil
+			}
+			return results[n], nil
+		}).Maybe()
+		return operator
+	}
+
+	// the active node emits the time ticks of the pchannels.
+	active := inspector.NewTimeTickSyncInspector()
+	defer active.Close()
+	expected := map[string]inspector.SyncState{
+		pchannels[0].Name: {LastPersistedTimeTick: 100, LastEmittedTimeTick: 102, LastEmittedSequence: 2},
+		pchannels[1].Name: {LastPersistedTimeTick: 200, LastEmittedTimeTick: 200, LastEmittedSequence: 1},
+	}
+	activeOperators := []*mock_inspector.MockTimeTickSyncOperator{
+		newOperator(pchannels[0], inspector.SyncResult{TimeTick: 100, Persisted: true}, inspector.SyncResult{TimeTick: 102}),
+		newOperator(pchannels[1], inspector.SyncResult{TimeTick: 200, Persisted: true}),
+	}
+	for k, operator := range activeOperators {
+		active.RegisterSyncOperator(operator)
+		defer active.UnregisterSyncOperator(operator)
+		assert.Eventually(t, func() bool {
+			active.TriggerSync(pchannels[k], false)
+			state, err := active.ExportSyncState(pchannels[k])
+			return err == nil && state == expected[pchannels[k].Name]
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	snapshot := active.SnapshotState()
+
+	// the standby restores the same state from the snapshot.
+	standby := inspector.NewTimeTickSyncInspector()
+	defer standby.Close()
+	standbyOperator := newOperator(pchannels[0])
+	standby.RegisterSyncOperator(standbyOperator)
+	defer standby.UnregisterSyncOperator(standbyOperator)
+	// the pchannel that is not registered on the standby is skipped.
+	assert.NoError(t, standby.RestoreState(snapshot))
+	state, err := standby.ExportSyncState(pchannels[0])
+	assert.NoError(t, err)
+	assert.Equal(t, expected[pchannels[0].Name], state)
+	readable, err := standby.IsReadable(pchannels[0], 102)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+
+	standbyOperator2 := newOperator(pchannels[1])
+	standby.RegisterSyncOperator(standbyOperator2)
+	defer standby.UnregisterSyncOperator(standbyOperator2)
+	assert.NoError(t, standby.RestoreState(snapshot))
+	for _, pchannel := range pchannels {
+		state, err := standby.ExportSyncState(pchannel)
+		assert.NoError(t, err)
+		assert.Equal(t, expected[pchannel.Name], state)
+	}
+	assert.JSONEq(t, string(snapshot), string(standby.SnapshotState()))
+
+	// the malformed or incompatible snapshot is rejected.
+	for _, data := range []string{
+		`not json`,
+		`{"channels":[]}`,
+		`{"version":2,"channels":[]}`,
+		`{"version":1,"channels":[{"channel":"test-snapshot-a","last_emitted_time_tick":200},{"channel":"test-snapshot-a","last_emitted_time_tick":300}]}`,
+		`{"version":1,"channels":[{"channel":"test-snapshot-a","last_persisted_time_tick":300,"last_emitted_time_tick":200}]}`,
+	} {
+		assert.ErrorIs(t, standby.RestoreState([]byte(data)), inspector.ErrSnapshotIncompatible, data)
+	}
+	// the stale snapshot can not roll back the state, the other pchannels are still restored.
+	err = standby.RestoreState([]byte(`{"version":1,"channels":[` +
+		`{"channel":"test-snapshot-a","last_persisted_time_tick":50,"last_emitted_time_tick":50,"last_emitted_sequence":1},` +
+		`{"channel":"test-snapshot-b","last_persisted_time_tick":300,"last_emitted_time_tick":301,"last_emitted_sequence":3,"unknown":true}]}`))
+	assert.ErrorIs(t, err, inspector.ErrSyncStateRollback)
+	state, err = standby.ExportSyncState(pchannels[0])
+	assert.NoError(t, err)
+	assert.Equal(t, expected[pchannels[0].Name], state)
+	state, err = standby.ExportSyncState(pchannels[1])
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncState{LastPersistedTimeTick: 300, LastEmittedTimeTick: 301, LastEmittedSequence: 3}, state)
+	readable, err = standby.IsReadable(pchannels[1], 301)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+}
+
+func TestInspectorAbort(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-abort", Term: 1}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+
+	syncStarted := make(chan struct{})
+	syncCancelled := make(chan struct{})
+	syncCount := atomic.NewInt32(0)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		if syncCount.Inc() == 1 {
+			close(syncStarted)
+		}
+		// a long-running sync that only returns when its context is cancelled.
+		<-ctx.Done()
+		if syncCount.Load() == 1 {
+			close(syncCancelled)
+		}
+		return inspector.SyncResult{}, ctx.Err()
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	i.TriggerSync(pchannel, true)
+	select {
+	case <-syncStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync is not started")
+	}
+
+	// Abort returns without waiting for the in-flight sync.
+	start := time.Now()
+	i.Abort()
+	select {
+	case <-syncCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the context of in-flight sync is not cancelled")
+	}
+	assert.Less(t, time.Since(start), time.Second)
+
+	// no new sync is performed after abort.
+	i.TriggerSync(pchannel, true)
+	time.Sleep(250 * time.Millisecond)
+	assert.Equal(t, int32(1), syncCount.Load())
+}
+
+func TestInspectorFailures(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector(inspector.OptFailureBufferSize(1))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-failures", Term: 1}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	syncErr := errors.New("append time tick failed")
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, syncErr)
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	before := time.Now()
+	i.TriggerSync(pchannel, false)
+	select {
+	case event := <-i.Failures():
+		assert.Equal(t, "test-failures", event.Channel)
+		assert.ErrorIs(t, event.Err, syncErr)
+		assert.False(t, event.Timestamp.Before(before))
+	case <-time.After(5 * time.Second):
+		t.Fatal("sync failure is not emitted")
+	}
+
+	// the periodic syncs keep failing, the old events are dropped if not consumed.
+	assert.Eventually(t, func() bool {
+		return i.DebugDump().DroppedSyncFailures > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestInspectorSyncErrorCategory(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so only the triggered syncs happen.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-error-category", Term: 1}
+	syncErrs := []error{
+		errors.Wrap(inspector.ErrSyncBufferFull, "too many pending time ticks"),
+		errors.Wrap(context.DeadlineExceeded, "append time tick"),
+		errors.Wrap(inspector.ErrSyncStalled, "append time tick"),
+		errors.New("write wal failed"),
+		errors.Wrap(status.NewUnrecoverableError("wal is broken"), "append time tick"),
+		inspector.ErrSyncFatal,
+	}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	calls := atomic.NewInt32(0)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		n := int(calls.Inc())
+		if n > len(syncErrs) {
+			return inspector.SyncResult{}, nil
+		}
+		return inspector.SyncResult{}, syncErrs[n-1]
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	categories := make([]inspector.SyncErrorCategory, 0, len(syncErrs))
+	for _, syncErr := range syncErrs {
+		i.TriggerSync(pchannel, false)
+		select {
+		case event := <-i.Failures():
+			assert.ErrorIs(t, event.Err, syncErr)
+			categories = append(categories, event.Category)
+		case <-time.After(5 * time.Second):
+			t.Fatal("sync failure is not emitted")
+		}
+	}
+	assert.Equal(t, []inspector.SyncErrorCategory{
+		inspector.SyncErrorBufferFull,
+		inspector.SyncErrorTimeout,
+		inspector.SyncErrorTimeout,
+		inspector.SyncErrorPersistenceIO,
+		inspector.SyncErrorFatal,
+		inspector.SyncErrorFatal,
+	}, categories)
+
+	stats, err := i.SyncStats(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncErrorStats{BufferFull: 1, Timeout: 2, PersistenceIO: 1, Fatal: 2}, stats.Errors)
+	assert.Equal(t, int64(len(syncErrs)), stats.Errors.Total())
+	for category, expected := range map[inspector.SyncErrorCategory]float64{
+		inspector.SyncErrorBufferFull:    1,
+		inspector.SyncErrorTimeout:       2,
+		inspector.SyncErrorPersistenceIO: 1,
+		inspector.SyncErrorFatal:         2,
+	} {
+		counter := metrics.WALTimeTickSyncErrorTotal.WithLabelValues(paramtable.GetStringNodeID(), pchannel.Name, string(category))
+		assert.Equal(t, expected, testutil.ToFloat64(counter), category)
+	}
+}
+
+func TestInspectorTriggerShedding(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so only the triggered syncs happen.
+	maxPending := 100
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()), inspector.OptMaxPendingTriggers(maxPending))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-shedding", Term: 1}
+
+	// the operator is stalled, e.g. the storage of wal is out, so the triggers pile up.
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return inspector.SyncResult{}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	defer close(release)
+	i.TriggerSync(pchannel, false)
+	<-started
+
+	shed := func(syncType string) float64 {
+		return testutil.ToFloat64(metrics.WALTimeTickShedTriggerTotal.WithLabelValues(paramtable.GetStringNodeID(), syncType))
+	}
+	memoryShedBefore, persistentShedBefore := shed("memory"), shed("persistent")
+	triggers := 10000
+	for n := 0; n < triggers; n++ {
+		i.TriggerSync(types.PChannelInfo{Name: fmt.Sprintf("test-shedding-%d", n), Term: 1}, false)
+	}
+	// the force persisted triggers replace the pending non-persisted ones.
+	for n := 0; n < 10; n++ {
+		i.TriggerSync(types.PChannelInfo{Name: fmt.Sprintf("test-shedding-persisted-%d", n), Term: 1}, true)
+	}
+
+	// the backlog is bounded by the cap, and every trigger beyond it is shed.
+	state := i.DebugDump()
+	assert.Equal(t, maxPending, state.PendingTriggers)
+	assert.Equal(t, uint64(triggers+10-maxPending), state.ShedTriggers)
+	assert.Equal(t, float64(triggers+10-maxPending), shed("memory")-memoryShedBefore)
+	assert.Zero(t, shed("persistent")-persistentShedBefore)
+
+	// the persisted trigger is only shed if all the pending triggers are persisted.
+	for n := 10; n < maxPending+1; n++ {
+		i.TriggerSync(types.PChannelInfo{Name: fmt.Sprintf("test-shedding-persisted-%d", n), Term: 1}, true)
+	}
+	assert.Equal(t, float64(1), shed("persistent")-persistentShedBefore)
+	assert.Equal(t, maxPending, i.DebugDump().PendingTriggers)
+}
+
+func TestInspectorTenantRateLimit(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+	// the budget of tenant a is shared by its two pchannels.
+	prefix := paramtable.Get().StreamingCfg.TimeTickTenantPersistedSyncRate.KeyPrefix
+	paramtable.Get().SaveGroup(map[string]string{
+		prefix + "tenant-a": "4",
+		prefix + "tenant-b": "1",
+	})
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	persistedSyncs := make(map[string]*atomic.Int32)
+	for _, name := range []string{"tenant-a_0", "tenant-a_1", "tenant-b_0"} {
+		pchannel := types.PChannelInfo{Name: name, Term: 1}
+		count := atomic.NewInt32(0)
+		persistedSyncs[name] = count
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		// every sync persists a time tick message.
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			count.Inc()
+			return inspector.SyncResult{TimeTick: uint64(clock.Now().UnixNano()), Persisted: true}, nil
+		})
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+
+	// run 20 seconds, every pchannel is due at every tick.
+	ticks := int(20 * time.Second / interval)
+	for k := 1; k <= ticks; k++ {
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == 3*k
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	// the persisted syncs are about the budget per second, plus the initial burst.
+	seconds := 20
+	assertRate := func(name string, rate int) {
+		count := int(persistedSyncs[name].Load())
+		assert.GreaterOrEqual(t, count, rate*seconds, name)
+		assert.LessOrEqual(t, count, rate*seconds+rate+2, name)
+	}
+	assertRate("tenant-a_0", 2)
+	assertRate("tenant-a_1", 2)
+	assertRate("tenant-b_0", 1)
+
+	rateLimited := 0
+	for _, decision := range recorder.Decisions() {
+		if decision.RateLimited {
+			assert.True(t, decision.Skipped)
+			rateLimited++
+		}
+	}
+	assert.Equal(t, 3*ticks-int(persistedSyncs["tenant-a_0"].Load()+persistedSyncs["tenant-a_1"].Load()+persistedSyncs["tenant-b_0"].Load()), rateLimited)
+}
+
+func TestInspectorTriggerCoalescing(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+	window, maxDelay := 2*interval, 5*interval
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock),
+		inspector.OptSyncDecisionRecorder(recorder),
+		inspector.OptTriggerCoalescing(window, maxDelay))
+	defer i.Close()
+
+	pchannel := types.PChannelInfo{Name: "test", Term: 1}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		return inspector.SyncResult{TimeTick: uint64(clock.Now().UnixNano()), Persisted: forcePersisted}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	triggered := func() []inspector.SyncDecision {
+		decisions := make([]inspector.SyncDecision, 0)
+		for _, decision := range recorder.Decisions() {
+			if decision.Cause == inspector.SyncCauseTrigger {
+				decisions = append(decisions, decision)
+			}
+		}
+		return decisions
+	}
+	// the periodic sync at every tick marks that the tick is done.
+	ticks := 0
+	tick := func() {
+		ticks++
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		assert.Eventually(t, func() bool {
+			periodic := 0
+			for _, decision := range recorder.Decisions() {
+				if decision.Cause == inspector.SyncCauseTimeTick {
+					periodic++
+				}
+			}
+			return periodic == ticks
+		}, 5*time.Second, time.Millisecond)
+	}
+	trigger := func(persisted bool) {
+		i.TriggerSync(pchannel, persisted)
+		assert.Eventually(t, func() bool {
+			channel := i.DebugDump().Channels[0]
+			return !channel.PendingSync && (persisted || channel.CoalescedSync)
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	// the sustained triggers are coalesced and emitted per max delay, rather than per trigger or window.
+	start := clock.Now()
+	for k := 0; k < 20; k++ {
+		for j := 0; j < 3; j++ {
+			trigger(false)
+		}
+		quiescent, err := i.IsQuiescent(pchannel, 0)
+		assert.NoError(t, err)
+		assert.False(t, quiescent)
+		tick()
+	}
+	decisions := triggered()
+	assert.Len(t, decisions, 4)
+	for k, decision := range decisions {
+		assert.False(t, decision.ForcePersisted)
+		assert.Equal(t, start.Add(time.Duration(k+1)*maxDelay), decision.Timestamp)
+	}
+
+	// the coalesced sync of a burst is emitted once no trigger arrives within the window.
+	assert.False(t, i.DebugDump().Channels[0].CoalescedSync)
+	for j := 0; j < 3; j++ {
+		trigger(false)
+	}
+	burstEnd := clock.Now()
+	tick()
+	assert.True(t, i.DebugDump().Channels[0].CoalescedSync)
+	assert.Len(t, triggered(), 4)
+	tick()
+	decisions = triggered()
+	assert.Len(t, decisions, 5)
+	assert.Equal(t, burstEnd.Add(window), decisions[4].Timestamp)
+	assert.False(t, i.DebugDump().Channels[0].CoalescedSync)
+
+	// the force persisted trigger is never coalesced, and it serves the coalesced one.
+	trigger(false)
+	trigger(true)
+	assert.Eventually(t, func() bool {
+		return len(triggered()) == 6
+	}, 5*time.Second, time.Millisecond)
+	assert.True(t, triggered()[5].ForcePersisted)
+	assert.False(t, i.DebugDump().Channels[0].CoalescedSync)
+	for k := 0; k < 5; k++ {
+		tick()
+	}
+	assert.Len(t, triggered(), 6)
+}
+
+func TestInspectorWatermarkRegression(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-watermark-regression", Term: 1}
+	_, err := i.LastWatermarkRegression(pchannel)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+
+	target := atomic.NewUint64(20)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		return inspector.SyncResult{TimeTick: target.Load()}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	assert.Eventually(t, func() bool {
+		minMVCC, _ := i.GlobalMinMVCC()
+		return minMVCC == 20
+	}, 5*time.Second, 10*time.Millisecond)
+	// the repeated watermark is not a regression.
+	regression, err := i.LastWatermarkRegression(pchannel)
+	assert.NoError(t, err)
+	assert.Nil(t, regression)
+
+	// inject a decreasing watermark.
+	target.Store(10)
+	assert.Eventually(t, func() bool {
+		regression, err = i.LastWatermarkRegression(pchannel)
+		return err == nil && regression != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "sync", regression.Source)
+	assert.Equal(t, uint64(20), regression.Current)
+	assert.Equal(t, uint64(10), regression.Rejected)
+
+	// the exposed watermark never goes backwards.
+	minMVCC, _ := i.GlobalMinMVCC()
+	assert.Equal(t, uint64(20), minMVCC)
+	readable, err := i.IsReadable(pchannel, 20)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+
+	target.Store(30)
+	assert.Eventually(t, func() bool {
+		minMVCC, _ := i.GlobalMinMVCC()
+		return minMVCC == 30
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestInspectorWeightedFairSync(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+
+	// the sync goroutine is saturated by the triggered syncs of all pchannels.
+	weights := []float64{1, 2, 4}
+	served := make([]*atomic.Int64, len(weights))
+	operators := make([]*mock_inspector.MockTimeTickSyncOperator, len(weights))
+	for j, weight := range weights {
+		count := atomic.NewInt64(0)
+		served[j] = count
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: fmt.Sprintf("test-weighted-%d", j), Term: 1})
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			if forcePersisted {
+				count.Inc()
+			}
+			time.Sleep(time.Millisecond)
+			return inspector.SyncResult{}, nil
+		})
+		operators[j] = operator
+		i.RegisterSyncOperator(operator, inspector.OptSyncWeight(weight), inspector.OptSyncStrategy(inspector.NewFixedSyncStrategy(time.Hour)))
+		defer i.UnregisterSyncOperator(operator)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for ctx.Err() == nil {
+		for _, operator := range operators {
+			i.TriggerSync(operator.Channel(), true)
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+
+	// no pchannel starves, and the service is in proportion to the weights.
+	base := float64(served[0].Load())
+	assert.Greater(t, base, float64(0))
+	for j, weight := range weights {
+		assert.InDelta(t, weight, float64(served[j].Load())/base, weight*0.3)
+	}
+}
+
+func TestInspectorWaitForRegistration(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	pchannel := types.PChannelInfo{Name: "test-wait-registration", Term: 1}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil).Maybe()
+
+	// the context expires before the registration.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := i.WaitForRegistration(ctx, pchannel)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the operator is registered shortly after the wait begins.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		i.RegisterSyncOperator(operator)
+	}()
+	registered, err := i.WaitForRegistration(context.Background(), pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, operator, registered)
+
+	// the registered operator is returned immediately.
+	registered, err = i.WaitForRegistration(context.Background(), pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, operator, registered)
+	i.UnregisterSyncOperator(operator)
+
+	// the wait is interrupted by the close of inspector.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		i.Close()
+	}()
+	_, err = i.WaitForRegistration(context.Background(), pchannel)
+	assert.ErrorIs(t, err, inspector.ErrInspectorClosed)
+}
+
+func TestInspectorSyncForAppend(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-sync-for-append", Term: 1}
+	assert.ErrorIs(t, i.SyncForAppend(context.Background(), pchannel, 1), inspector.ErrSyncOperatorNotFound)
+
+	// the time tick advances by one on every sync, and the syncs are blocked until the gate is opened.
+	gate := make(chan struct{})
+	timeTick := atomic.NewUint64(0)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return inspector.SyncResult{}, ctx.Err()
+		}
+		return inspector.SyncResult{TimeTick: timeTick.Inc()}, nil
+	})
+	i.RegisterSyncOperator(operator, inspector.OptSyncStrategy(inspector.NewFixedSyncStrategy(time.Hour)))
+	defer i.UnregisterSyncOperator(operator)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- i.SyncForAppend(context.Background(), pchannel, 5)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("SyncForAppend returns before the time tick is synced, %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gate)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("SyncForAppend is not returned after the time tick is synced")
+	}
+	readable, err := i.IsReadable(pchannel, 5)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+
+	// the appended timestamp is already synced.
+	assert.NoError(t, i.SyncForAppend(context.Background(), pchannel, 3))
+
+	// the context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, i.SyncForAppend(ctx, pchannel, 1<<60), context.DeadlineExceeded)
+
+	// the read-only pchannel is never synced.
+	i.SetReadOnly(pchannel)
+	assert.ErrorIs(t, i.SyncForAppend(context.Background(), pchannel, 1<<60), inspector.ErrReadOnly)
+}
+
+func TestInspectorStaleEpochSync(t *testing.T) {
+	paramtable.Init()
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	pchannel := types.PChannelInfo{Name: "test-epoch", Term: 1}
+	blocked := make(chan struct{})
+	entered := make(chan struct{})
+	stale := mock_inspector.NewMockTimeTickSyncOperator(t)
+	stale.EXPECT().Channel().Return(pchannel)
+	stale.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		close(entered)
+		<-blocked
+		return inspector.SyncResult{TimeTick: 100, Persisted: true}, nil
+	}).Once()
+	i.RegisterSyncOperator(stale)
+	i.TriggerSync(pchannel, true)
+	<-entered
+
+	// re-register the pchannel while the sync of the previous registration is in flight.
+	i.UnregisterSyncOperator(stale)
+	current := mock_inspector.NewMockTimeTickSyncOperator(t)
+	current.EXPECT().Channel().Return(pchannel)
+	current.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{TimeTick: 50}, nil)
+	i.RegisterSyncOperator(current)
+	defer i.UnregisterSyncOperator(current)
+	close(blocked)
+
+	// the stale result is discarded.
+	assert.Eventually(t, func() bool {
+		return len(recorder.Decisions()) == 1
+	}, 5*time.Second, time.Millisecond)
+	decision := recorder.Decisions()[0]
+	assert.True(t, decision.Stale)
+	assert.NoError(t, decision.Err)
+	assert.False(t, decision.Result.IsSent())
+	readable, err := i.IsReadable(pchannel, 1)
+	assert.NoError(t, err)
+	assert.False(t, readable)
+	stats, err := i.SyncStats(pchannel)
+	assert.NoError(t, err)
+	assert.Zero(t, stats.TotalSyncs())
+
+	// the sync of the current registration advances the watermark.
+	i.TriggerSync(pchannel, false)
+	assert.Eventually(t, func() bool {
+		return len(recorder.Decisions()) == 2
+	}, 5*time.Second, time.Millisecond)
+	assert.False(t, recorder.Decisions()[1].Stale)
+	readable, err = i.IsReadable(pchannel, 50)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+	readable, err = i.IsReadable(pchannel, 51)
+	assert.NoError(t, err)
+	assert.False(t, readable)
+}
+
+func TestInspectorThroughput(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+	assert.Equal(t, inspector.ThroughputSnapshot{Window: 10 * time.Second}, i.Throughput())
+
+	// the syncs of pchannel a are persisted, the syncs of pchannel b are not.
+	newOperator := func(name string, persisted bool) *mock_inspector.MockTimeTickSyncOperator {
+		timeTick := atomic.NewUint64(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: name, Term: 1})
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: persisted}, nil
+		})
+		return operator
+	}
+	operatorA := newOperator("a", true)
+	operatorB := newOperator("b", false)
+	i.RegisterSyncOperator(operatorA)
+	i.RegisterSyncOperator(operatorB)
+
+	// run 15 seconds, both pchannels are synced at every tick.
+	ticks := int(15 * time.Second / interval)
+	for k := 1; k <= ticks; k++ {
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == 2*k
+		}, 5*time.Second, time.Millisecond)
+	}
+	perSecond := float64(time.Second / interval)
+	throughput := i.Throughput()
+	assert.Equal(t, 10*time.Second, throughput.Window)
+	assert.InDelta(t, 2*perSecond, throughput.TotalPerSecond, 2*perSecond/10)
+	assert.InDelta(t, perSecond, throughput.PersistedPerSecond, perSecond/10)
+
+	// the syncs slide out of the window.
+	i.UnregisterSyncOperator(operatorA)
+	i.UnregisterSyncOperator(operatorB)
+	clock.BlockUntil(1)
+	clock.Advance(11 * time.Second)
+	assert.Equal(t, inspector.ThroughputSnapshot{Window: 10 * time.Second}, i.Throughput())
+}
+
+func TestInspectorWatermarkDelta(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so only the triggered syncs happen.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-watermark-delta", Term: 1}
+	now := time.Now()
+	deltas := []time.Duration{100 * time.Millisecond, time.Second, 3 * time.Second}
+	timeTicks := []uint64{inspector.WatermarkFromPhysicalTime(now)}
+	for _, delta := range deltas {
+		now = now.Add(delta)
+		timeTicks = append(timeTicks, inspector.WatermarkFromPhysicalTime(now))
+	}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	idx := atomic.NewInt32(0)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		n := int(idx.Inc()) - 1
+		if n >= len(timeTicks) {
+			return inspector.SyncResult{}, nil
+		}
+		return inspector.SyncResult{TimeTick: timeTicks[n]}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	for _, timeTick := range timeTicks {
+		i.TriggerSync(pchannel, false)
+		assert.Eventually(t, func() bool {
+			readable, err := i.IsReadable(pchannel, timeTick)
+			return err == nil && readable
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	// the first emitted time tick has no delta.
+	m := &dto.Metric{}
+	err := metrics.WALTimeTickWatermarkDeltaSeconds.WithLabelValues(paramtable.GetStringNodeID(), pchannel.Name).(prometheus.Metric).Write(m)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(deltas)), m.GetHistogram().GetSampleCount())
+	assert.InDelta(t, 4.1, m.GetHistogram().GetSampleSum(), 1e-9)
+	// the steady and the bursty deltas fall into different buckets.
+	for _, bucket := range m.GetHistogram().GetBucket() {
+		switch {
+		case bucket.GetUpperBound() < 0.1:
+			assert.Zero(t, bucket.GetCumulativeCount(), bucket.GetUpperBound())
+		case bucket.GetUpperBound() >= 3:
+			assert.Equal(t, uint64(3), bucket.GetCumulativeCount(), bucket.GetUpperBound())
+		}
+	}
+}
+
+func TestInspectorClockSkew(t *testing.T) {
+	paramtable.Init()
+	maxSkew := time.Second
+
+	clock := clockwork.NewFakeClockAt(time.Now().Truncate(time.Millisecond))
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptMaxClockSkew(maxSkew))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-clock-skew", Term: 1}
+	// the physical time of the hybrid clock of the time tick source, which is skewed from the clock of inspector.
+	source := atomic.NewTime(clock.Now())
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		return inspector.SyncResult{TimeTick: inspector.WatermarkFromPhysicalTime(source.Load())}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	skewCount := func(direction string) uint64 {
+		m := &dto.Metric{}
+		err := metrics.WALTimeTickClockSkewSeconds.WithLabelValues(paramtable.GetStringNodeID(), pchannel.Name, direction).(prometheus.Metric).Write(m)
+		assert.NoError(t, err)
+		return m.GetHistogram().GetSampleCount()
+	}
+	expectWatermark := func(expected time.Time) {
+		i.TriggerSync(pchannel, false)
+		assert.Eventually(t, func() bool {
+			watermark, _ := i.GlobalMinMVCC()
+			return watermark == inspector.WatermarkFromPhysicalTime(expected)
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	// the time tick within the max skew is not clamped.
+	expectWatermark(source.Load())
+	source.Store(clock.Now().Add(maxSkew))
+	expectWatermark(source.Load())
+	assert.Zero(t, skewCount("ahead"))
+
+	// the time tick that jumps far ahead is clamped to the clock plus the max skew, and follows the clock.
+	source.Store(clock.Now().Add(time.Hour))
+	expectWatermark(clock.Now().Add(maxSkew))
+	assert.Equal(t, uint64(1), skewCount("ahead"))
+	clock.Advance(300 * time.Millisecond)
+	expectWatermark(clock.Now().Add(maxSkew))
+
+	// the watermark is held once the clock of the source is corrected, the stall is bounded by the max skew.
+	clamped := clock.Now().Add(maxSkew)
+	source.Store(clock.Now())
+	i.TriggerSync(pchannel, false)
+	assert.Eventually(t, func() bool {
+		return skewCount("behind") > 0
+	}, 5*time.Second, time.Millisecond)
+	watermark, _ := i.GlobalMinMVCC()
+	assert.Equal(t, inspector.WatermarkFromPhysicalTime(clamped), watermark)
+	regression, err := i.LastWatermarkRegression(pchannel)
+	assert.NoError(t, err)
+	assert.NotNil(t, regression)
+
+	clock.Advance(maxSkew + time.Millisecond)
+	source.Store(clock.Now())
+	expectWatermark(source.Load())
+}
+
+func TestInspectorMaintenance(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	start := clock.Now()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	pchannel := types.PChannelInfo{Name: "test-maintenance", Term: 1}
+	timeTick := atomic.NewUint64(0)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: forcePersisted}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	waitDecisions := func(n int) {
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == n
+		}, 5*time.Second, time.Millisecond)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(interval)
+	waitDecisions(1)
+
+	// no time tick is emitted during the maintenance.
+	i.SuspendForMaintenance(pchannel, "compaction")
+	stats, err := i.SyncStats(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncStats{NonPersistedSyncs: 1, MaintenanceReason: "compaction", LastEmittedSequence: 1}, stats)
+	assert.Equal(t, "compaction", i.DebugDump().Channels[0].Stats.MaintenanceReason)
+	assert.False(t, i.DebugDump().Channels[0].ReadOnly)
+
+	clock.Advance(interval)
+	waitDecisions(2)
+	i.TriggerSync(pchannel, true)
+	waitDecisions(3)
+	readable, err := i.IsReadable(pchannel, 2)
+	assert.NoError(t, err)
+	assert.False(t, readable)
+
+	// a catch-up sync is emitted on resume.
+	i.ResumeFromMaintenance(pchannel)
+	waitDecisions(4)
+	stats, err = i.SyncStats(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncStats{PersistedSyncs: 1, NonPersistedSyncs: 1, LastEmittedSequence: 2}, stats)
+	assert.Empty(t, i.DebugDump().Channels[0].Stats.MaintenanceReason)
+	readable, err = i.IsReadable(pchannel, 2)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+
+	// resume a pchannel that is not in maintenance is a no-op.
+	i.ResumeFromMaintenance(pchannel)
+	clock.Advance(interval)
+	waitDecisions(5)
+
+	assert.Equal(t, []inspector.SyncDecision{
+		{Timestamp: start.Add(interval), Channel: pchannel.Name, Cause: inspector.SyncCauseTimeTick, Result: inspector.SyncResult{TimeTick: 1}},
+		{Timestamp: start.Add(2 * interval), Channel: pchannel.Name, Cause: inspector.SyncCauseTimeTick, Skipped: true, Maintenance: true},
+		{Timestamp: start.Add(2 * interval), Channel: pchannel.Name, Cause: inspector.SyncCauseTrigger, ForcePersisted: true, Skipped: true, Maintenance: true},
+		{Timestamp: start.Add(2 * interval), Channel: pchannel.Name, Cause: inspector.SyncCauseTrigger, ForcePersisted: true, Result: inspector.SyncResult{TimeTick: 2, Persisted: true}},
+		{Timestamp: start.Add(3 * interval), Channel: pchannel.Name, Cause: inspector.SyncCauseTimeTick, Result: inspector.SyncResult{TimeTick: 3}},
+	}, recorder.Decisions())
+}
+
+func TestInspectorStallWatchdog(t *testing.T) {
+	paramtable.Init()
+	threshold := 10 * time.Second
+
+	for _, cancel := range []bool{true, false} {
+		clock := clockwork.NewFakeClock()
+		recorder := inspector.NewSyncDecisionRecorder()
+		i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock),
+			inspector.OptSyncDecisionRecorder(recorder),
+			inspector.OptStallWatchdog(threshold, cancel))
+
+		// the first sync blocks until its context is canceled.
+		pchannel := types.PChannelInfo{Name: "test-stall", Term: 1}
+		entered := make(chan struct{})
+		calls := atomic.NewInt32(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			if calls.Inc() > 1 {
+				return inspector.SyncResult{}, nil
+			}
+			close(entered)
+			<-ctx.Done()
+			return inspector.SyncResult{}, context.Cause(ctx)
+		})
+		i.RegisterSyncOperator(operator)
+		i.TriggerSync(pchannel, false)
+		<-entered
+
+		// wait for the tickers of the sync goroutine and the watchdog.
+		clock.BlockUntil(2)
+		clock.Advance(threshold / 2)
+		clock.BlockUntil(2)
+		assert.Zero(t, i.DebugDump().StalledSyncs)
+
+		// the watchdog fires once the sync lasts for the threshold.
+		clock.Advance(threshold / 2)
+		assert.Eventually(t, func() bool {
+			return i.DebugDump().StalledSyncs == 1
+		}, 5*time.Second, time.Millisecond)
+
+		if cancel {
+			// the stalled sync is canceled.
+			event := <-i.Failures()
+			assert.ErrorIs(t, event.Err, inspector.ErrSyncStalled)
+			assert.Equal(t, pchannel.Name, event.Channel)
+			assert.Eventually(t, func() bool {
+				return len(recorder.Decisions()) >= 1
+			}, 5*time.Second, time.Millisecond)
+			assert.ErrorIs(t, recorder.Decisions()[0].Err, inspector.ErrSyncStalled)
+		} else {
+			// the stalled sync is kept, and the stall is reported only once.
+			for k := 0; k < 4; k++ {
+				clock.BlockUntil(2)
+				clock.Advance(threshold / 4)
+			}
+			assert.Equal(t, uint64(1), i.DebugDump().StalledSyncs)
+			assert.Empty(t, recorder.Decisions())
+		}
+		i.UnregisterSyncOperator(operator)
+		i.Close()
+	}
+}
+
+func TestInspectorBufferPressure(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+	params := paramtable.Get()
+	params.Save(params.StreamingCfg.TimeTickBufferPressureHighWatermark.Key, "1000")
+	defer params.Reset(params.StreamingCfg.TimeTickBufferPressureHighWatermark.Key)
+	params.Save(params.StreamingCfg.TimeTickBufferPressureLowWatermark.Key, "500")
+	defer params.Reset(params.StreamingCfg.TimeTickBufferPressureLowWatermark.Key)
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	names := []string{"a", "b", "c"}
+	buffered := make(map[string]*atomic.Int64)
+	for _, name := range names {
+		size := atomic.NewInt64(0)
+		buffered[name] = size
+		wb := mock_wab.NewMockROWriteAheadBuffer(t)
+		wb.EXPECT().BufferedBytes().RunAndReturn(func() int {
+			return int(size.Load())
+		})
+		timeTick := atomic.NewUint64(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: name, Term: 1})
+		operator.EXPECT().WriteAheadBuffer().Return(wb)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: forcePersisted}, nil
+		})
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+
+	// tick once with the buffered sizes, returns the channels in order of sync and whether the syncs are persisted.
+	tick := func(a, b, c int64) ([]string, []bool) {
+		buffered["a"].Store(a)
+		buffered["b"].Store(b)
+		buffered["c"].Store(c)
+		n := len(recorder.Decisions())
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == n+len(names)
+		}, 5*time.Second, time.Millisecond)
+		channels := make([]string, 0, len(names))
+		persisted := make([]bool, 0, len(names))
+		for _, decision := range recorder.Decisions()[n:] {
+			assert.Equal(t, inspector.SyncCauseTimeTick, decision.Cause)
+			channels = append(channels, decision.Channel)
+			persisted = append(persisted, decision.ForcePersisted)
+		}
+		return channels, persisted
+	}
+
+	// below the high watermark, the channels are synced in order of name without persisting.
+	channels, persisted := tick(100, 200, 300)
+	assert.Equal(t, []string{"a", "b", "c"}, channels)
+	assert.Equal(t, []bool{false, false, false}, persisted)
+	assert.False(t, i.DebugDump().BufferPressure)
+
+	// above the high watermark, the persisted syncs are prioritized by the buffered size.
+	channels, persisted = tick(100, 800, 300)
+	assert.Equal(t, []string{"b", "c", "a"}, channels)
+	assert.Equal(t, []bool{true, true, true}, persisted)
+	assert.True(t, i.DebugDump().BufferPressure)
+
+	// the pressure is kept until the total size falls below the low watermark.
+	channels, persisted = tick(400, 100, 200)
+	assert.Equal(t, []string{"a", "c", "b"}, channels)
+	assert.Equal(t, []bool{true, true, true}, persisted)
+	assert.True(t, i.DebugDump().BufferPressure)
+
+	channels, persisted = tick(100, 100, 200)
+	assert.Equal(t, []string{"a", "b", "c"}, channels)
+	assert.Equal(t, []bool{false, false, false}, persisted)
+	assert.False(t, i.DebugDump().BufferPressure)
+}
+
+// recoverableOperator is a sync operator that recovers from a wal with a persisted time tick.
+type recoverableOperator struct {
+	*mock_inspector.MockTimeTickSyncOperator
+	lastPersistedTimeTick uint64
+	err                   error
+}
+
+func (o *recoverableOperator) LastPersistedTimeTick() (uint64, error) {
+	return o.lastPersistedTimeTick, o.err
+}
+
+func TestInspectorRecoverFromWAL(t *testing.T) {
+	paramtable.Init()
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	pchannel := types.PChannelInfo{Name: "test-recover", Term: 1}
+	_, err := i.RecoverFromWAL(pchannel)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+
+	// the sync state is recovered from the persisted time tick on registration.
+	mockOperator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	mockOperator.EXPECT().Channel().Return(pchannel)
+	operator := &recoverableOperator{MockTimeTickSyncOperator: mockOperator, lastPersistedTimeTick: 100}
+	i.RegisterSyncOperator(operator)
+	state, err := i.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncState{LastPersistedTimeTick: 100, LastEmittedTimeTick: 100}, state)
+	readable, err := i.IsReadable(pchannel, 100)
+	assert.NoError(t, err)
+	assert.True(t, readable)
+	minMVCC, ok := i.GlobalMinMVCC()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), minMVCC)
+
+	// the recovery never rolls back the state.
+	assert.NoError(t, i.ImportSyncState(pchannel, inspector.SyncState{LastPersistedTimeTick: 150, LastEmittedTimeTick: 200}))
+	timeTick, err := i.RecoverFromWAL(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), timeTick)
+	state, err = i.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncState{LastPersistedTimeTick: 150, LastEmittedTimeTick: 200}, state)
+	regression, err := i.LastWatermarkRegression(pchannel)
+	assert.NoError(t, err)
+	assert.Nil(t, regression)
+	i.UnregisterSyncOperator(operator)
+
+	// a cold state is used if the recovery fails.
+	operator = &recoverableOperator{MockTimeTickSyncOperator: mockOperator, err: errors.New("wal is not ready")}
+	i.RegisterSyncOperator(operator)
+	state, err = i.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncState{}, state)
+	_, err = i.RecoverFromWAL(pchannel)
+	assert.Error(t, err)
+	i.UnregisterSyncOperator(operator)
+
+	// the operator that can't recover from wal starts with a cold state.
+	i.RegisterSyncOperator(mockOperator)
+	defer i.UnregisterSyncOperator(mockOperator)
+	timeTick, err = i.RecoverFromWAL(pchannel)
+	assert.NoError(t, err)
+	assert.Zero(t, timeTick)
+	state, err = i.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncState{}, state)
+}
+
+// sequenceRecoverableOperator is a sync operator that recovers from a wal with a persisted time tick and its sequence number.
+type sequenceRecoverableOperator struct {
+	*recoverableOperator
+	lastPersistedSequence uint64
+}
+
+func (o *sequenceRecoverableOperator) LastPersistedSequence() (uint64, error) {
+	return o.lastPersistedSequence, o.err
+}
+
+func TestInspectorSyncSequence(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so only the triggered syncs happen.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-sequence", Term: 1}
+
+	// the operator emits a time tick on every other sync, and fails once.
+	var mu sync.Mutex
+	var sequences []uint64
+	var timeTick uint64
+	calls := 0
+	mockOperator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	mockOperator.EXPECT().Channel().Return(pchannel)
+	mockOperator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		sequence, ok := inspector.SyncSequence(ctx)
+		assert.True(t, ok)
+		calls++
+		switch {
+		case calls == 3:
+			return inspector.SyncResult{}, errors.New("sync failed")
+		case calls%2 == 0:
+			return inspector.SyncResult{}, nil
+		}
+		timeTick += 10
+		sequences = append(sequences, sequence)
+		return inspector.SyncResult{TimeTick: timeTick, Persisted: true}, nil
+	})
+	emitted := func(n int) []uint64 {
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(sequences) >= n {
+				return true
+			}
+			i.TriggerSync(pchannel, false)
+			return false
+		}, 5*time.Second, 10*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]uint64{}, sequences...)
+	}
+
+	// the emitted time ticks carry the consecutive sequence numbers, the failed or empty syncs don't consume them.
+	i.RegisterSyncOperator(mockOperator)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, emitted(4)[:4])
+	// the stats follow the last emitted sequence, the pending trigger may emit one more time tick.
+	assert.Eventually(t, func() bool {
+		stats, err := i.SyncStats(pchannel)
+		mu.Lock()
+		defer mu.Unlock()
+		return err == nil && stats.LastEmittedSequence == sequences[len(sequences)-1]
+	}, 5*time.Second, 10*time.Millisecond)
+	i.UnregisterSyncOperator(mockOperator)
+
+	// the restarted node continues the sequence recovered from wal.
+	mu.Lock()
+	sequences, calls, timeTick = nil, 0, 100
+	mu.Unlock()
+	operator := &sequenceRecoverableOperator{
+		recoverableOperator:   &recoverableOperator{MockTimeTickSyncOperator: mockOperator, lastPersistedTimeTick: 100},
+		lastPersistedSequence: 10,
+	}
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	state, err := i.ExportSyncState(pchannel)
+	assert.NoError(t, err)
+	assert.Equal(t, inspector.SyncState{LastPersistedTimeTick: 100, LastEmittedTimeTick: 100, LastEmittedSequence: 10}, state)
+	assert.Equal(t, []uint64{11, 12}, emitted(2)[:2])
+}
+
+func TestInspectorOnBecomeLeader(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so no periodic sync happens.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+	newOperator := func(pchannel types.PChannelInfo, synced chan bool) *recoverableOperator {
+		mockOperator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		mockOperator.EXPECT().Channel().Return(pchannel)
+		mockOperator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			synced <- forcePersisted
+			return inspector.SyncResult{TimeTick: 120, Persisted: forcePersisted}, nil
+		}).Maybe()
+		return &recoverableOperator{MockTimeTickSyncOperator: mockOperator, lastPersistedTimeTick: 100}
+	}
+	assertPersistedSync := func(synced chan bool) {
+		select {
+		case forcePersisted := <-synced:
+			assert.True(t, forcePersisted)
+		case <-time.After(5 * time.Second):
+			t.Fatal("a persisted sync should be performed on leadership change")
+		}
+	}
+
+	pchannel := types.PChannelInfo{Name: "test-leader", Term: 1}
+	synced := make(chan bool, 10)
+	operator := newOperator(pchannel, synced)
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	// the previous leader persists more time ticks before the failover.
+	operator.lastPersistedTimeTick = 110
+
+	// the node takes over the leadership, the recovered watermark is emitted by a persisted sync immediately.
+	i.OnBecomeLeader(pchannel)
+	assertPersistedSync(synced)
+	assert.Eventually(t, func() bool {
+		state, err := i.ExportSyncState(pchannel)
+		return err == nil && state.LastPersistedTimeTick == 120
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the leadership change before the registration is served once registered.
+	pendingChannel := types.PChannelInfo{Name: "test-leader-pending", Term: 1}
+	pendingSynced := make(chan bool, 10)
+	i.OnBecomeLeader(pendingChannel)
+	pendingOperator := newOperator(pendingChannel, pendingSynced)
+	i.RegisterSyncOperator(pendingOperator)
+	defer i.UnregisterSyncOperator(pendingOperator)
+	assertPersistedSync(pendingSynced)
+
+	// a read-only pchannel never syncs.
+	i.SetReadOnly(pchannel)
+	i.OnBecomeLeader(pchannel)
+	select {
+	case <-synced:
+		t.Fatal("a read-only pchannel should not sync on leadership change")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestInspectorTickSink(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+
+	pchannel := types.PChannelInfo{Name: "test-sink", Term: 1}
+	timeTick := atomic.NewUint64(0)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		// the odd syncs are persisted.
+		tt := timeTick.Inc()
+		return inspector.SyncResult{TimeTick: tt, Persisted: tt%2 == 1}, nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	type tick struct {
+		info      types.PChannelInfo
+		ts        uint64
+		persisted bool
+	}
+	received := make(chan tick, 100)
+	remove := i.AddTickSink(func(info types.PChannelInfo, ts uint64, persisted bool) {
+		received <- tick{info: info, ts: ts, persisted: persisted}
+	})
+	// a blocked sink never blocks the sync.
+	block := make(chan struct{})
+	defer close(block)
+	removeBlocked := i.AddTickSink(func(info types.PChannelInfo, ts uint64, persisted bool) {
+		<-block
+	})
+	defer removeBlocked()
+
+	syncN := func(n int) {
+		for k := 0; k < n; k++ {
+			c := len(recorder.Decisions())
+			clock.BlockUntil(1)
+			clock.Advance(interval)
+			assert.Eventually(t, func() bool {
+				return len(recorder.Decisions()) == c+1
+			}, 5*time.Second, time.Millisecond)
+		}
+	}
+	syncN(3)
+	for k := uint64(1); k <= 3; k++ {
+		select {
+		case got := <-received:
+			assert.Equal(t, tick{info: pchannel, ts: k, persisted: k%2 == 1}, got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the emitted tick is not received by the sink")
+		}
+	}
+
+	// the removed sink receives no more ticks, and the removal is idempotent.
+	remove()
+	remove()
+	syncN(2)
+	select {
+	case got := <-received:
+		t.Fatalf("the removed sink receives tick %+v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestInspectorBackpressureSignal(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+	params := paramtable.Get()
+	params.Save(params.StreamingCfg.TimeTickBufferPressureHighWatermark.Key, "1000")
+	defer params.Reset(params.StreamingCfg.TimeTickBufferPressureHighWatermark.Key)
+	params.Save(params.StreamingCfg.TimeTickBufferPressureLowWatermark.Key, "500")
+	defer params.Reset(params.StreamingCfg.TimeTickBufferPressureLowWatermark.Key)
+
+	clock := clockwork.NewFakeClock()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder))
+	defer i.Close()
+	assert.Nil(t, i.BackpressureSignal(types.PChannelInfo{Name: "not-registered", Term: 1}))
+
+	pchannels := []types.PChannelInfo{{Name: "heavy", Term: 1}, {Name: "light", Term: 1}}
+	buffered := make(map[string]*atomic.Int64)
+	for _, pchannel := range pchannels {
+		size := atomic.NewInt64(0)
+		buffered[pchannel.Name] = size
+		wb := mock_wab.NewMockROWriteAheadBuffer(t)
+		wb.EXPECT().BufferedBytes().RunAndReturn(func() int {
+			return int(size.Load())
+		})
+		timeTick := atomic.NewUint64(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().WriteAheadBuffer().Return(wb)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: forcePersisted}, nil
+		})
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+	isActive := func(pchannel types.PChannelInfo) bool {
+		select {
+		case <-i.BackpressureSignal(pchannel):
+			return true
+		default:
+			return false
+		}
+	}
+	tick := func(heavy, light int64) {
+		buffered["heavy"].Store(heavy)
+		buffered["light"].Store(light)
+		n := len(recorder.Decisions())
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == n+len(pchannels)
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	tick(400, 100)
+	assert.False(t, isActive(pchannels[0]))
+	assert.False(t, isActive(pchannels[1]))
+
+	// the saturated buffer of the heavy pchannel activates its backpressure, the light one is not throttled.
+	tick(1000, 100)
+	signal := i.BackpressureSignal(pchannels[0])
+	assert.True(t, isActive(pchannels[0]))
+	assert.False(t, isActive(pchannels[1]))
+	state := i.DebugDump()
+	assert.True(t, state.Channels[0].Backpressure)
+	assert.False(t, state.Channels[1].Backpressure)
+
+	// the backpressure is kept until the pressure is released.
+	tick(600, 100)
+	assert.True(t, isActive(pchannels[0]))
+	tick(300, 100)
+	assert.False(t, isActive(pchannels[0]))
+	assert.False(t, isActive(pchannels[1]))
+	assert.False(t, i.DebugDump().Channels[0].Backpressure)
+	// the previous signal is kept closed, a new one is returned after the backpressure is cleared.
+	_, ok := <-signal
+	assert.False(t, ok)
+}
+
+func TestInspectorFlushBarrier(t *testing.T) {
+	paramtable.Init()
+
+	clock := clockwork.NewFakeClock()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock))
+	defer i.Close()
+
+	// the persisted syncs fail with persistErr if it's given.
+	newOperator := func(pchannel types.PChannelInfo, persistErr error) *mock_inspector.MockTimeTickSyncOperator {
+		timeTick := atomic.NewUint64(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			if forcePersisted && persistErr != nil {
+				return inspector.SyncResult{}, persistErr
+			}
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: forcePersisted}, nil
+		})
+		return operator
+	}
+	pchannelA := types.PChannelInfo{Name: "barrier-a", Term: 1}
+	pchannelB := types.PChannelInfo{Name: "barrier-b", Term: 1}
+	pchannelFailed := types.PChannelInfo{Name: "barrier-failed", Term: 1}
+	pchannelNotFound := types.PChannelInfo{Name: "barrier-not-found", Term: 1}
+	for _, operator := range []*mock_inspector.MockTimeTickSyncOperator{
+		newOperator(pchannelA, nil),
+		newOperator(pchannelB, nil),
+		newOperator(pchannelFailed, errors.New("wal is not available")),
+	} {
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+
+	// the time ticks of the pchannels are emitted but not persisted.
+	for _, pchannel := range []types.PChannelInfo{pchannelA, pchannelA, pchannelB, pchannelFailed} {
+		state, err := i.ExportSyncState(pchannel)
+		assert.NoError(t, err)
+		i.TriggerSync(pchannel, false)
+		assert.Eventually(t, func() bool {
+			readable, err := i.IsReadable(pchannel, state.LastEmittedTimeTick+1)
+			return err == nil && readable
+		}, 5*time.Second, time.Millisecond)
+	}
+	captured := make(map[string]uint64)
+	for _, pchannel := range []types.PChannelInfo{pchannelA, pchannelB} {
+		state, err := i.ExportSyncState(pchannel)
+		assert.NoError(t, err)
+		assert.Zero(t, state.LastPersistedTimeTick)
+		captured[pchannel.Name] = state.LastEmittedTimeTick
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	persisted, err := i.FlushBarrier(ctx, []types.PChannelInfo{pchannelA, pchannelB, pchannelFailed, pchannelNotFound})
+	barrierErr := &inspector.FlushBarrierError{}
+	assert.ErrorAs(t, err, &barrierErr)
+	assert.Len(t, barrierErr.Failures, 2)
+	assert.ErrorIs(t, barrierErr.Failures[pchannelFailed.Name], context.DeadlineExceeded)
+	assert.ErrorIs(t, barrierErr.Failures[pchannelNotFound.Name], inspector.ErrSyncOperatorNotFound)
+
+	// each succeeded pchannel persisted at least its captured watermark.
+	assert.Len(t, persisted, 2)
+	for name, watermark := range captured {
+		assert.GreaterOrEqual(t, persisted[name], watermark)
+	}
+	for _, pchannel := range []types.PChannelInfo{pchannelA, pchannelB} {
+		state, err := i.ExportSyncState(pchannel)
+		assert.NoError(t, err)
+		assert.Equal(t, persisted[pchannel.Name], state.LastPersistedTimeTick)
+	}
+
+	// the barrier of the persisted pchannels returns immediately.
+	again, err := i.FlushBarrier(context.Background(), []types.PChannelInfo{pchannelA, pchannelB})
+	assert.NoError(t, err)
+	assert.Equal(t, persisted, again)
+}
+
+func TestInspectorMaxConcurrentPersistedSyncs(t *testing.T) {
+	paramtable.Init()
+
+	limit := 2
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()),
+		inspector.OptMaxConcurrentPersistedSyncs(limit))
+
+	// the persisted syncs block until released, so the dispatched syncs saturate the limit.
+	release := make(chan struct{})
+	inflight := atomic.NewInt32(0)
+	maxInflight := atomic.NewInt32(0)
+	persisted := atomic.NewInt32(0)
+	pchannels := make([]types.PChannelInfo, 0, 8)
+	for k := 0; k < 8; k++ {
+		pchannel := types.PChannelInfo{Name: fmt.Sprintf("persisted-limit-%d", k), Term: 1}
+		timeTick := atomic.NewUint64(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, true).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			current := inflight.Inc()
+			defer inflight.Dec()
+			for {
+				previous := maxInflight.Load()
+				if current <= previous || maxInflight.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return inspector.SyncResult{}, context.Cause(ctx)
+			}
+			persisted.Inc()
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: true}, nil
+		})
+		i.RegisterSyncOperator(operator)
+		pchannels = append(pchannels, pchannel)
+	}
+
+	// the non-persisted syncs of the other pchannels are not blocked by the saturated persisted syncs.
+	free := types.PChannelInfo{Name: "persisted-limit-free", Term: 1}
+	nonPersisted := atomic.NewInt32(0)
+	freeOperator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	freeOperator.EXPECT().Channel().Return(free)
+	freeOperator.EXPECT().Sync(mock.Anything, false).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		return inspector.SyncResult{TimeTick: uint64(nonPersisted.Inc())}, nil
+	})
+	i.RegisterSyncOperator(freeOperator)
+
+	for _, pchannel := range pchannels {
+		i.TriggerSync(pchannel, true)
+	}
+	assert.Eventually(t, func() bool {
+		return inflight.Load() == int32(limit)
+	}, 5*time.Second, time.Millisecond)
+	for k := 0; k < 3; k++ {
+		i.TriggerSync(free, false)
+		assert.Eventually(t, func() bool {
+			return nonPersisted.Load() == int32(k+1)
+		}, 5*time.Second, time.Millisecond)
+	}
+	// the busy pchannels are not quiescent until their dispatched syncs return.
+	quiescent, err := i.IsQuiescent(pchannels[0], 0)
+	assert.NoError(t, err)
+	assert.False(t, quiescent)
+	assert.Equal(t, int32(limit), inflight.Load())
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		for _, pchannel := range pchannels {
+			if quiescent, _ := i.IsQuiescent(pchannel, 0); !quiescent {
+				return false
+			}
+		}
+		return persisted.Load() == int32(len(pchannels))
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, int32(limit), maxInflight.Load())
+	assert.Zero(t, inflight.Load())
+
+	// the dispatched syncs waiting for the permit are canceled by the close of inspector.
+	release = make(chan struct{})
+	for _, pchannel := range pchannels {
+		i.TriggerSync(pchannel, true)
+	}
+	assert.Eventually(t, func() bool {
+		return inflight.Load() == int32(limit)
+	}, 5*time.Second, time.Millisecond)
+	i.Close()
+	assert.Zero(t, inflight.Load())
+	assert.Equal(t, int32(len(pchannels)), persisted.Load())
+}
+
+func TestInspectorNextSyncTimes(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	start := clock.Now()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock))
+	defer i.Close()
+	assert.Empty(t, i.NextSyncTimes())
+
+	newOperator := func(name string) *mock_inspector.MockTimeTickSyncOperator {
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(types.PChannelInfo{Name: name, Term: 1})
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil)
+		return operator
+	}
+	defaults := newOperator("next-default")
+	fixed := newOperator("next-fixed")
+	i.RegisterSyncOperator(defaults)
+	i.RegisterSyncOperator(fixed, inspector.OptSyncStrategy(inspector.NewFixedSyncStrategy(3*interval)))
+	defer i.UnregisterSyncOperator(defaults)
+	defer i.UnregisterSyncOperator(fixed)
+
+	// the new registered pchannels are due at the next tick.
+	assert.Equal(t, map[string]time.Time{"next-default": start, "next-fixed": start}, i.NextSyncTimes())
+
+	// the pchannels are rescheduled by their intervals after the first tick.
+	clock.BlockUntil(1)
+	clock.Advance(interval)
+	expected := map[string]time.Time{
+		"next-default": start.Add(2 * interval),
+		"next-fixed":   start.Add(4 * interval),
+	}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expected, i.NextSyncTimes())
+	}, 5*time.Second, time.Millisecond)
+
+	// only the default pchannel is synced at the second tick.
+	clock.BlockUntil(1)
+	clock.Advance(interval)
+	expected["next-default"] = start.Add(3 * interval)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expected, i.NextSyncTimes())
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestInspectorTriggerBeforeRegistration(t *testing.T) {
+	paramtable.Init()
+
+	// the periodic syncs are never due with the fake clock, so only the triggered syncs are performed.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+
+	for k := 0; k < 50; k++ {
+		pchannel := types.PChannelInfo{Name: fmt.Sprintf("trigger-before-register-%d", k), Term: 1}
+		persisted := make(chan struct{})
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			if forcePersisted {
+				close(persisted)
+			}
+			return inspector.SyncResult{}, nil
+		}).Maybe()
+
+		// interleave the trigger with the registration.
+		triggered := make(chan struct{})
+		go func() {
+			defer close(triggered)
+			i.TriggerSync(pchannel, true)
+		}()
+		if k%2 == 0 {
+			<-triggered
+			// wait until the trigger is served but parked by the background goroutine.
+			assert.Eventually(t, func() bool {
+				return i.DebugDump().ParkedTriggers == 1
+			}, 5*time.Second, time.Millisecond)
+		}
+		i.RegisterSyncOperator(operator)
+		<-triggered
+
+		// the trigger is served once the registration completes.
+		select {
+		case <-persisted:
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "the trigger before registration is lost")
+		}
+		assert.Zero(t, i.DebugDump().ParkedTriggers)
+		i.UnregisterSyncOperator(operator)
+	}
+
+	// the parked trigger of a stale term is discarded at the registration of a newer term.
+	stale := types.PChannelInfo{Name: "trigger-stale-term", Term: 1}
+	i.TriggerSync(stale, true)
+	assert.Eventually(t, func() bool {
+		return i.DebugDump().ParkedTriggers == 1
+	}, 5*time.Second, time.Millisecond)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(types.PChannelInfo{Name: stale.Name, Term: 2})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	assert.Zero(t, i.DebugDump().ParkedTriggers)
+}
+
+func TestInspectorIsQuiescent(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+	stableFor := 5 * interval
+
+	clock := clockwork.NewFakeClock()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock))
+	defer i.Close()
+
+	// only the force persisted syncs send a timetick message, so the periodic syncs keep the watermark stable.
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	newOperator := func(pchannel types.PChannelInfo, block bool) *mock_inspector.MockTimeTickSyncOperator {
+		timeTick := atomic.NewUint64(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			if !forcePersisted {
+				return inspector.SyncResult{}, nil
+			}
+			if block {
+				entered <- struct{}{}
+				<-release
+			}
+			return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: true}, nil
+		})
+		return operator
+	}
+	pchannelA := types.PChannelInfo{Name: "quiescent-a", Term: 1}
+	pchannelB := types.PChannelInfo{Name: "quiescent-b", Term: 1}
+	operatorA := newOperator(pchannelA, true)
+	operatorB := newOperator(pchannelB, false)
+	i.RegisterSyncOperator(operatorA)
+	i.RegisterSyncOperator(operatorB)
+	defer i.UnregisterSyncOperator(operatorA)
+	defer i.UnregisterSyncOperator(operatorB)
+
+	_, err := i.IsQuiescent(types.PChannelInfo{Name: "quiescent-not-found", Term: 1}, stableFor)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+	// the watermark of the new registered pchannel is stable since the registration.
+	quiescent, err := i.IsQuiescent(pchannelA, 0)
+	assert.NoError(t, err)
+	assert.True(t, quiescent)
+	quiescent, err = i.IsQuiescent(pchannelA, stableFor)
+	assert.NoError(t, err)
+	assert.False(t, quiescent)
+
+	// the pchannel of the in-flight sync is not quiescent,
+	// and the trigger of the other pchannel is pending while the sync goroutine is blocked.
+	i.TriggerSync(pchannelA, true)
+	<-entered
+	i.TriggerSync(pchannelB, true)
+	for _, pchannel := range []types.PChannelInfo{pchannelA, pchannelB} {
+		quiescent, err = i.IsQuiescent(pchannel, 0)
+		assert.NoError(t, err)
+		assert.False(t, quiescent)
+	}
+	close(release)
+	assert.Eventually(t, func() bool {
+		state, err := i.ExportSyncState(pchannelB)
+		return err == nil && state.LastPersistedTimeTick == 1
+	}, 5*time.Second, time.Millisecond)
+
+	// the watermarks are just advanced, so the pchannels are quiescent only after the stable window.
+	for _, pchannel := range []types.PChannelInfo{pchannelA, pchannelB} {
+		quiescent, err = i.IsQuiescent(pchannel, 0)
+		assert.NoError(t, err)
+		assert.True(t, quiescent)
+		quiescent, err = i.IsQuiescent(pchannel, stableFor)
+		assert.NoError(t, err)
+		assert.False(t, quiescent)
+	}
+	clock.Advance(stableFor - time.Millisecond)
+	quiescent, err = i.IsQuiescent(pchannelA, stableFor)
+	assert.NoError(t, err)
+	assert.False(t, quiescent)
+	clock.Advance(time.Millisecond)
+	for _, pchannel := range []types.PChannelInfo{pchannelA, pchannelB} {
+		// the periodic sync may be in flight just after the clock is advanced.
+		assert.Eventually(t, func() bool {
+			quiescent, err := i.IsQuiescent(pchannel, stableFor)
+			return err == nil && quiescent
+		}, 5*time.Second, time.Millisecond)
+	}
+}
+
+func TestInspectorSourceID(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+
+	type emitted struct {
+		channel  string
+		sourceID int64
+		ok       bool
+	}
+	ticks := make(chan emitted, 2)
+	newOperator := func(pchannel types.PChannelInfo) *mock_inspector.MockTimeTickSyncOperator {
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			sourceID, ok := inspector.SyncSourceID(ctx)
+			ticks <- emitted{channel: pchannel.Name, sourceID: sourceID, ok: ok}
+			return inspector.SyncResult{TimeTick: 1}, nil
+		})
+		return operator
+	}
+	tagged := types.PChannelInfo{Name: "source-tagged", Term: 1}
+	untagged := types.PChannelInfo{Name: "source-untagged", Term: 1}
+	taggedOperator := newOperator(tagged)
+	untaggedOperator := newOperator(untagged)
+	i.RegisterSyncOperator(taggedOperator, inspector.OptSourceID(42))
+	i.RegisterSyncOperator(untaggedOperator)
+	defer i.UnregisterSyncOperator(taggedOperator)
+	defer i.UnregisterSyncOperator(untaggedOperator)
+
+	// the source id is passed to the syncs of the tagged pchannel only.
+	i.TriggerSync(tagged, false)
+	assert.Equal(t, emitted{channel: tagged.Name, sourceID: 42, ok: true}, <-ticks)
+	i.TriggerSync(untagged, false)
+	assert.Equal(t, emitted{channel: untagged.Name}, <-ticks)
+
+	stats, err := i.SyncStats(tagged)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), stats.SourceID)
+	stats, err = i.SyncStats(untagged)
+	assert.NoError(t, err)
+	assert.Zero(t, stats.SourceID)
+}
+
+func TestInspectorReplicaWatermark(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+
+	// the replicas are at different persisted watermarks.
+	replicas := []types.PChannelInfo{
+		{Name: "test-replica-1", Term: 1},
+		{Name: "test-replica-2", Term: 1},
+		{Name: "test-replica-3", Term: 1},
+	}
+	states := []inspector.SyncState{
+		{LastPersistedTimeTick: 200, LastEmittedTimeTick: 250},
+		{LastPersistedTimeTick: 100, LastEmittedTimeTick: 300},
+		{LastPersistedTimeTick: 150, LastEmittedTimeTick: 150},
+	}
+	for idx, replica := range replicas {
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(replica)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil).Maybe()
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+		assert.NoError(t, i.ImportSyncState(replica, states[idx]))
+	}
+
+	watermark, err := i.ReplicaWatermark(replicas, inspector.ReplicaWatermarkMin)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), watermark)
+	watermark, err = i.ReplicaWatermark(replicas, inspector.ReplicaWatermarkMax)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), watermark)
+	watermark, err = i.ReplicaWatermark(replicas[2:], inspector.ReplicaWatermarkMin)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(150), watermark)
+
+	// the replica set should be registered, non-empty and aggregated by a known mode.
+	_, err = i.ReplicaWatermark(append(replicas, types.PChannelInfo{Name: "test-replica-4", Term: 1}), inspector.ReplicaWatermarkMax)
+	assert.ErrorIs(t, err, inspector.ErrSyncOperatorNotFound)
+	_, err = i.ReplicaWatermark(nil, inspector.ReplicaWatermarkMin)
+	assert.Error(t, err)
+	_, err = i.ReplicaWatermark(replicas, inspector.MinMax("avg"))
+	assert.Error(t, err)
+}
+
+func TestInspectorStatsByCollection(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so only the triggered syncs happen.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+
+	// two pchannels of collection 100, one of collection 200, and a shared one of no collection.
+	channels := map[types.PChannelInfo][]inspector.SyncResult{
+		{Name: "test-dml_0_100v0", Term: 1}: {{TimeTick: 101, Persisted: true}, {TimeTick: 102}},
+		{Name: "test-dml_1_100v1", Term: 1}: {{TimeTick: 101, Persisted: true}},
+		{Name: "test-dml_0_200v0", Term: 1}: {{TimeTick: 101}, {TimeTick: 102}, {TimeTick: 103, Persisted: true}},
+		{Name: "test-dml_2", Term: 1}:       {{TimeTick: 101, Persisted: true}},
+	}
+	for pchannel, results := range channels {
+		idx := atomic.NewInt32(0)
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			n := int(idx.Inc()) - 1
+			if n >= len(results) {
+				return inspector.SyncResult{}, nil
+			}
+			return results[n], nil
+		})
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+	assert.Eventually(t, func() bool {
+		done := true
+		for pchannel, results := range channels {
+			stats, err := i.SyncStats(pchannel)
+			if err != nil || stats.TotalSyncs() < int64(len(results)) {
+				done = false
+				i.TriggerSync(pchannel, false)
+			}
+		}
+		return done
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stats := i.StatsByCollection()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, inspector.CollectionSyncStats{Channels: 2, PersistedSyncs: 2, NonPersistedSyncs: 1}, stats[100])
+	assert.Equal(t, inspector.CollectionSyncStats{Channels: 1, PersistedSyncs: 1, NonPersistedSyncs: 2}, stats[200])
+	assert.Equal(t, int64(3), stats[200].TotalSyncs())
+
+	// the collection is derived by the configured resolver.
+	shared := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()),
+		inspector.OptCollectionResolver(func(pchannel types.PChannelInfo) (int64, bool) { return 1, true }))
+	defer shared.Close()
+	for pchannel := range channels {
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{}, nil).Maybe()
+		shared.RegisterSyncOperator(operator)
+		defer shared.UnregisterSyncOperator(operator)
+	}
+	assert.Equal(t, map[int64]inspector.CollectionSyncStats{1: {Channels: 4}}, shared.StatsByCollection())
+}
+
+func TestInspectorSyncOnRegistration(t *testing.T) {
+	paramtable.Init()
+
+	// the fake clock is never advanced, so no periodic sync happens.
+	newInspector := func(enabled bool) inspector.TimeTickSyncInspector {
+		return inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()), inspector.OptSyncOnRegistration(enabled))
+	}
+	newOperator := func(pchannel types.PChannelInfo, synced chan bool) *mock_inspector.MockTimeTickSyncOperator {
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+			synced <- forcePersisted
+			return inspector.SyncResult{TimeTick: 100}, nil
+		}).Maybe()
+		return operator
+	}
+
+	// the initial time tick is emitted right after the registration without any write.
+	i := newInspector(true)
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-sync-on-registration", Term: 1}
+	synced := make(chan bool, 10)
+	operator := newOperator(pchannel, synced)
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	select {
+	case forcePersisted := <-synced:
+		assert.False(t, forcePersisted)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the initial time tick should be emitted on registration")
+	}
+	assert.Eventually(t, func() bool {
+		readable, err := i.IsReadable(pchannel, 100)
+		return err == nil && readable
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// no time tick is emitted until the first write or periodic sync if disabled.
+	disabled := newInspector(false)
+	defer disabled.Close()
+	synced = make(chan bool, 10)
+	operator = newOperator(pchannel, synced)
+	disabled.RegisterSyncOperator(operator)
+	defer disabled.UnregisterSyncOperator(operator)
+	select {
+	case <-synced:
+		t.Fatal("no time tick should be emitted on registration if disabled")
+	case <-time.After(100 * time.Millisecond):
+	}
+	readable, err := disabled.IsReadable(pchannel, 100)
+	assert.NoError(t, err)
+	assert.False(t, readable)
+}
+
+func TestInspectorSyncTracing(t *testing.T) {
+	paramtable.Init()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+	defaultProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(defaultProvider)
+
+	// the fake clock is never advanced, so only the triggered syncs are performed.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()), inspector.OptSyncTracing(true))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-sync-tracing", Term: 1}
+	results := []inspector.SyncResult{{TimeTick: 100, Persisted: true}, {}}
+	idx := atomic.NewInt32(0)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		n := int(idx.Inc()) - 1
+		if n >= len(results) {
+			return inspector.SyncResult{}, errors.New("wal is not ready")
+		}
+		return results[n], nil
+	})
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	spansOf := func(n int) tracetest.SpanStubs {
+		assert.Eventually(t, func() bool {
+			return len(exporter.GetSpans()) >= n
+		}, 5*time.Second, 10*time.Millisecond)
+		return exporter.GetSpans()
+	}
+	attributesOf := func(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes {
+			attrs[kv.Key] = kv.Value
+		}
+		return attrs
+	}
+
+	// a span is produced per sync with the attributes of the sync.
+	i.TriggerSync(pchannel, true)
+	span := spansOf(1)[0]
+	assert.Equal(t, "TimeTickSync", span.Name)
+	attrs := attributesOf(span)
+	assert.Equal(t, "test-sync-tracing", attrs["channel"].AsString())
+	assert.Equal(t, string(inspector.SyncCauseTrigger), attrs["cause"].AsString())
+	assert.True(t, attrs["force_persisted"].AsBool())
+	assert.True(t, attrs["persisted"].AsBool())
+	assert.Equal(t, int64(100), attrs["time_tick"].AsInt64())
+	assert.Equal(t, int64(100), attrs["watermark"].AsInt64())
+	assert.Contains(t, attrs, attribute.Key("duration_ms"))
+	assert.Equal(t, "sent", attrs["outcome"].AsString())
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	// the sync that sends nothing keeps the watermark.
+	i.TriggerSync(pchannel, false)
+	attrs = attributesOf(spansOf(2)[1])
+	assert.False(t, attrs["force_persisted"].AsBool())
+	assert.Equal(t, int64(100), attrs["watermark"].AsInt64())
+	assert.Equal(t, "not_sent", attrs["outcome"].AsString())
+
+	// the failed sync is marked as error.
+	i.TriggerSync(pchannel, false)
+	span = spansOf(3)[2]
+	assert.Equal(t, "failed", attributesOf(span)["outcome"].AsString())
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Len(t, exporter.GetSpans(), 3)
+}
+
+func TestInspectorDryRun(t *testing.T) {
+	paramtable.Init()
+	interval := paramtable.Get().ProxyCfg.TimeTickInterval.GetAsDuration(time.Millisecond)
+
+	clock := clockwork.NewFakeClock()
+	start := clock.Now()
+	recorder := inspector.NewSyncDecisionRecorder()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptSyncDecisionRecorder(recorder), inspector.OptDryRun(true))
+	defer i.Close()
+
+	// the operator is never synced, so nothing is written into the wal or the write ahead buffer.
+	pchannel := types.PChannelInfo{Name: "test-dry-run", Term: 1}
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	emitted := atomic.NewInt32(0)
+	defer i.AddTickSink(func(types.PChannelInfo, uint64, bool) { emitted.Inc() })()
+
+	waitDecisions := func(n int) {
+		assert.Eventually(t, func() bool {
+			return len(recorder.Decisions()) == n
+		}, 5*time.Second, time.Millisecond)
+	}
+	clock.BlockUntil(1)
+	i.TriggerSync(pchannel, true)
+	waitDecisions(1)
+	clock.Advance(interval)
+	waitDecisions(2)
+
+	// the would-be syncs are still recorded.
+	assert.Equal(t, []inspector.SyncDecision{
+		{Timestamp: start, Channel: "test-dry-run", Cause: inspector.SyncCauseTrigger, ForcePersisted: true, DryRun: true},
+		{Timestamp: start.Add(interval), Channel: "test-dry-run", Cause: inspector.SyncCauseTimeTick, DryRun: true},
+	}, recorder.Decisions())
+	assert.Zero(t, emitted.Load())
+	readable, err := i.IsReadable(pchannel, 1)
+	assert.NoError(t, err)
+	assert.False(t, readable)
+	stats, err := i.SyncStats(pchannel)
+	assert.NoError(t, err)
+	assert.Zero(t, stats.PersistedSyncs+stats.NonPersistedSyncs)
+}
+
+func TestInspectorAudit(t *testing.T) {
+	paramtable.Init()
+
+	clock := clockwork.NewFakeClock()
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clock), inspector.OptAudit(time.Minute, 10*time.Second))
+	defer i.Close()
+
+	now := time.Now()
+	watermark := inspector.WatermarkFromPhysicalTime(now)
+	newOperator := func(name string, mvccTimeTick uint64, wb wab.ROWriteAheadBuffer) (types.PChannelInfo, *mock_inspector.MockTimeTickSyncOperator) {
+		pchannel := types.PChannelInfo{Name: name, Term: 1}
+		operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+		operator.EXPECT().Channel().Return(pchannel)
+		operator.EXPECT().MVCCManager().Return(mvcc.NewMVCCManager(mvccTimeTick))
+		operator.EXPECT().WriteAheadBuffer().Return(wb)
+		operator.EXPECT().Sync(mock.Anything, mock.Anything).Return(inspector.SyncResult{TimeTick: watermark}, nil)
+		return pchannel, operator
+	}
+
+	// the watermark lags behind the mvcc by a minute, e.g. the persisted time ticks are not emitted.
+	behind, behindOperator := newOperator("test-audit-behind", inspector.WatermarkFromPhysicalTime(now.Add(time.Minute)), nil)
+	// the watermark is consistent with the mvcc and the write ahead buffer.
+	consistentWB := mock_wab.NewMockROWriteAheadBuffer(t)
+	consistentWB.EXPECT().ReadFromExclusiveTimeTick(mock.Anything, watermark-1).Return(nil, nil)
+	consistent, consistentOperator := newOperator("test-audit-consistent", watermark, consistentWB)
+	// the watermark is ahead of both the mvcc and the write ahead buffer.
+	aheadWB := mock_wab.NewMockROWriteAheadBuffer(t)
+	aheadWB.EXPECT().ReadFromExclusiveTimeTick(mock.Anything, watermark-1).RunAndReturn(func(ctx context.Context, timetick uint64) (*wab.WriteAheadBufferReader, error) {
+		<-ctx.Done()
+		return nil, context.Cause(ctx)
+	})
+	ahead, aheadOperator := newOperator("test-audit-ahead", watermark-1, aheadWB)
+
+	for _, operator := range []*mock_inspector.MockTimeTickSyncOperator{behindOperator, consistentOperator, aheadOperator} {
+		i.RegisterSyncOperator(operator)
+		defer i.UnregisterSyncOperator(operator)
+	}
+	for _, pchannel := range []types.PChannelInfo{behind, consistent, ahead} {
+		i.TriggerSync(pchannel, false)
+		assert.Eventually(t, func() bool {
+			readable, err := i.IsReadable(pchannel, watermark)
+			return err == nil && readable
+		}, 5*time.Second, time.Millisecond)
+	}
+
+	findings := i.AuditNow()
+	assert.Len(t, findings, 3)
+	for k := range findings {
+		assert.Equal(t, clock.Now(), findings[k].Timestamp)
+		assert.Equal(t, watermark, findings[k].Watermark)
+		findings[k].Timestamp = time.Time{}
+	}
+	assert.Equal(t, []inspector.AuditFinding{
+		{Channel: ahead.Name, Kind: inspector.AuditWatermarkAheadOfBuffer, Watermark: watermark},
+		{Channel: ahead.Name, Kind: inspector.AuditWatermarkAheadOfMVCC, Watermark: watermark, MVCC: watermark - 1},
+		{Channel: behind.Name, Kind: inspector.AuditWatermarkBehindMVCC, Watermark: watermark, MVCC: inspector.WatermarkFromPhysicalTime(now.Add(time.Minute)), Lag: time.Minute},
+	}, findings)
+	behindCounter := metrics.WALTimeTickAuditFindingTotal.WithLabelValues(paramtable.GetStringNodeID(), behind.Name, string(inspector.AuditWatermarkBehindMVCC))
+	assert.Equal(t, float64(1), testutil.ToFloat64(behindCounter))
+
+	// the read-only pchannel is not audited.
+	i.SetReadOnly(ahead)
+	findings = i.AuditNow()
+	assert.Len(t, findings, 1)
+	assert.Equal(t, behind.Name, findings[0].Channel)
+	assert.Equal(t, float64(2), testutil.ToFloat64(behindCounter))
+
+	// the audit is performed periodically, wait for the tickers of the sync goroutine and the auditor.
+	clock.BlockUntil(2)
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(behindCounter) == 3
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestInspectorOldestUnpersisted(t *testing.T) {
+	paramtable.Init()
+
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+	pchannel := types.PChannelInfo{Name: "test-oldest-unpersisted", Term: 1}
+	timeTick := atomic.NewUint64(0)
+	operator := mock_inspector.NewMockTimeTickSyncOperator(t)
+	operator.EXPECT().Channel().Return(pchannel)
+	operator.EXPECT().Sync(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, forcePersisted bool) (inspector.SyncResult, error) {
+		return inspector.SyncResult{TimeTick: timeTick.Inc(), Persisted: forcePersisted}, nil
+	})
+	_, ok := i.OldestUnpersisted(pchannel)
+	assert.False(t, ok)
+	i.RegisterSyncOperator(operator)
+
+	syncs := 0
+	sync := func(forcePersisted bool) {
+		syncs++
+		i.TriggerSync(pchannel, forcePersisted)
+		assert.Eventually(t, func() bool {
+			stats, err := i.SyncStats(pchannel)
+			return err == nil && stats.PersistedSyncs+stats.NonPersistedSyncs == int64(syncs)
+		}, 5*time.Second, time.Millisecond)
+	}
+	expectOldest := func(expected uint64) {
+		oldest, ok := i.OldestUnpersisted(pchannel)
+		assert.Equal(t, expected != 0, ok)
+		assert.Equal(t, expected, oldest)
+	}
+	expectOldest(0)
+
+	// the oldest one of the emitted time ticks is reported until it's persisted.
+	sync(false)
+	sync(false)
+	expectOldest(1)
+	// the persisted time tick covers all the time ticks emitted before it.
+	sync(true)
+	expectOldest(0)
+	sync(false)
+	sync(false)
+	expectOldest(4)
+	sync(true)
+	sync(true)
+	expectOldest(0)
+
+	sync(false)
+	expectOldest(8)
+	i.UnregisterSyncOperator(operator)
+	_, ok = i.OldestUnpersisted(pchannel)
+	assert.False(t, ok)
+}
@@ -7,32 +7,74 @@ import (
 	"github.com/milvus-io/milvus/pkg/v2/util/syncutil"
 )
 
-// newSyncNotifier creates a new sync notifier.
-func newSyncNotifier() *syncNotifier {
+// newSyncNotifier creates a new sync notifier, the pending signals are capped by maxPending, 0 means unlimited.
+func newSyncNotifier(maxPending int) *syncNotifier {
 	return &syncNotifier{
-		cond:   syncutil.NewContextCond(&sync.Mutex{}),
-		signal: map[types.PChannelInfo]bool{},
+		cond:       syncutil.NewContextCond(&sync.Mutex{}),
+		signal:     map[types.PChannelInfo]bool{},
+		maxPending: maxPending,
 	}
 }
 
 // syncNotifier is a notifier for sync signal.
 type syncNotifier struct {
-	cond   *syncutil.ContextCond
-	signal map[types.PChannelInfo]bool
+	cond       *syncutil.ContextCond
+	signal     map[types.PChannelInfo]bool
+	maxPending int
+}
+
+// shedTrigger is the triggered sync that is shed because the pending signals reach the cap.
+type shedTrigger struct {
+	info      types.PChannelInfo
+	persisted bool
 }
 
 // AddAndNotify adds a signal and notifies the waiter.
-func (n *syncNotifier) AddAndNotify(pChannelInfo types.PChannelInfo, persisted bool) {
+// Once the pending signals reach the cap, the signal is shed by the policy:
+// the signal of another term of a pending pchannel is coalesced into it with the higher term,
+// a non-persisted signal is shed, and a persisted signal replaces a pending non-persisted one,
+// which is shed instead, the persisted signal is only shed if all the pending signals are persisted.
+// The shed signal is returned, false if nothing is shed.
+func (n *syncNotifier) AddAndNotify(pChannelInfo types.PChannelInfo, persisted bool) (shedTrigger, bool) {
 	n.cond.LockAndBroadcast()
 	defer n.cond.L.Unlock()
-	if _, ok := n.signal[pChannelInfo]; !ok {
-		n.signal[pChannelInfo] = persisted
-		return
-	}
-	if persisted {
+	if previous, ok := n.signal[pChannelInfo]; ok {
 		// only persisted signal can overwrite the previous signal
+		n.signal[pChannelInfo] = previous || persisted
+		return shedTrigger{}, false
+	}
+	if n.maxPending <= 0 || len(n.signal) < n.maxPending {
 		n.signal[pChannelInfo] = persisted
+		return shedTrigger{}, false
+	}
+
+	var victim *types.PChannelInfo
+	for pending, pendingPersisted := range n.signal {
+		if pending.Name == pChannelInfo.Name {
+			delete(n.signal, pending)
+			if pending.Term > pChannelInfo.Term {
+				pChannelInfo = pending
+			}
+			n.signal[pChannelInfo] = persisted || pendingPersisted
+			return shedTrigger{}, false
+		}
+		if victim == nil && !pendingPersisted {
+			victim = &pending
+		}
+	}
+	if !persisted || victim == nil {
+		return shedTrigger{info: pChannelInfo, persisted: persisted}, true
 	}
+	delete(n.signal, *victim)
+	n.signal[pChannelInfo] = persisted
+	return shedTrigger{info: *victim, persisted: false}, true
+}
+
+// Len returns the count of the pending signals.
+func (n *syncNotifier) Len() int {
+	n.cond.L.Lock()
+	defer n.cond.L.Unlock()
+	return len(n.signal)
 }
 
 // WaitChan returns the wait channel.
@@ -55,3 +97,74 @@ func (n *syncNotifier) Get() map[types.PChannelInfo]bool {
 	n.cond.L.Unlock()
 	return signal
 }
+
+// Pending returns a copy of the signals that are not consumed.
+func (n *syncNotifier) Pending() map[types.PChannelInfo]bool {
+	n.cond.L.Lock()
+	defer n.cond.L.Unlock()
+	pending := make(map[types.PChannelInfo]bool, len(n.signal))
+	for pchannel, persisted := range n.signal {
+		pending[pchannel] = persisted
+	}
+	return pending
+}
+
+// newParkedTriggers creates a new set of parked triggers.
+func newParkedTriggers() *parkedTriggers {
+	return &parkedTriggers{triggers: make(map[string]parkedTrigger)}
+}
+
+// parkedTriggers holds the triggered syncs of the pchannels that are not registered when the triggers are served,
+// so the trigger that races with the registration of its pchannel is served once the registration completes rather than dropped.
+// Only one trigger is kept for each pchannel, the trigger of the higher term replaces the lower one,
+// and the force persisted flag of the same term is merged.
+type parkedTriggers struct {
+	mu       sync.Mutex
+	triggers map[string]parkedTrigger
+}
+
+// parkedTrigger is the parked triggered sync of a pchannel.
+type parkedTrigger struct {
+	info           types.PChannelInfo
+	forcePersisted bool
+}
+
+// ParkIf parks the trigger if unregistered returns true, the check and the park are atomic with Take.
+// Returns whether the trigger is parked.
+func (p *parkedTriggers) ParkIf(info types.PChannelInfo, forcePersisted bool, unregistered func() bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !unregistered() {
+		return false
+	}
+	if parked, ok := p.triggers[info.Name]; ok {
+		if parked.info.Term > info.Term {
+			return true
+		}
+		if parked.info.Term == info.Term {
+			forcePersisted = forcePersisted || parked.forcePersisted
+		}
+	}
+	p.triggers[info.Name] = parkedTrigger{info: info, forcePersisted: forcePersisted}
+	return true
+}
+
+// Take takes the parked trigger of the registered pchannel, false if there's no trigger of the term.
+// The parked trigger of a lower term is stale and discarded, and the one of a higher term is kept for its registration.
+func (p *parkedTriggers) Take(info types.PChannelInfo) (forcePersisted bool, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	parked, ok := p.triggers[info.Name]
+	if !ok || parked.info.Term > info.Term {
+		return false, false
+	}
+	delete(p.triggers, info.Name)
+	return parked.forcePersisted, parked.info.Term == info.Term
+}
+
+// Len returns the count of the parked triggers.
+func (p *parkedTriggers) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.triggers)
+}
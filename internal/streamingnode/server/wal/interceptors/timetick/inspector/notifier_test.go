@@ -9,7 +9,7 @@ import (
 )
 
 func TestSyncNotifier(t *testing.T) {
-	n := newSyncNotifier()
+	n := newSyncNotifier(0)
 	ch := n.WaitChan()
 	assert.True(t, len(n.Get()) == 0)
 
@@ -49,6 +49,80 @@ func TestSyncNotifier(t *testing.T) {
 	}
 }
 
+func TestSyncNotifierShed(t *testing.T) {
+	n := newSyncNotifier(2)
+	p1 := types.PChannelInfo{Name: "p1", Term: 1}
+	p2 := types.PChannelInfo{Name: "p2", Term: 1}
+	p3 := types.PChannelInfo{Name: "p3", Term: 1}
+	_, shed := n.AddAndNotify(p1, false)
+	assert.False(t, shed)
+	_, shed = n.AddAndNotify(p2, true)
+	assert.False(t, shed)
+
+	// the signal of a pending pchannel is still coalesced.
+	_, shed = n.AddAndNotify(p1, false)
+	assert.False(t, shed)
+	assert.Equal(t, 2, n.Len())
+
+	// the new non-persisted signal is shed.
+	trigger, shed := n.AddAndNotify(p3, false)
+	assert.True(t, shed)
+	assert.Equal(t, shedTrigger{info: p3}, trigger)
+
+	// the signal of another term is coalesced with the higher term.
+	_, shed = n.AddAndNotify(types.PChannelInfo{Name: "p2", Term: 2}, false)
+	assert.False(t, shed)
+	assert.Equal(t, map[types.PChannelInfo]bool{p1: false, {Name: "p2", Term: 2}: true}, n.Pending())
+
+	// the persisted signal replaces the pending non-persisted one.
+	trigger, shed = n.AddAndNotify(p3, true)
+	assert.True(t, shed)
+	assert.Equal(t, shedTrigger{info: p1}, trigger)
+
+	// the persisted signal is shed if all the pending signals are persisted.
+	trigger, shed = n.AddAndNotify(p1, true)
+	assert.True(t, shed)
+	assert.Equal(t, shedTrigger{info: p1, persisted: true}, trigger)
+	assert.Equal(t, map[types.PChannelInfo]bool{{Name: "p2", Term: 2}: true, p3: true}, n.Get())
+}
+
+func TestParkedTriggers(t *testing.T) {
+	p := newParkedTriggers()
+	term1 := types.PChannelInfo{Name: "test", Term: 1}
+	term2 := types.PChannelInfo{Name: "test", Term: 2}
+	registered := func() bool { return false }
+	unregistered := func() bool { return true }
+
+	// the trigger of a registered pchannel is not parked.
+	assert.False(t, p.ParkIf(term1, true, registered))
+	_, ok := p.Take(term1)
+	assert.False(t, ok)
+
+	// the force persisted flag of the same term is merged.
+	assert.True(t, p.ParkIf(term1, true, unregistered))
+	assert.True(t, p.ParkIf(term1, false, unregistered))
+	assert.Equal(t, 1, p.Len())
+	forcePersisted, ok := p.Take(term1)
+	assert.True(t, ok)
+	assert.True(t, forcePersisted)
+	assert.Equal(t, 0, p.Len())
+
+	// the trigger of the higher term is kept for its registration.
+	assert.True(t, p.ParkIf(term2, false, unregistered))
+	assert.True(t, p.ParkIf(term1, true, unregistered))
+	_, ok = p.Take(term1)
+	assert.False(t, ok)
+	forcePersisted, ok = p.Take(term2)
+	assert.True(t, ok)
+	assert.False(t, forcePersisted)
+
+	// the trigger of the lower term is stale and discarded.
+	assert.True(t, p.ParkIf(term1, true, unregistered))
+	_, ok = p.Take(term2)
+	assert.False(t, ok)
+	assert.Equal(t, 0, p.Len())
+}
+
 func shouldBeBlocked(ch <-chan struct{}) {
 	select {
 	case <-ch:
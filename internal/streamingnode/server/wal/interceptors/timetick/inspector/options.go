@@ -0,0 +1,184 @@
+package inspector
+
+import (
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// InspectorOption is the option for time tick sync inspector.
+type InspectorOption func(*timeTickSyncInspectorImpl)
+
+// OptClock sets the clock of the inspector, the real clock is used by default.
+// A fake clock can be used to drive the time tick sync deterministically in test.
+func OptClock(clock clockwork.Clock) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.clock = clock
+	}
+}
+
+// OptSyncDecisionRecorder records every sync decision of the inspector into the recorder.
+// Only used in test.
+func OptSyncDecisionRecorder(recorder *SyncDecisionRecorder) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.recorder = recorder
+	}
+}
+
+// OptFailureBufferSize sets the buffer size of the sync failure events, the oldest event is dropped if the buffer is full.
+func OptFailureBufferSize(size int) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.failureBufferSize = size
+	}
+}
+
+// OptThroughputWindow sets the sliding window of the sync throughput, which is rounded up to seconds.
+// The window is 10 seconds by default.
+func OptThroughputWindow(window time.Duration) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.throughputWindow = window
+	}
+}
+
+// OptStallWatchdog enables the watchdog of the sync goroutine, a stall is reported if the in-flight sync lasts longer than the threshold,
+// and the stalled sync is canceled with ErrSyncStalled if cancel is true.
+// The watchdog is disabled by default, or if the threshold is not positive.
+func OptStallWatchdog(threshold time.Duration, cancel bool) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.stallThreshold = threshold
+		s.cancelStalledSync = cancel
+	}
+}
+
+// OptAudit enables the periodic audit of the emitted watermarks of all pchannels at the interval, see AuditNow.
+// A watermark lagging behind the mvcc of its pchannel longer than the tolerance is reported, 10 seconds by default.
+// The periodic audit is disabled by default, or if the interval is not positive, the non-positive tolerance is ignored.
+func OptAudit(interval time.Duration, tolerance time.Duration) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.auditInterval = interval
+		if tolerance > 0 {
+			s.auditTolerance = tolerance
+		}
+	}
+}
+
+// OptTriggerCoalescing coalesces the triggered non-persisted syncs of each pchannel, so a burst of triggers emits one time tick.
+// The coalesced sync is performed once no trigger arrives within the window since the last one,
+// or once maxDelay has passed since the first one, so a time tick is still emitted per maxDelay under continuous triggering.
+// Both are checked at every tick, so they're quantized to the tick interval.
+// The force persisted triggers are never coalesced, and serve the coalesced ones of their pchannels.
+// The coalescing is disabled by default, or if the window is not positive, the window is used as maxDelay if it's not positive.
+func OptTriggerCoalescing(window time.Duration, maxDelay time.Duration) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.coalesceWindow = window
+		s.coalesceCap = maxDelay
+		if maxDelay <= 0 {
+			s.coalesceCap = window
+		}
+	}
+}
+
+// OptMaxClockSkew clamps the watermark advanced by a synced time tick to the clock of the inspector plus the max skew,
+// see clampSkewedTimeTick for the policy. The clamping is disabled by default, or if the max skew is not positive.
+func OptMaxClockSkew(maxSkew time.Duration) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.maxClockSkew = maxSkew
+	}
+}
+
+// OptMaxConcurrentPersistedSyncs dispatches the force persisted syncs of pchannels to perform concurrently under the limit,
+// the dispatched sync waits for the permit within its context, the non-persisted syncs are not limited.
+// The persisted syncs are performed one by one by the background goroutine by default, or if the limit is not positive.
+func OptMaxConcurrentPersistedSyncs(limit int) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.maxConcurrentPersistedSyncs = limit
+	}
+}
+
+// OptMaxPendingTriggers caps the triggered syncs that are not taken by the sync goroutine yet,
+// so the backlog stays bounded when the syncs are stalled, e.g. the storage of wal is out.
+// The triggers of the same pchannel are always coalesced, once the cap is reached the new triggers are shed,
+// the force persisted trigger is favored by replacing a pending non-persisted one, see TriggerSync.
+// The triggers are unlimited by default, or if the cap is not positive.
+func OptMaxPendingTriggers(limit int) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.maxPendingTriggers = limit
+	}
+}
+
+// OptSyncOnRegistration triggers a non-persisted sync of the pchannel once it's registered,
+// so a time tick is emitted at the recovered or current watermark even if there's no write yet,
+// rather than the readers stalling until the first write or periodic sync. It's disabled by default.
+func OptSyncOnRegistration(enabled bool) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.syncOnRegistration = enabled
+	}
+}
+
+// OptSyncTracing traces each performed sync with an OpenTelemetry span of the global tracer provider,
+// with the attributes of the channel, cause, persistence, time tick, watermark, duration and outcome of the sync.
+// The skipped syncs are not traced. It's disabled by default.
+func OptSyncTracing(enabled bool) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.traceSync = enabled
+	}
+}
+
+// OptDryRun makes the inspector report the syncs it would perform by log and metrics without performing them,
+// for validating the new scheduling configs, e.g. the sync strategies and tenant budgets.
+// No time tick is written into the wal in dry-run mode, so the watermarks of the pchannels never advance
+// and the readers of them stall. It's disabled by default.
+func OptDryRun(enabled bool) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.dryRun = enabled
+	}
+}
+
+// OptCollectionResolver sets the resolver to derive the collection of the pchannels for StatsByCollection,
+// DefaultCollectionResolver is used by default.
+func OptCollectionResolver(resolver CollectionResolver) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.collections = resolver
+	}
+}
+
+// OptTenantResolver sets the resolver to group the pchannels into tenants,
+// the persisted syncs of each tenant are limited by its budget, DefaultTenantResolver is used by default.
+func OptTenantResolver(resolver TenantResolver) InspectorOption {
+	return func(s *timeTickSyncInspectorImpl) {
+		s.tenants = newTenantLimiters(resolver)
+	}
+}
+
+// RegisterOption is the option for registering a sync operator.
+type RegisterOption func(*syncChannel)
+
+// OptSyncStrategy sets the periodic sync strategy of the pchannel,
+// a fixed strategy with the time tick interval is used by default.
+func OptSyncStrategy(strategy SyncStrategy) RegisterOption {
+	return func(c *syncChannel) {
+		c.strategy = strategy
+	}
+}
+
+// OptSourceID sets the source id of the emitted time ticks of the pchannel, e.g. the id of the emitting node,
+// for the provenance of the time ticks when there are multiple writers.
+// The source id is passed to every sync of the operator by SyncSourceID of the context, and reported by SyncStats.
+func OptSourceID(sourceID int64) RegisterOption {
+	return func(c *syncChannel) {
+		c.sourceID = sourceID
+		c.hasSourceID = true
+	}
+}
+
+// OptSyncWeight sets the weight of the pchannel in the fair queue of triggered syncs, the default weight is 1.
+// When the sync goroutine is saturated, the pchannels are served in proportion to their weights,
+// so an important pchannel is favored but a low-weight one is never starved.
+// A non-positive weight is ignored.
+func OptSyncWeight(weight float64) RegisterOption {
+	return func(c *syncChannel) {
+		if weight > 0 {
+			c.weight = weight
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package inspector
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// getBufferPressureWatermarks returns the high and low watermarks of the total size of write ahead buffers,
+// the detection of buffer pressure is disabled if the high watermark is not positive.
+func getBufferPressureWatermarks() (high int64, low int64) {
+	high = paramtable.Get().StreamingCfg.TimeTickBufferPressureHighWatermark.GetAsSize()
+	low = paramtable.Get().StreamingCfg.TimeTickBufferPressureLowWatermark.GetAsSize()
+	if low <= 0 || low > high {
+		low = high
+	}
+	return high, low
+}
+
+// observeBufferPressure sums up the write ahead buffers of the channels and updates the buffer pressure.
+// The pressure is raised once the total size exceeds the high watermark, and released once the total size falls below the low watermark,
+// so the sync strategy doesn't flap around one watermark.
+// The buffered size of each channel is returned to prioritize the syncs, nil if the detection is disabled.
+func (s *timeTickSyncInspectorImpl) observeBufferPressure(channels []*syncChannel) (map[*syncChannel]int, bool) {
+	high, low := getBufferPressureWatermarks()
+	if high <= 0 {
+		if s.bufferPressure.Swap(false) {
+			log.Info("write ahead buffer pressure is released because the detection is disabled")
+			metrics.WALTimeTickBufferPressure.WithLabelValues(paramtable.GetStringNodeID()).Set(0)
+		}
+		s.updateBackpressure(channels, nil, 0, false)
+		return nil, false
+	}
+
+	buffered := make(map[*syncChannel]int, len(channels))
+	total := int64(0)
+	for _, channel := range channels {
+		if wb := channel.operator.WriteAheadBuffer(); wb != nil {
+			buffered[channel] = wb.BufferedBytes()
+			total += int64(buffered[channel])
+		}
+	}
+	underPressure := s.bufferPressure.Load()
+	switch {
+	case !underPressure && total > high:
+		underPressure = true
+		log.Warn("write ahead buffers are under pressure, the periodic time tick syncs are persisted",
+			zap.Int64("bufferedBytes", total), zap.Int64("highWatermark", high))
+		metrics.WALTimeTickBufferPressure.WithLabelValues(paramtable.GetStringNodeID()).Set(1)
+	case underPressure && total < low:
+		underPressure = false
+		log.Info("write ahead buffer pressure is released",
+			zap.Int64("bufferedBytes", total), zap.Int64("lowWatermark", low))
+		metrics.WALTimeTickBufferPressure.WithLabelValues(paramtable.GetStringNodeID()).Set(0)
+	}
+	s.bufferPressure.Store(underPressure)
+	s.updateBackpressure(channels, buffered, total, underPressure)
+	return buffered, underPressure
+}
+
+// updateBackpressure updates the backpressure signals of the channels.
+// Under buffer pressure, the channels that hold at least their fair share of the buffered bytes are under backpressure,
+// so only the heavy producers are throttled. All the signals are cleared once the pressure is released.
+func (s *timeTickSyncInspectorImpl) updateBackpressure(channels []*syncChannel, buffered map[*syncChannel]int, total int64, underPressure bool) {
+	for _, channel := range channels {
+		active := underPressure && total > 0 && int64(buffered[channel])*int64(len(channels)) >= total
+		if channel.backpressure.Set(active) {
+			log.Info("backpressure of pchannel is changed",
+				zap.String("channel", channel.operator.Channel().Name),
+				zap.Bool("active", active),
+				zap.Int("bufferedBytes", buffered[channel]),
+				zap.Int64("totalBufferedBytes", total))
+		}
+	}
+}
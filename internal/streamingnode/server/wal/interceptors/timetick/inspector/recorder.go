@@ -0,0 +1,58 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	SyncCauseTimeTick SyncCause = "timetick" // the sync is caused by the periodic time tick.
+	SyncCauseTrigger  SyncCause = "trigger"  // the sync is caused by TriggerSync.
+)
+
+// SyncCause is the cause of a sync decision.
+type SyncCause string
+
+// SyncDecision is a scheduler decision of the inspector.
+type SyncDecision struct {
+	Timestamp      time.Time // the clock time when the decision is made.
+	Channel        string
+	Cause          SyncCause
+	ForcePersisted bool
+	Skipped        bool       // the sync is skipped because the channel is read-only, in maintenance, rate limited or busy.
+	RateLimited    bool       // the sync is skipped because the persisted sync budget of the tenant is exhausted.
+	Maintenance    bool       // the sync is skipped because the channel is in maintenance.
+	Stale          bool       // the result is discarded because the pchannel is re-registered during the sync.
+	DryRun         bool       // the sync is not performed because the inspector is in dry-run mode, the result is always zero.
+	Dispatched     bool       // the persisted sync is dispatched to perform concurrently, its result is recorded once it returns.
+	Busy           bool       // the sync is skipped because a dispatched persisted sync of the channel is in flight.
+	Result         SyncResult // the result of the sync, zero if skipped or failed.
+	Err            error      // the error of the sync.
+}
+
+// NewSyncDecisionRecorder creates a new sync decision recorder.
+func NewSyncDecisionRecorder() *SyncDecisionRecorder {
+	return &SyncDecisionRecorder{}
+}
+
+// SyncDecisionRecorder records the sync decisions of the inspector in order, only used in test.
+type SyncDecisionRecorder struct {
+	mu        sync.Mutex
+	decisions []SyncDecision
+}
+
+// record appends a decision.
+func (r *SyncDecisionRecorder) record(decision SyncDecision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions = append(r.decisions, decision)
+}
+
+// Decisions returns a copy of the recorded decisions.
+func (r *SyncDecisionRecorder) Decisions() []SyncDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	decisions := make([]SyncDecision, len(r.decisions))
+	copy(decisions, r.decisions)
+	return decisions
+}
@@ -0,0 +1,44 @@
+package inspector
+
+import (
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+)
+
+// MinMax selects how the watermarks of a replica set are aggregated.
+type MinMax string
+
+const (
+	// ReplicaWatermarkMin takes the least advanced replica, the timestamp is readable on every replica of the set.
+	ReplicaWatermarkMin MinMax = "min"
+	// ReplicaWatermarkMax takes the most advanced replica, the timestamp is readable on at least one replica of the set.
+	ReplicaWatermarkMax MinMax = "max"
+)
+
+// ReplicaWatermark aggregates the persisted watermarks of the replicas of the same logical channel.
+func (s *timeTickSyncInspectorImpl) ReplicaWatermark(infos []types.PChannelInfo, mode MinMax) (uint64, error) {
+	if mode != ReplicaWatermarkMin && mode != ReplicaWatermarkMax {
+		return 0, errors.Newf("unknown replica watermark mode %q", mode)
+	}
+	if len(infos) == 0 {
+		return 0, errors.New("replica set of replica watermark should not be empty")
+	}
+	var watermark uint64
+	for idx, info := range infos {
+		channel, ok := s.channels.Get(info.Name)
+		if !ok {
+			return 0, errors.Wrapf(ErrSyncOperatorNotFound, "replica %s", info.Name)
+		}
+		persisted := channel.SyncState().LastPersistedTimeTick
+		switch {
+		case idx == 0:
+			watermark = persisted
+		case mode == ReplicaWatermarkMin:
+			watermark = min(watermark, persisted)
+		default:
+			watermark = max(watermark, persisted)
+		}
+	}
+	return watermark, nil
+}
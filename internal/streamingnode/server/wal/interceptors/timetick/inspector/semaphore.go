@@ -0,0 +1,56 @@
+package inspector
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// persistedSyncSemaphore limits the number of concurrent persisted syncs,
+// which write the time tick messages into the wal and are IO heavy.
+// A nil semaphore means unlimited.
+type persistedSyncSemaphore struct {
+	sem *semaphore.Weighted
+}
+
+// newPersistedSyncSemaphore creates a new semaphore of the limit, nil is returned if the limit is not positive.
+func newPersistedSyncSemaphore(limit int) *persistedSyncSemaphore {
+	if limit <= 0 {
+		return nil
+	}
+	return &persistedSyncSemaphore{sem: semaphore.NewWeighted(int64(limit))}
+}
+
+// Acquire waits for the permit of a persisted sync of the channel until the context is done,
+// the cause of the context is returned if the wait is canceled.
+// The returned function should be called to release the permit once the sync returns.
+func (p *persistedSyncSemaphore) Acquire(ctx context.Context, channel string) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	start := time.Now()
+	err := p.sem.Acquire(ctx, 1)
+	metrics.WALTimeTickPersistedSyncWaitSeconds.WithLabelValues(paramtable.GetStringNodeID(), channel).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, context.Cause(ctx)
+	}
+	return func() { p.sem.Release(1) }, nil
+}
+
+// syncOperator performs the sync of the channel, the force persisted sync waits for the permit of the persisted syncs first.
+// The wait is done within the context of the sync, so it's bounded by the stall watchdog and the close of inspector.
+func (s *timeTickSyncInspectorImpl) syncOperator(ctx context.Context, channel *syncChannel, forcePersisted bool) (SyncResult, error) {
+	if !forcePersisted {
+		return channel.operator.Sync(ctx, false)
+	}
+	release, err := s.persistedSyncs.Acquire(ctx, channel.operator.Channel().Name)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	defer release()
+	return channel.operator.Sync(ctx, true)
+}
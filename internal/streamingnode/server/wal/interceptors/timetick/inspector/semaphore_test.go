@@ -0,0 +1,45 @@
+package inspector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+func TestPersistedSyncSemaphore(t *testing.T) {
+	paramtable.Init()
+
+	// unlimited if the limit is not positive.
+	assert.Nil(t, newPersistedSyncSemaphore(0))
+	var unlimited *persistedSyncSemaphore
+	release, err := unlimited.Acquire(context.Background(), "p1")
+	assert.NoError(t, err)
+	release()
+
+	sem := newPersistedSyncSemaphore(1)
+	release, err = sem.Acquire(context.Background(), "p1")
+	assert.NoError(t, err)
+
+	// the wait respects the deadline of the sync.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = sem.Acquire(ctx, "p2")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the cause of the canceled sync is returned.
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(ErrSyncStalled)
+	_, err = sem.Acquire(ctx, "p2")
+	assert.True(t, errors.Is(err, ErrSyncStalled))
+
+	// the permit is available once released.
+	release()
+	release, err = sem.Acquire(context.Background(), "p2")
+	assert.NoError(t, err)
+	release()
+}
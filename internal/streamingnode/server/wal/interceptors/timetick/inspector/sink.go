@@ -0,0 +1,123 @@
+package inspector
+
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+)
+
+// tickSinkBufferSize is the buffer size of the emitted time ticks of each sink.
+const tickSinkBufferSize = 1024
+
+// TickSink receives the time ticks emitted by the syncs of inspector, e.g. a metrics exporter or a debug tap.
+type TickSink func(info types.PChannelInfo, ts uint64, persisted bool)
+
+// emittedTick is a time tick emitted by a sync.
+type emittedTick struct {
+	info      types.PChannelInfo
+	ts        uint64
+	persisted bool
+}
+
+// newTickSinks creates a new set of tick sinks.
+func newTickSinks() *tickSinks {
+	return &tickSinks{
+		sinks: make(map[int64]*tickSink),
+	}
+}
+
+// tickSinks mirrors the emitted time ticks to the registered sinks.
+type tickSinks struct {
+	mu      sync.RWMutex
+	nextID  int64
+	sinks   map[int64]*tickSink
+	dropped atomic.Uint64 // the count of ticks dropped by the slow sinks.
+}
+
+// tickSink is a registered sink, which is called by its own goroutine, so a slow sink never blocks the sync.
+type tickSink struct {
+	sink TickSink
+	ch   chan emittedTick
+	stop chan struct{}
+}
+
+// Add registers the sink and returns the function to remove it.
+func (s *tickSinks) Add(sink TickSink) func() {
+	ts := &tickSink{
+		sink: sink,
+		ch:   make(chan emittedTick, tickSinkBufferSize),
+		stop: make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.sinks[id] = ts
+	s.mu.Unlock()
+
+	go ts.run()
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// the sink may be already removed, or removed by Close.
+		if _, ok := s.sinks[id]; ok {
+			delete(s.sinks, id)
+			close(ts.stop)
+		}
+	}
+}
+
+// Emit mirrors the time tick to all sinks, it's only called by the background goroutine of inspector.
+// The oldest tick of a sink is dropped if its buffer is full.
+func (s *tickSinks) Emit(tick emittedTick) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sink := range s.sinks {
+		for !sink.offer(tick) {
+			// the sink may receive concurrently, so the drop may find an empty channel.
+			select {
+			case <-sink.ch:
+				s.dropped.Inc()
+			default:
+			}
+		}
+	}
+}
+
+// Dropped returns the count of the ticks dropped by the slow sinks.
+func (s *tickSinks) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close removes all the sinks, the ticks that are not received by the sinks yet are discarded.
+func (s *tickSinks) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sink := range s.sinks {
+		delete(s.sinks, id)
+		close(sink.stop)
+	}
+}
+
+// offer sends the tick to the sink without blocking, returns false if the buffer is full.
+func (t *tickSink) offer(tick emittedTick) bool {
+	select {
+	case t.ch <- tick:
+		return true
+	default:
+		return false
+	}
+}
+
+// run calls the sink with the emitted ticks until the sink is removed.
+func (t *tickSink) run() {
+	for {
+		select {
+		case <-t.stop:
+			return
+		case tick := <-t.ch:
+			t.sink(tick.info, tick.ts, tick.persisted)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package inspector
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+const (
+	skewDirectionBehind = "behind" // the physical time of the synced time tick is behind the watermark.
+	skewDirectionAhead  = "ahead"  // the physical time of the synced time tick is ahead of the clock of inspector.
+)
+
+// clampSkewedTimeTick detects the clock skew of the synced time tick, and returns the watermark to advance to.
+// The time ticks are allocated by the hybrid clock of the node, whose physical time follows the system clock,
+// so a skewed system clock, e.g. corrected by NTP, makes the time ticks jump or regress.
+//
+//   - A time tick whose physical time is behind the watermark is a regression of the clock,
+//     the watermark is held, see advanceWatermark, until the time ticks catch up with it.
+//   - A time tick whose physical time is ahead of the clock of inspector more than the max skew is clamped,
+//     the watermark advances to the clock plus the max skew and follows the clock while the time ticks keep ahead,
+//     so the watermark never jumps far ahead, and the readers stall at most the max skew once the clock is corrected.
+//     The clamped watermark is less than the time tick, which stays safe for the readers.
+//
+// Both skews are observed by metrics.
+func (s *timeTickSyncInspectorImpl) clampSkewedTimeTick(channel *syncChannel, timeTick uint64) uint64 {
+	name := channel.operator.Channel().Name
+	current, _ := s.watermarks.Get(name)
+	if behind := WatermarkPhysicalTime(current).Sub(WatermarkPhysicalTime(timeTick)); current > 0 && behind > 0 {
+		metrics.WALTimeTickClockSkewSeconds.WithLabelValues(paramtable.GetStringNodeID(), name, skewDirectionBehind).Observe(behind.Seconds())
+		log.RatedWarn(10, "physical time of the synced time tick is behind the watermark, the clock may be skewed",
+			zap.String("channel", name),
+			zap.Uint64("timeTick", timeTick),
+			zap.Uint64("watermark", current),
+			zap.Duration("behind", behind))
+		return timeTick
+	}
+	if s.maxClockSkew <= 0 {
+		return timeTick
+	}
+	now := s.clock.Now()
+	ahead := WatermarkPhysicalTime(timeTick).Sub(now)
+	if ahead <= s.maxClockSkew {
+		return timeTick
+	}
+	metrics.WALTimeTickClockSkewSeconds.WithLabelValues(paramtable.GetStringNodeID(), name, skewDirectionAhead).Observe(ahead.Seconds())
+	clamped := max(WatermarkFromPhysicalTime(now.Add(s.maxClockSkew)), current)
+	log.RatedWarn(10, "physical time of the synced time tick is ahead of the clock, the watermark is clamped",
+		zap.String("channel", name),
+		zap.Uint64("timeTick", timeTick),
+		zap.Uint64("clamped", clamped),
+		zap.Duration("ahead", ahead),
+		zap.Duration("maxClockSkew", s.maxClockSkew))
+	return clamped
+}
@@ -0,0 +1,116 @@
+package inspector
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/util/merr"
+)
+
+// stateSnapshotVersion is the version of the snapshot format produced by SnapshotState.
+// The new fields can be added without bumping the version, because the unknown fields are ignored at restore,
+// the version should be bumped once the meaning of the existing fields is changed.
+const stateSnapshotVersion = 1
+
+// ErrSnapshotIncompatible is returned if the snapshot is malformed or its version is not supported by RestoreState.
+var ErrSnapshotIncompatible = errors.New("inspector state snapshot is incompatible")
+
+// stateSnapshot is the serialized emission state of all pchannels of the inspector.
+type stateSnapshot struct {
+	Version  int                    `json:"version"`
+	Channels []channelStateSnapshot `json:"channels"` // the registered pchannels in order of name.
+}
+
+// channelStateSnapshot is the serialized emission state of one pchannel, see SyncState.
+type channelStateSnapshot struct {
+	Channel               string `json:"channel"`
+	LastPersistedTimeTick uint64 `json:"last_persisted_time_tick"`
+	LastEmittedTimeTick   uint64 `json:"last_emitted_time_tick"`
+	LastEmittedSequence   uint64 `json:"last_emitted_sequence"`
+}
+
+// syncState returns the handover state of the pchannel in the snapshot.
+func (c channelStateSnapshot) syncState() SyncState {
+	return SyncState{
+		LastPersistedTimeTick: c.LastPersistedTimeTick,
+		LastEmittedTimeTick:   c.LastEmittedTimeTick,
+		LastEmittedSequence:   c.LastEmittedSequence,
+	}
+}
+
+// SnapshotState serializes the emission state of all registered pchannels.
+func (s *timeTickSyncInspectorImpl) SnapshotState() []byte {
+	snapshot := stateSnapshot{
+		Version:  stateSnapshotVersion,
+		Channels: make([]channelStateSnapshot, 0),
+	}
+	s.channels.Range(func(name string, channel *syncChannel) bool {
+		state := channel.SyncState()
+		snapshot.Channels = append(snapshot.Channels, channelStateSnapshot{
+			Channel:               name,
+			LastPersistedTimeTick: state.LastPersistedTimeTick,
+			LastEmittedTimeTick:   state.LastEmittedTimeTick,
+			LastEmittedSequence:   state.LastEmittedSequence,
+		})
+		return true
+	})
+	sort.Slice(snapshot.Channels, func(i, j int) bool {
+		return snapshot.Channels[i].Channel < snapshot.Channels[j].Channel
+	})
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		panic("marshal inspector state snapshot failed, critical bug in code")
+	}
+	return data
+}
+
+// RestoreState restores the emission state of the registered pchannels from the snapshot.
+func (s *timeTickSyncInspectorImpl) RestoreState(data []byte) error {
+	snapshot, err := parseStateSnapshot(data)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, channelSnapshot := range snapshot.Channels {
+		channel, ok := s.channels.Get(channelSnapshot.Channel)
+		if !ok {
+			// the standby may not host all the pchannels of the snapshot.
+			log.Info("RestoreState on a sync operator that is not registered, skipped", zap.String("channel", channelSnapshot.Channel))
+			continue
+		}
+		state := channelSnapshot.syncState()
+		if err := channel.ImportSyncState(state); err != nil {
+			errs = append(errs, errors.Wrapf(err, "restore sync state of pchannel %s", channelSnapshot.Channel))
+			continue
+		}
+		s.advanceWatermark(channel, state.LastEmittedTimeTick, watermarkSourceSnapshot)
+	}
+	log.Info("RestoreState", zap.Int("version", snapshot.Version), zap.Int("channels", len(snapshot.Channels)), zap.Int("failures", len(errs)))
+	return merr.Combine(errs...)
+}
+
+// parseStateSnapshot decodes and validates the snapshot, so nothing is restored from a malformed snapshot.
+func parseStateSnapshot(data []byte) (stateSnapshot, error) {
+	var snapshot stateSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return stateSnapshot{}, errors.Wrapf(ErrSnapshotIncompatible, "decode snapshot, %s", err.Error())
+	}
+	if snapshot.Version < 1 || snapshot.Version > stateSnapshotVersion {
+		return stateSnapshot{}, errors.Wrapf(ErrSnapshotIncompatible, "snapshot version %d, supported version %d", snapshot.Version, stateSnapshotVersion)
+	}
+	channels := make(map[string]struct{}, len(snapshot.Channels))
+	for _, channelSnapshot := range snapshot.Channels {
+		if _, ok := channels[channelSnapshot.Channel]; ok {
+			return stateSnapshot{}, errors.Wrapf(ErrSnapshotIncompatible, "duplicate pchannel %s", channelSnapshot.Channel)
+		}
+		channels[channelSnapshot.Channel] = struct{}{}
+		if err := channelSnapshot.syncState().validate(); err != nil {
+			return stateSnapshot{}, errors.Wrapf(ErrSnapshotIncompatible, "sync state of pchannel %s, %s", channelSnapshot.Channel, err.Error())
+		}
+	}
+	return snapshot, nil
+}
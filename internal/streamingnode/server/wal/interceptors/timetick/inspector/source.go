@@ -0,0 +1,37 @@
+package inspector
+
+import "context"
+
+// syncSourceIDKey is the context key of the source id of the sync.
+type syncSourceIDKey struct{}
+
+// withSyncSourceID returns a context that carries the source id of the sync to the operator.
+func withSyncSourceID(ctx context.Context, sourceID int64) context.Context {
+	return context.WithValue(ctx, syncSourceIDKey{}, sourceID)
+}
+
+// syncSequenceKey is the context key of the sequence number of the sync.
+type syncSequenceKey struct{}
+
+// withSyncSequence returns a context that carries the sequence number of the sync to the operator.
+func withSyncSequence(ctx context.Context, sequence uint64) context.Context {
+	return context.WithValue(ctx, syncSequenceKey{}, sequence)
+}
+
+// SyncSequence returns the sequence number assigned to the timetick message emitted by the sync,
+// which should be stamped into the message, so the consumers can detect the dropped or reordered time ticks.
+// The sequence numbers of the emitted timetick messages of a pchannel are consecutive from 1,
+// the number is reused by the next sync if no timetick message is sent.
+// False is returned if the sync is not performed by the inspector.
+func SyncSequence(ctx context.Context) (uint64, bool) {
+	sequence, ok := ctx.Value(syncSequenceKey{}).(uint64)
+	return sequence, ok
+}
+
+// SyncSourceID returns the source id that is configured at the registration of the operator,
+// which should be stamped into the emitted timetick message for provenance.
+// False is returned if the source id is not configured, the operator should stamp its own default.
+func SyncSourceID(ctx context.Context) (int64, bool) {
+	sourceID, ok := ctx.Value(syncSourceIDKey{}).(int64)
+	return sourceID, ok
+}
@@ -0,0 +1,52 @@
+package inspector
+
+// SyncStats is the sync statistics of one pchannel.
+type SyncStats struct {
+	PersistedSyncs    int64 `json:"persisted_syncs"`     // the count of syncs that persisted the timetick message into wal.
+	NonPersistedSyncs int64 `json:"non_persisted_syncs"` // the count of syncs that only sent the timetick message into memory.
+
+	MaintenanceReason string `json:"maintenance_reason,omitempty"` // the reason of maintenance, empty if the pchannel is not in maintenance.
+	SourceID          int64  `json:"source_id,omitempty"`          // the source id of the emitted time ticks, 0 if it's not configured.
+
+	LastEmittedSequence uint64 `json:"last_emitted_sequence"` // the sequence number of the last emitted timetick message, 0 if there's no one sent.
+
+	Errors SyncErrorStats `json:"errors"` // the count of failed syncs by category.
+}
+
+// TotalSyncs returns the count of all syncs that sent a timetick message.
+func (s SyncStats) TotalSyncs() int64 {
+	return s.PersistedSyncs + s.NonPersistedSyncs
+}
+
+// PersistedRatio returns the ratio of persisted syncs to all syncs, 0 if there's no sync.
+// A high ratio means the expensive persisted syncs are frequent, the sync config should be tuned.
+func (s SyncStats) PersistedRatio() float64 {
+	total := s.TotalSyncs()
+	if total == 0 {
+		return 0
+	}
+	return float64(s.PersistedSyncs) / float64(total)
+}
+
+// CollectionSyncStats is the sync statistics of the pchannels of one collection.
+type CollectionSyncStats struct {
+	Channels            int   `json:"channels"`             // the count of registered pchannels of the collection.
+	MaintenanceChannels int   `json:"maintenance_channels"` // the count of pchannels in maintenance.
+	PersistedSyncs      int64 `json:"persisted_syncs"`      // the sum of persisted syncs of the pchannels.
+	NonPersistedSyncs   int64 `json:"non_persisted_syncs"`  // the sum of non-persisted syncs of the pchannels.
+}
+
+// add accumulates the sync statistics of one pchannel.
+func (s *CollectionSyncStats) add(stats SyncStats) {
+	s.Channels++
+	if stats.MaintenanceReason != "" {
+		s.MaintenanceChannels++
+	}
+	s.PersistedSyncs += stats.PersistedSyncs
+	s.NonPersistedSyncs += stats.NonPersistedSyncs
+}
+
+// TotalSyncs returns the count of all syncs of the collection that sent a timetick message.
+func (s CollectionSyncStats) TotalSyncs() int64 {
+	return s.PersistedSyncs + s.NonPersistedSyncs
+}
@@ -0,0 +1,92 @@
+package inspector
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+var (
+	_ SyncStrategy = (*defaultSyncStrategy)(nil)
+	_ SyncStrategy = (*fixedSyncStrategy)(nil)
+	_ SyncStrategy = (*adaptiveSyncStrategy)(nil)
+)
+
+// SyncStrategyState is the state of the last periodic sync of a pchannel.
+type SyncStrategyState struct {
+	LastSyncTime time.Time  // the time when the last periodic sync is performed.
+	Skipped      bool       // the last periodic sync is skipped because the pchannel is read-only.
+	Result       SyncResult // the result of the last periodic sync.
+	Err          error      // the error of the last periodic sync.
+	Dispatched   bool       // the last periodic sync is dispatched to perform concurrently, so its result is unknown yet.
+}
+
+// SyncStrategy decides when the pchannel should be synced periodically.
+// A strategy instance is bound to one pchannel, and it's only called by the background goroutine of inspector.
+// The triggered syncs are always performed immediately, and don't affect the periodic schedule.
+type SyncStrategy interface {
+	// NextSyncTime returns the time of the next periodic sync given the state of the last periodic sync.
+	// The schedule is quantized to the tick interval of inspector.
+	NextSyncTime(state SyncStrategyState) time.Time
+}
+
+// newDefaultSyncStrategy creates a strategy that syncs the pchannel at the tick interval of inspector,
+// so the change of interval is followed by the pchannels without a specified strategy.
+func newDefaultSyncStrategy(interval *atomic.Duration) SyncStrategy {
+	return &defaultSyncStrategy{interval: interval}
+}
+
+// defaultSyncStrategy syncs the pchannel at the tick interval of inspector.
+type defaultSyncStrategy struct {
+	interval *atomic.Duration
+}
+
+func (s *defaultSyncStrategy) NextSyncTime(state SyncStrategyState) time.Time {
+	return state.LastSyncTime.Add(s.interval.Load())
+}
+
+// NewFixedSyncStrategy creates a strategy that syncs the pchannel at a fixed interval.
+func NewFixedSyncStrategy(interval time.Duration) SyncStrategy {
+	return &fixedSyncStrategy{interval: interval}
+}
+
+// fixedSyncStrategy syncs the pchannel at a fixed interval.
+type fixedSyncStrategy struct {
+	interval time.Duration
+}
+
+func (s *fixedSyncStrategy) NextSyncTime(state SyncStrategyState) time.Time {
+	return state.LastSyncTime.Add(s.interval)
+}
+
+// NewAdaptiveSyncStrategy creates a strategy that adapts the sync interval to the traffic of pchannel.
+// The interval is reset to minInterval once a timetick message is sent, and doubled up to maxInterval if the pchannel is idle.
+func NewAdaptiveSyncStrategy(minInterval time.Duration, maxInterval time.Duration) SyncStrategy {
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	return &adaptiveSyncStrategy{
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		interval:    minInterval,
+	}
+}
+
+// adaptiveSyncStrategy backs off the sync interval of an idle pchannel.
+type adaptiveSyncStrategy struct {
+	minInterval time.Duration
+	maxInterval time.Duration
+	interval    time.Duration
+}
+
+func (s *adaptiveSyncStrategy) NextSyncTime(state SyncStrategyState) time.Time {
+	switch {
+	case state.Err != nil, state.Dispatched:
+		// keep the current interval to retry, or until the result of the dispatched sync is known.
+	case state.Result.IsSent():
+		s.interval = s.minInterval
+	default:
+		s.interval = min(s.interval*2, s.maxInterval)
+	}
+	return state.LastSyncTime.Add(s.interval)
+}
@@ -0,0 +1,35 @@
+package inspector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedSyncStrategy(t *testing.T) {
+	s := NewFixedSyncStrategy(time.Second)
+	now := time.Now()
+	assert.Equal(t, now.Add(time.Second), s.NextSyncTime(SyncStrategyState{LastSyncTime: now}))
+	assert.Equal(t, now.Add(time.Second), s.NextSyncTime(SyncStrategyState{LastSyncTime: now, Result: SyncResult{TimeTick: 1}}))
+}
+
+func TestAdaptiveSyncStrategy(t *testing.T) {
+	s := NewAdaptiveSyncStrategy(time.Second, 3*time.Second)
+	now := time.Now()
+	idle := SyncStrategyState{LastSyncTime: now}
+	assert.Equal(t, now.Add(2*time.Second), s.NextSyncTime(idle))
+	assert.Equal(t, now.Add(3*time.Second), s.NextSyncTime(idle))
+	assert.Equal(t, now.Add(3*time.Second), s.NextSyncTime(idle))
+
+	// the interval is kept on error.
+	assert.Equal(t, now.Add(3*time.Second), s.NextSyncTime(SyncStrategyState{LastSyncTime: now, Err: errors.New("sync failed")}))
+
+	// the interval is reset once a timetick is sent.
+	assert.Equal(t, now.Add(time.Second), s.NextSyncTime(SyncStrategyState{LastSyncTime: now, Result: SyncResult{TimeTick: 1}}))
+
+	// the max interval is not less than the min interval.
+	s = NewAdaptiveSyncStrategy(time.Second, time.Millisecond)
+	assert.Equal(t, now.Add(time.Second), s.NextSyncTime(idle))
+}
@@ -0,0 +1,152 @@
+package inspector
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/v2/util/ratelimitutil"
+)
+
+// TenantResolver derives the tenant of the pchannel, the pchannels of the same tenant share the persisted sync budget.
+type TenantResolver func(pchannel types.PChannelInfo) string
+
+// DefaultTenantResolver uses the name prefix before the last '_' as the tenant,
+// e.g. the tenant of pchannel `by-dev-rootcoord-dml_1` is `by-dev-rootcoord-dml`,
+// so the pchannels of the milvus clusters sharing the same wal are grouped by cluster.
+func DefaultTenantResolver(pchannel types.PChannelInfo) string {
+	if idx := strings.LastIndex(pchannel.Name, "_"); idx > 0 {
+		return pchannel.Name[:idx]
+	}
+	return pchannel.Name
+}
+
+// getTenantPersistedSyncRate returns the persisted sync budget of the tenant per second, 0 means no limit.
+func getTenantPersistedSyncRate(tenant string) float64 {
+	cfg := &paramtable.Get().StreamingCfg
+	rate := cfg.TimeTickDefaultTenantPersistedSyncRate.GetAsFloat()
+	// the keys of config are case-insensitive.
+	if v, ok := cfg.TimeTickTenantPersistedSyncRate.GetValue()[strings.ToLower(tenant)]; ok {
+		var err error
+		if rate, err = strconv.ParseFloat(v, 64); err != nil {
+			log.Warn("invalid persisted sync rate of tenant, use the default", zap.String("tenant", tenant), zap.String("rate", v), zap.Error(err))
+			rate = cfg.TimeTickDefaultTenantPersistedSyncRate.GetAsFloat()
+		}
+	}
+	return max(rate, 0)
+}
+
+// newTenantLimiters creates a new tenant limiters.
+func newTenantLimiters(resolver TenantResolver) *tenantLimiters {
+	return &tenantLimiters{
+		resolver: resolver,
+		tenants:  make(map[string]map[string]*syncChannel),
+		rates:    make(map[string]float64),
+	}
+}
+
+// tenantLimiters limits the persisted syncs of the pchannels by tenant.
+// The budget of a tenant is evenly distributed across its pchannels, so a busy pchannel can't starve the others.
+type tenantLimiters struct {
+	resolver TenantResolver
+	mu       sync.Mutex
+	tenants  map[string]map[string]*syncChannel
+	rates    map[string]float64 // the applied persisted sync budget of tenants.
+}
+
+// Add adds the channel into its tenant and redistributes the budget of the tenant.
+func (t *tenantLimiters) Add(channel *syncChannel) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tenant := t.resolver(channel.operator.Channel())
+	if _, ok := t.tenants[tenant]; !ok {
+		t.tenants[tenant] = make(map[string]*syncChannel)
+	}
+	t.tenants[tenant][channel.operator.Channel().Name] = channel
+	t.redistribute(tenant)
+}
+
+// Remove removes the channel from its tenant and redistributes the budget of the tenant.
+func (t *tenantLimiters) Remove(channel *syncChannel) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tenant := t.resolver(channel.operator.Channel())
+	channels, ok := t.tenants[tenant]
+	if !ok {
+		return
+	}
+	delete(channels, channel.operator.Channel().Name)
+	if len(channels) == 0 {
+		delete(t.tenants, tenant)
+		delete(t.rates, tenant)
+		return
+	}
+	t.redistribute(tenant)
+}
+
+// Refresh redistributes the budget of the tenants whose persisted sync rate is changed by the configuration.
+func (t *tenantLimiters) Refresh() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for tenant := range t.tenants {
+		if rate := getTenantPersistedSyncRate(tenant); rate != t.rates[tenant] {
+			log.Info("persisted sync rate of tenant is changed",
+				zap.String("tenant", tenant), zap.Float64("previous", t.rates[tenant]), zap.Float64("rate", rate))
+			t.redistribute(tenant)
+		}
+	}
+}
+
+// redistribute resets the limiters of all channels of the tenant with the evenly distributed budget.
+func (t *tenantLimiters) redistribute(tenant string) {
+	channels := t.tenants[tenant]
+	rate := getTenantPersistedSyncRate(tenant)
+	t.rates[tenant] = rate
+	if rate == 0 {
+		for _, channel := range channels {
+			channel.limiter.Store(nil)
+		}
+		return
+	}
+	channelRate := rate / float64(len(channels))
+	for _, channel := range channels {
+		channel.limiter.Store(newPersistedSyncLimiter(channelRate))
+	}
+	log.Info("redistribute the persisted sync budget of tenant",
+		zap.String("tenant", tenant), zap.Float64("rate", rate), zap.Int("channels", len(channels)))
+}
+
+// newPersistedSyncLimiter creates a limiter that allows rate persisted syncs per second.
+func newPersistedSyncLimiter(rate float64) *persistedSyncLimiter {
+	return &persistedSyncLimiter{
+		limiter: ratelimitutil.NewLimiter(ratelimitutil.Limit(rate), max(rate, 1)),
+	}
+}
+
+// persistedSyncLimiter limits the persisted syncs of a pchannel.
+// Whether a sync is persisted is decided by the operator, so a token is taken before the sync
+// and given back if the sync doesn't persist a timetick message.
+type persistedSyncLimiter struct {
+	limiter *ratelimitutil.Limiter
+}
+
+// Acquire takes a token for a sync at now, false if the budget is exhausted.
+func (l *persistedSyncLimiter) Acquire(now time.Time) bool {
+	return l.limiter.AllowN(now, 1)
+}
+
+// Release gives back the token if the sync is not persisted.
+func (l *persistedSyncLimiter) Release(result SyncResult) {
+	if !result.Persisted {
+		l.limiter.Cancel(1)
+	}
+}
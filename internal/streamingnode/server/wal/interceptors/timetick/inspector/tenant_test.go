@@ -0,0 +1,34 @@
+package inspector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/types"
+)
+
+func TestDefaultTenantResolver(t *testing.T) {
+	assert.Equal(t, "by-dev-rootcoord-dml", DefaultTenantResolver(types.PChannelInfo{Name: "by-dev-rootcoord-dml_1"}))
+	assert.Equal(t, "a_b", DefaultTenantResolver(types.PChannelInfo{Name: "a_b_10"}))
+	assert.Equal(t, "standalone", DefaultTenantResolver(types.PChannelInfo{Name: "standalone"}))
+	assert.Equal(t, "_1", DefaultTenantResolver(types.PChannelInfo{Name: "_1"}))
+}
+
+func TestPersistedSyncLimiter(t *testing.T) {
+	now := time.Now()
+	l := newPersistedSyncLimiter(1)
+	// the token is given back if the sync is not persisted.
+	for i := 0; i < 10; i++ {
+		assert.True(t, l.Acquire(now))
+		l.Release(SyncResult{TimeTick: 1})
+	}
+	assert.True(t, l.Acquire(now))
+	l.Release(SyncResult{TimeTick: 1, Persisted: true})
+	assert.True(t, l.Acquire(now))
+	l.Release(SyncResult{TimeTick: 2, Persisted: true})
+	// the budget is exhausted until it's refilled.
+	assert.False(t, l.Acquire(now))
+	assert.True(t, l.Acquire(now.Add(time.Second)))
+}
@@ -0,0 +1,86 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultThroughputWindow is the default sliding window of the sync throughput.
+const defaultThroughputWindow = 10 * time.Second
+
+// throughputBucketSize is the resolution of the sliding window of the sync throughput.
+const throughputBucketSize = time.Second
+
+// ThroughputSnapshot is the aggregate sync throughput of all pchannels over the sliding window.
+type ThroughputSnapshot struct {
+	Window             time.Duration `json:"window"`               // the length of the sliding window.
+	TotalPerSecond     float64       `json:"total_per_second"`     // the syncs that sent a timetick message per second.
+	PersistedPerSecond float64       `json:"persisted_per_second"` // the syncs that persisted the timetick message into wal per second.
+}
+
+// throughputBucket counts the syncs in one bucket of the sliding window.
+type throughputBucket struct {
+	start     int64 // the start of the bucket in unit of bucket size, identifies which round of the ring the bucket belongs to.
+	total     int64
+	persisted int64
+}
+
+// throughputCounter is a rolling counter of the syncs over the sliding window.
+// The window is split into the buckets of one second, which are reused as a ring.
+type throughputCounter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	buckets []throughputBucket
+}
+
+// newThroughputCounter creates a new rolling counter over the window, the window is rounded up to seconds.
+func newThroughputCounter(window time.Duration) *throughputCounter {
+	if window <= 0 {
+		window = defaultThroughputWindow
+	}
+	n := int((window + throughputBucketSize - 1) / throughputBucketSize)
+	return &throughputCounter{
+		window:  time.Duration(n) * throughputBucketSize,
+		buckets: make([]throughputBucket, n),
+	}
+}
+
+// Record counts a sync that sent a timetick message at now.
+func (c *throughputCounter) Record(now time.Time, persisted bool) {
+	start := now.UnixNano() / int64(throughputBucketSize)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bucket := &c.buckets[start%int64(len(c.buckets))]
+	if bucket.start != start {
+		// the bucket is left by the previous round of the ring, reset it.
+		*bucket = throughputBucket{start: start}
+	}
+	bucket.total++
+	if persisted {
+		bucket.persisted++
+	}
+}
+
+// Snapshot returns the throughput over the window that ends at now.
+func (c *throughputCounter) Snapshot(now time.Time) ThroughputSnapshot {
+	current := now.UnixNano() / int64(throughputBucketSize)
+	oldest := current - int64(len(c.buckets)) + 1
+
+	var total, persisted int64
+	c.mu.Lock()
+	for _, bucket := range c.buckets {
+		if bucket.start >= oldest && bucket.start <= current {
+			total += bucket.total
+			persisted += bucket.persisted
+		}
+	}
+	c.mu.Unlock()
+
+	seconds := c.window.Seconds()
+	return ThroughputSnapshot{
+		Window:             c.window,
+		TotalPerSecond:     float64(total) / seconds,
+		PersistedPerSecond: float64(persisted) / seconds,
+	}
+}
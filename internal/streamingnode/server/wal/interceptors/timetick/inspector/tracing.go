@@ -0,0 +1,74 @@
+package inspector
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/milvus-io/milvus/pkg/v2/util/typeutil"
+)
+
+const (
+	syncSpanName = "TimeTickSync"
+
+	syncOutcomeSent    = "sent"     // a timetick message is sent.
+	syncOutcomeNotSent = "not_sent" // no timetick message can be sent, e.g. some message is not acknowledged yet.
+	syncOutcomeFailed  = "failed"   // the sync returns an error.
+	syncOutcomeStale   = "stale"    // the result is discarded because the pchannel is re-registered during the sync.
+)
+
+// syncSpan is the trace span of a sync performed by the inspector, the methods are no-op on a nil span.
+type syncSpan struct {
+	span  trace.Span
+	start time.Time
+}
+
+// startSyncSpan starts the span of the sync if the tracing is enabled, the span is attached to the returned context,
+// so the spans of the operator, e.g. the append of wal, are the children of it.
+func (s *timeTickSyncInspectorImpl) startSyncSpan(ctx context.Context, decision SyncDecision) (context.Context, *syncSpan) {
+	if !s.traceSync {
+		return ctx, nil
+	}
+	ctx, span := otel.Tracer(typeutil.StreamingNodeRole).Start(ctx, syncSpanName, trace.WithAttributes(
+		attribute.String("channel", decision.Channel),
+		attribute.String("cause", string(decision.Cause)),
+		attribute.Bool("force_persisted", decision.ForcePersisted),
+	))
+	return ctx, &syncSpan{span: span, start: s.clock.Now()}
+}
+
+// End ends the span with the outcome of the sync and the watermark of the pchannel after the sync.
+func (sp *syncSpan) End(s *timeTickSyncInspectorImpl, decision SyncDecision, outcome string) {
+	if sp == nil {
+		return
+	}
+	watermark, _ := s.watermarks.Get(decision.Channel)
+	sp.span.SetAttributes(
+		attribute.String("outcome", outcome),
+		attribute.Bool("persisted", decision.Result.Persisted),
+		attribute.Int64("time_tick", int64(decision.Result.TimeTick)),
+		attribute.Int64("watermark", int64(watermark)),
+		attribute.Int64("duration_ms", s.clock.Since(sp.start).Milliseconds()),
+	)
+	if decision.Err != nil {
+		sp.span.RecordError(decision.Err)
+		sp.span.SetStatus(codes.Error, decision.Err.Error())
+	}
+	sp.span.End()
+}
+
+// syncOutcome returns the outcome of a sync that is not stale.
+func syncOutcome(decision SyncDecision) string {
+	switch {
+	case decision.Err != nil:
+		return syncOutcomeFailed
+	case decision.Result.IsSent():
+		return syncOutcomeSent
+	default:
+		return syncOutcomeNotSent
+	}
+}
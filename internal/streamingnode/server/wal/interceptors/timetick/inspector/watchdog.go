@@ -0,0 +1,107 @@
+package inspector
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// inflightSync is the sync that is being performed by the background goroutine of inspector, or dispatched by it.
+type inflightSync struct {
+	channel    string
+	start      time.Time
+	cancel     context.CancelCauseFunc
+	dispatched bool        // the sync is a dispatched persisted sync, which doesn't block the other pchannels.
+	reported   atomic.Bool // the stall of the sync is already reported.
+}
+
+// newInflightSync creates the in-flight sync of the channel and returns the context of the sync.
+func (s *timeTickSyncInspectorImpl) newInflightSync(channel string, dispatched bool) (context.Context, *inflightSync) {
+	ctx, cancel := context.WithCancelCause(s.taskNotifier.Context())
+	return ctx, &inflightSync{
+		channel:    channel,
+		start:      s.clock.Now(),
+		cancel:     cancel,
+		dispatched: dispatched,
+	}
+}
+
+// startSync marks the sync of the channel is in flight and returns the context of the sync,
+// the returned function should be called when the sync returns.
+func (s *timeTickSyncInspectorImpl) startSync(channel string) (context.Context, func()) {
+	ctx, inflight := s.newInflightSync(channel, false)
+	s.inflight.Store(inflight)
+	return ctx, func() {
+		s.inflight.Store(nil)
+		inflight.cancel(nil)
+	}
+}
+
+// startDispatchedSync marks the dispatched persisted sync of the channel is in flight, see startSync.
+func (s *timeTickSyncInspectorImpl) startDispatchedSync(channel *syncChannel) (context.Context, func()) {
+	ctx, inflight := s.newInflightSync(channel.operator.Channel().Name, true)
+	s.dispatchedSyncs.Insert(channel, inflight)
+	return ctx, func() {
+		s.dispatchedSyncs.Remove(channel)
+		inflight.cancel(nil)
+	}
+}
+
+// watchdog detects the stall of the background goroutine, which blocks all the time tick syncs of the node.
+// Because the syncs are performed one by one, no sync can complete while the in-flight sync lasts longer than the threshold.
+// The dispatched persisted syncs are watched too, each of them halts the time ticks of its pchannel.
+func (s *timeTickSyncInspectorImpl) watchdog() {
+	defer s.wg.Done()
+
+	// check at a quarter of the threshold, so the stall is detected soon after the threshold.
+	ticker := s.clock.NewTicker(s.stallThreshold / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.taskNotifier.Context().Done():
+			return
+		case <-ticker.Chan():
+			s.checkStall()
+		}
+	}
+}
+
+// checkStall reports the in-flight syncs if they last longer than the threshold, and cancels them if configured.
+// Each in-flight sync is reported at most once.
+func (s *timeTickSyncInspectorImpl) checkStall() {
+	if inflight := s.inflight.Load(); inflight != nil {
+		s.checkInflightStall(inflight)
+	}
+	s.dispatchedSyncs.Range(func(_ *syncChannel, inflight *inflightSync) bool {
+		s.checkInflightStall(inflight)
+		return true
+	})
+}
+
+// checkInflightStall reports the in-flight sync if it lasts longer than the threshold, and cancels it if configured.
+func (s *timeTickSyncInspectorImpl) checkInflightStall(inflight *inflightSync) {
+	elapsed := s.clock.Since(inflight.start)
+	if elapsed < s.stallThreshold || inflight.reported.Swap(true) {
+		return
+	}
+	s.stalledSyncs.Inc()
+	metrics.WALTimeTickSyncStallTotal.WithLabelValues(paramtable.GetStringNodeID(), inflight.channel).Inc()
+	msg := "time tick sync is stalled, the time ticks of all pchannels are halted"
+	if inflight.dispatched {
+		msg = "dispatched persisted time tick sync is stalled, the time ticks of the pchannel are halted"
+	}
+	log.Warn(msg,
+		zap.String("channel", inflight.channel),
+		zap.Duration("elapsed", elapsed),
+		zap.Duration("threshold", s.stallThreshold),
+		zap.Bool("cancel", s.cancelStalledSync))
+	if s.cancelStalledSync {
+		inflight.cancel(ErrSyncStalled)
+	}
+}
@@ -0,0 +1,225 @@
+package inspector
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/milvus-io/milvus/pkg/v2/util/syncutil"
+	"github.com/milvus-io/milvus/pkg/v2/util/tsoutil"
+)
+
+const (
+	watermarkSourceSync     = "sync"
+	watermarkSourceHandover = "handover"
+	watermarkSourceRecovery = "recovery"
+	watermarkSourceSnapshot = "snapshot"
+)
+
+// WatermarkRegression is a rejected watermark that would move the watermark of a pchannel backwards.
+type WatermarkRegression struct {
+	Timestamp time.Time `json:"timestamp"` // the clock time when the regression is detected.
+	Source    string    `json:"source"`    // where the watermark comes from, sync, handover, recovery or snapshot.
+	Current   uint64    `json:"current"`   // the watermark of the pchannel that is kept.
+	Rejected  uint64    `json:"rejected"`  // the rejected watermark.
+}
+
+// WatermarkPhysicalTime returns the physical time component of the watermark,
+// the watermark is a hybrid timestamp of the physical milliseconds and the logical counter.
+func WatermarkPhysicalTime(watermark uint64) time.Time {
+	return tsoutil.PhysicalTime(watermark)
+}
+
+// WatermarkFromPhysicalTime returns the smallest watermark at the physical time, whose logical counter is zero.
+// The physical time is truncated to milliseconds.
+func WatermarkFromPhysicalTime(t time.Time) uint64 {
+	return tsoutil.ComposeTSByTime(t, 0)
+}
+
+// WatermarkLag returns how far the physical time of the watermark falls behind now, 0 if it's not behind.
+func WatermarkLag(watermark uint64, now time.Time) time.Duration {
+	return max(now.Sub(WatermarkPhysicalTime(watermark)), 0)
+}
+
+// newWatermarkManager creates a new watermark manager.
+func newWatermarkManager() *watermarkManager {
+	m := &watermarkManager{
+		watermarkHeap: make(channelWatermarkHeap, 0),
+		index:         make(map[string]*channelWatermark),
+	}
+	m.cond = syncutil.NewContextCond(&m.mu)
+	return m
+}
+
+// watermarkManager maintains the watermarks of all pchannels incrementally,
+// so the global minimum watermark can be got without scanning all pchannels.
+type watermarkManager struct {
+	mu            sync.Mutex
+	cond          *syncutil.ContextCond // broadcast when a watermark is advanced or a pchannel is removed.
+	watermarkHeap channelWatermarkHeap
+	index         map[string]*channelWatermark
+}
+
+// Add adds a pchannel with its initial watermark.
+func (m *watermarkManager) Add(pchannel string, watermark uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.index[pchannel]; ok {
+		panic("watermark of pchannel already exists, critical bug in code")
+	}
+	cw := &channelWatermark{
+		pchannel:  pchannel,
+		watermark: watermark,
+	}
+	heap.Push(&m.watermarkHeap, cw)
+	m.index[pchannel] = cw
+}
+
+// Remove removes a pchannel from the manager.
+func (m *watermarkManager) Remove(pchannel string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw, ok := m.index[pchannel]
+	if !ok {
+		return
+	}
+	heap.Remove(&m.watermarkHeap, cw.index)
+	delete(m.index, pchannel)
+	m.cond.UnsafeBroadcast()
+}
+
+// Advance advances the watermark of a pchannel, a watermark that is not greater than the current one is ignored.
+// The current watermark is returned, and regressed is true if the ignored watermark is less than the current one.
+func (m *watermarkManager) Advance(pchannel string, watermark uint64) (current uint64, regressed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw, ok := m.index[pchannel]
+	if !ok {
+		return 0, false
+	}
+	if watermark <= cw.watermark {
+		return cw.watermark, watermark < cw.watermark
+	}
+	m.watermarkHeap.Update(cw, watermark)
+	m.cond.UnsafeBroadcast()
+	return watermark, false
+}
+
+// WaitForAdvance blocks until the watermark of a pchannel is greater than the given one,
+// ErrSyncOperatorNotFound is returned if the pchannel is not found or removed while waiting.
+func (m *watermarkManager) WaitForAdvance(ctx context.Context, pchannel string, watermark uint64) error {
+	m.mu.Lock()
+	for {
+		cw, ok := m.index[pchannel]
+		if !ok {
+			m.mu.Unlock()
+			return ErrSyncOperatorNotFound
+		}
+		if cw.watermark > watermark {
+			m.mu.Unlock()
+			return nil
+		}
+		if err := m.cond.Wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitForMin blocks until the minimum watermark of all pchannels is not less than the target,
+// it returns immediately if there's no pchannel.
+func (m *watermarkManager) WaitForMin(ctx context.Context, target uint64) error {
+	m.mu.Lock()
+	for {
+		if len(m.watermarkHeap) == 0 || m.watermarkHeap[0].watermark >= target {
+			m.mu.Unlock()
+			return nil
+		}
+		if err := m.cond.Wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Below returns the watermarks of the pchannels that are less than the target, keyed by the pchannel name.
+func (m *watermarkManager) Below(target uint64) map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	below := make(map[string]uint64)
+	for _, cw := range m.watermarkHeap {
+		if cw.watermark < target {
+			below[cw.pchannel] = cw.watermark
+		}
+	}
+	return below
+}
+
+// Get returns the watermark of a pchannel, false if the pchannel is not found.
+func (m *watermarkManager) Get(pchannel string) (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw, ok := m.index[pchannel]
+	if !ok {
+		return 0, false
+	}
+	return cw.watermark, true
+}
+
+// Min returns the minimum watermark of all pchannels, false if there's no pchannel.
+func (m *watermarkManager) Min() (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.watermarkHeap) == 0 {
+		return 0, false
+	}
+	return m.watermarkHeap[0].watermark, true
+}
+
+// channelWatermark is the watermark of a pchannel.
+type channelWatermark struct {
+	pchannel  string
+	watermark uint64
+	index     int
+}
+
+// channelWatermarkHeap is a minimum heap of channel watermarks, implements heap.Interface.
+type channelWatermarkHeap []*channelWatermark
+
+func (h channelWatermarkHeap) Len() int { return len(h) }
+
+func (h channelWatermarkHeap) Less(i, j int) bool {
+	return h[i].watermark < h[j].watermark
+}
+
+func (h channelWatermarkHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *channelWatermarkHeap) Push(x any) {
+	item := x.(*channelWatermark)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *channelWatermarkHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[0 : n-1]
+	return item
+}
+
+func (h *channelWatermarkHeap) Update(item *channelWatermark, watermark uint64) {
+	item.watermark = watermark
+	heap.Fix(h, item.index)
+}
@@ -0,0 +1,99 @@
+package inspector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatermarkManager(t *testing.T) {
+	m := newWatermarkManager()
+	_, ok := m.Min()
+	assert.False(t, ok)
+
+	m.Add("p1", 10)
+	m.Add("p2", 5)
+	m.Add("p3", 20)
+	assert.Panics(t, func() {
+		m.Add("p1", 10)
+	})
+	minWatermark, ok := m.Min()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), minWatermark)
+
+	// the watermark never goes backward.
+	current, regressed := m.Advance("p2", 3)
+	assert.True(t, regressed)
+	assert.Equal(t, uint64(5), current)
+	minWatermark, _ = m.Min()
+	assert.Equal(t, uint64(5), minWatermark)
+
+	// the same watermark is not a regression.
+	current, regressed = m.Advance("p2", 5)
+	assert.False(t, regressed)
+	assert.Equal(t, uint64(5), current)
+
+	current, regressed = m.Advance("p2", 15)
+	assert.False(t, regressed)
+	assert.Equal(t, uint64(15), current)
+	minWatermark, _ = m.Min()
+	assert.Equal(t, uint64(10), minWatermark)
+
+	// unknown pchannel is ignored.
+	m.Advance("p4", 1)
+	m.Remove("p4")
+
+	m.Remove("p1")
+	minWatermark, _ = m.Min()
+	assert.Equal(t, uint64(15), minWatermark)
+
+	m.Remove("p2")
+	m.Remove("p3")
+	_, ok = m.Min()
+	assert.False(t, ok)
+}
+
+func TestWatermarkManagerWaitForAdvance(t *testing.T) {
+	m := newWatermarkManager()
+	assert.ErrorIs(t, m.WaitForAdvance(context.Background(), "p1", 0), ErrSyncOperatorNotFound)
+
+	m.Add("p1", 10)
+	assert.NoError(t, m.WaitForAdvance(context.Background(), "p1", 5))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m.WaitForAdvance(ctx, "p1", 10), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		m.Advance("p1", 11)
+	}()
+	assert.NoError(t, m.WaitForAdvance(context.Background(), "p1", 10))
+
+	// the removal of pchannel wakes up the waiter.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		m.Remove("p1")
+	}()
+	assert.ErrorIs(t, m.WaitForAdvance(context.Background(), "p1", 11), ErrSyncOperatorNotFound)
+}
+
+func TestWatermarkPhysicalTime(t *testing.T) {
+	// 1700000000000ms << 18 + 5
+	watermark := uint64(445644800000000005)
+	physical := time.UnixMilli(1700000000000)
+	assert.True(t, physical.Equal(WatermarkPhysicalTime(watermark)))
+	assert.Equal(t, uint64(445644800000000000), WatermarkFromPhysicalTime(physical))
+	assert.True(t, physical.Equal(WatermarkPhysicalTime(WatermarkFromPhysicalTime(physical))))
+
+	// the sub-millisecond part is truncated.
+	physical = time.UnixMilli(1700000000123)
+	assert.Equal(t, uint64(445644800032243712), WatermarkFromPhysicalTime(physical.Add(999*time.Microsecond)))
+	assert.True(t, physical.Equal(WatermarkPhysicalTime(445644800032243712+(1<<18-1))))
+
+	assert.Equal(t, 123*time.Millisecond, WatermarkLag(watermark, physical))
+	assert.Zero(t, WatermarkLag(WatermarkFromPhysicalTime(physical), physical))
+	assert.Zero(t, WatermarkLag(WatermarkFromPhysicalTime(physical), physical.Add(-time.Second)))
+}
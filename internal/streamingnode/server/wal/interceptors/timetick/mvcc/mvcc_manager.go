@@ -42,6 +42,14 @@ func (cm *MVCCManager) GetMVCCOfVChannel(vchannel string) VChannelMVCC {
 	}
 }
 
+// GetMVCCOfPChannel gets the mvcc of the pchannel, which is the last confirmed timetick synced by timeticksync message.
+func (cm *MVCCManager) GetMVCCOfPChannel() uint64 {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	return cm.pchannelMVCCTimestamp
+}
+
 // UpdateMVCC updates the mvcc state by incoming message.
 func (cm *MVCCManager) UpdateMVCC(msg message.MutableMessage) {
 	tt := msg.TimeTick()
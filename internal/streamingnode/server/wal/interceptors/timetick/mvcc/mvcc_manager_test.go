@@ -11,6 +11,7 @@ import (
 
 func TestNewMVCCManager(t *testing.T) {
 	cm := NewMVCCManager(100)
+	assert.Equal(t, uint64(100), cm.GetMVCCOfPChannel())
 	v := cm.GetMVCCOfVChannel("vc1")
 	assert.Equal(t, v, VChannelMVCC{Timetick: 100, Confirmed: true})
 
@@ -21,6 +22,7 @@ func TestNewMVCCManager(t *testing.T) {
 	assert.Equal(t, v, VChannelMVCC{Timetick: 100, Confirmed: true})
 
 	cm.UpdateMVCC(createTestMessage(t, 102, "", message.MessageTypeTimeTick, false))
+	assert.Equal(t, uint64(102), cm.GetMVCCOfPChannel())
 	v = cm.GetMVCCOfVChannel("vc1")
 	assert.Equal(t, v, VChannelMVCC{Timetick: 102, Confirmed: true})
 	v = cm.GetMVCCOfVChannel("vc2")
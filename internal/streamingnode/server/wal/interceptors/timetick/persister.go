@@ -0,0 +1,52 @@
+package timetick
+
+import (
+	"context"
+
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal"
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
+	"github.com/milvus-io/milvus/pkg/v2/util/syncutil"
+)
+
+var _ TimeTickPersister = (*walPersister)(nil)
+
+// TimeTickPersister appends the time tick message generated by the sync operation.
+type TimeTickPersister interface {
+	// Persist appends the time tick message and returns its message id.
+	// If persisted is false, the context carries the not persisted hint,
+	// the message should pass through the interceptors but not be persisted.
+	Persist(ctx context.Context, msg message.MutableMessage, persisted bool) (message.MessageID, error)
+}
+
+// OperatorOption is the option of the time tick sync operator.
+type OperatorOption func(*timeTickSyncOperator)
+
+// OptPersister sets the persister of the time tick sync operator, the wal of the interceptor is used by default.
+func OptPersister(persister TimeTickPersister) OperatorOption {
+	return func(impl *timeTickSyncOperator) {
+		impl.persister = persister
+	}
+}
+
+// newWALPersister creates a persister that appends the time tick message into the wal.
+func newWALPersister(wal *syncutil.Future[wal.WAL]) *walPersister {
+	return &walPersister{wal: wal}
+}
+
+// walPersister is the persister that appends the time tick message into the wal.
+type walPersister struct {
+	wal *syncutil.Future[wal.WAL]
+}
+
+// Persist implements TimeTickPersister, it blocks until the wal is ready.
+func (p *walPersister) Persist(ctx context.Context, msg message.MutableMessage, _ bool) (message.MessageID, error) {
+	w, err := p.wal.GetWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	appendResult, err := w.Append(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return appendResult.MessageID, nil
+}
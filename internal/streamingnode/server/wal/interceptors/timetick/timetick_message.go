@@ -8,6 +8,12 @@ import (
 )
 
 func NewTimeTickMsg(ts uint64, lastConfirmedMessageID message.MessageID, sourceID int64, persist bool) message.MutableMessage {
+	return NewSequencedTimeTickMsg(ts, lastConfirmedMessageID, sourceID, 0, persist)
+}
+
+// NewSequencedTimeTickMsg creates a time tick message stamped with the sequence number and the source id as its properties,
+// so the consumers can detect the dropped or reordered time ticks, 0 means the sequence is not assigned.
+func NewSequencedTimeTickMsg(ts uint64, lastConfirmedMessageID message.MessageID, sourceID int64, sequence uint64, persist bool) message.MutableMessage {
 	// TODO: time tick should be put on properties, for compatibility, we put it on message body now.
 	// Common message's time tick is set on interceptor.
 	// TimeTickMsg's time tick should be set here.
@@ -21,6 +27,7 @@ func NewTimeTickMsg(ts uint64, lastConfirmedMessageID message.MessageID, sourceI
 				commonpbutil.WithSourceID(sourceID),
 			),
 		}).
+		WithTimeTickSource(sourceID, sequence).
 		WithAllVChannel()
 	if !persist {
 		b.WithNotPersisted()
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/milvus-io/milvus/pkg/v2/streaming/util/message"
 	"github.com/milvus-io/milvus/pkg/v2/streaming/walimpls/impls/walimplstest"
 )
 
@@ -39,4 +40,29 @@ func TestNewTimeTickMsg(t *testing.T) {
 	assert.Equal(t, ts, msg.TimeTick())
 	assert.True(t, immutableMsg.LastConfirmedMessageID().EQ(messageID))
 	assert.False(t, msg.IsPersisted())
+
+	// Test without the sequence stamped
+	immutableMsg = NewTimeTickMsg(ts, nil, sourceID, true).IntoImmutableMessage(messageID)
+	_, ok := message.GetTimeTickSequence(immutableMsg)
+	assert.False(t, ok)
+	gotSourceID, ok := message.GetTimeTickSourceID(immutableMsg)
+	assert.True(t, ok)
+	assert.Equal(t, sourceID, gotSourceID)
+
+	// Test with the sequence stamped
+	msg = NewSequencedTimeTickMsg(ts, nil, sourceID, 7, true)
+	ttMsg, err := message.AsMutableTimeTickMessageV1(msg)
+	assert.NoError(t, err)
+	body, err := ttMsg.Body()
+	assert.NoError(t, err)
+	// the msg id of base is not overloaded by the sequence.
+	assert.Zero(t, body.GetBase().GetMsgID())
+	assert.Equal(t, sourceID, body.GetBase().GetSourceID())
+	immutableMsg = msg.IntoImmutableMessage(messageID)
+	sequence, ok := message.GetTimeTickSequence(immutableMsg)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), sequence)
+	gotSourceID, ok = message.GetTimeTickSourceID(immutableMsg)
+	assert.True(t, ok)
+	assert.Equal(t, sourceID, gotSourceID)
 }
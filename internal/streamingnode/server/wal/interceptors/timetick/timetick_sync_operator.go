@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/errors"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
 	"github.com/milvus-io/milvus/internal/streamingnode/server/resource"
@@ -21,12 +22,16 @@ import (
 )
 
 // timeTickSyncOperator is a time tick sync operator.
-var _ inspector.TimeTickSyncOperator = &timeTickSyncOperator{}
+var (
+	_ inspector.TimeTickSyncOperator           = &timeTickSyncOperator{}
+	_ inspector.WALRecoverableOperator         = &timeTickSyncOperator{}
+	_ inspector.WALSequenceRecoverableOperator = &timeTickSyncOperator{}
+)
 
 // NewTimeTickSyncOperator creates a new time tick sync operator.
-func newTimeTickSyncOperator(param *interceptors.InterceptorBuildParam) *timeTickSyncOperator {
+func newTimeTickSyncOperator(param *interceptors.InterceptorBuildParam, opts ...OperatorOption) *timeTickSyncOperator {
 	metrics := metricsutil.NewTimeTickMetrics(param.ChannelInfo.Name)
-	return &timeTickSyncOperator{
+	impl := &timeTickSyncOperator{
 		logger: resource.Resource().Logger().With(
 			log.FieldComponent("timetick-sync"),
 			zap.Any("pchannel", param.ChannelInfo),
@@ -36,7 +41,15 @@ func newTimeTickSyncOperator(param *interceptors.InterceptorBuildParam) *timeTic
 		ackDetails:            ack.NewAckDetails(),
 		sourceID:              paramtable.GetNodeID(),
 		metrics:               metrics,
+		persister:             newWALPersister(param.WAL),
+	}
+	// the first timetick message sent when the wal is opened is persisted as a fence of the wal.
+	impl.lastPersistedTimeTick.Store(param.InitializedTimeTick)
+	impl.lastPersistedSequence.Store(param.InitializedTimeTickSequence)
+	for _, opt := range opts {
+		opt(impl)
 	}
+	return impl
 }
 
 // timeTickSyncOperator is a time tick sync operator.
@@ -47,6 +60,10 @@ type timeTickSyncOperator struct {
 	ackDetails            *ack.AckDetails                     // all acknowledged details, all acked messages but not sent to wal will be kept here.
 	sourceID              int64                               // source id of the time tick sync operator.
 	metrics               *metricsutil.TimeTickMetrics
+
+	persister             TimeTickPersister // persister of the time tick message, the wal by default.
+	lastPersistedTimeTick atomic.Uint64     // time tick of the last time tick message persisted into wal.
+	lastPersistedSequence atomic.Uint64     // sequence of the last sequenced time tick message persisted into wal.
 }
 
 // Channel returns the pchannel info.
@@ -59,6 +76,18 @@ func (impl *timeTickSyncOperator) WriteAheadBuffer() wab.ROWriteAheadBuffer {
 	return impl.interceptorBuildParam.WriteAheadBuffer
 }
 
+// LastPersistedTimeTick returns the time tick of the last time tick message persisted into wal.
+// It's the fence sent when the wal is opened until a persisted sync succeeds.
+func (impl *timeTickSyncOperator) LastPersistedTimeTick() (uint64, error) {
+	return impl.lastPersistedTimeTick.Load(), nil
+}
+
+// LastPersistedSequence returns the sequence of the last sequenced time tick message persisted into wal.
+// It's the sequence of the fence sent when the wal is opened until a persisted sync stamped with a sequence succeeds.
+func (impl *timeTickSyncOperator) LastPersistedSequence() (uint64, error) {
+	return impl.lastPersistedSequence.Load(), nil
+}
+
 // MVCCManager returns the mvcc manager.
 func (impl *timeTickSyncOperator) MVCCManager() *mvcc.MVCCManager {
 	return impl.interceptorBuildParam.MVCCManager
@@ -66,24 +95,16 @@ func (impl *timeTickSyncOperator) MVCCManager() *mvcc.MVCCManager {
 
 // Sync trigger a sync operation.
 // Sync operation is not thread safe, so call it in a single goroutine.
-func (impl *timeTickSyncOperator) Sync(ctx context.Context, persisted bool) {
-	// Sync operation cannot trigger until isReady.
-	wal, err := impl.interceptorBuildParam.WAL.GetWithContext(ctx)
-	if err != nil {
-		impl.logger.Warn("unreachable: get wal failed", zap.Error(err))
-		return
-	}
-
-	err = impl.sendTsMsg(ctx, func(ctx context.Context, msg message.MutableMessage) (message.MessageID, error) {
-		appendResult, err := wal.Append(ctx, msg)
-		if err != nil {
-			return nil, err
-		}
-		return appendResult.MessageID, nil
+func (impl *timeTickSyncOperator) Sync(ctx context.Context, persisted bool) (inspector.SyncResult, error) {
+	// Sync operation cannot trigger until the persister is ready,
+	// the wal persister blocks until the wal is ready.
+	result, err := impl.sendTsMsg(ctx, func(ctx context.Context, msg message.MutableMessage) (message.MessageID, error) {
+		return impl.persister.Persist(ctx, msg, msg.IsPersisted())
 	}, persisted)
 	if err != nil {
 		impl.logger.Warn("send time tick sync message failed", zap.Error(err))
 	}
+	return result, err
 }
 
 // AckManager returns the ack manager.
@@ -98,14 +119,14 @@ func (impl *timeTickSyncOperator) Close() {
 
 // sendTsMsg sends first timestamp message to wal.
 // TODO: TT lag warning.
-func (impl *timeTickSyncOperator) sendTsMsg(ctx context.Context, appender func(ctx context.Context, msg message.MutableMessage) (message.MessageID, error), forcePersisted bool) error {
+func (impl *timeTickSyncOperator) sendTsMsg(ctx context.Context, appender func(ctx context.Context, msg message.MutableMessage) (message.MessageID, error), forcePersisted bool) (inspector.SyncResult, error) {
 	// Sync the timestamp acknowledged details.
 	impl.syncAcknowledgedDetails(ctx)
 
 	if impl.ackDetails.Empty() {
 		// No acknowledged info can be sent.
 		// Some message sent operation is blocked, new TT cannot be pushed forward.
-		return nil
+		return inspector.SyncResult{}, nil
 	}
 
 	// Construct time tick message.
@@ -113,17 +134,30 @@ func (impl *timeTickSyncOperator) sendTsMsg(ctx context.Context, appender func(c
 	lastConfirmedMessageID := impl.ackDetails.EarliestLastConfirmedMessageID()
 	persist := (!impl.ackDetails.IsNoPersistedMessage() || forcePersisted)
 
-	return impl.sendTsMsgToWAL(ctx, ts, lastConfirmedMessageID, persist, appender)
+	// the source id configured at the registration takes precedence over the default one.
+	sourceID := impl.sourceID
+	if id, ok := inspector.SyncSourceID(ctx); ok {
+		sourceID = id
+	}
+	// the sequence is assigned by the inspector, it's not stamped if the sync is not performed by the inspector.
+	sequence, _ := inspector.SyncSequence(ctx)
+
+	if err := impl.sendTsMsgToWAL(ctx, ts, lastConfirmedMessageID, sourceID, sequence, persist, appender); err != nil {
+		return inspector.SyncResult{}, err
+	}
+	return inspector.SyncResult{TimeTick: ts, Persisted: persist}, nil
 }
 
 // sendPersistentTsMsg sends persistent time tick message to wal.
 func (impl *timeTickSyncOperator) sendTsMsgToWAL(ctx context.Context,
 	ts uint64,
 	lastConfirmedMessageID message.MessageID,
+	sourceID int64,
+	sequence uint64,
 	persist bool,
 	appender func(ctx context.Context, msg message.MutableMessage) (message.MessageID, error),
 ) error {
-	msg := NewTimeTickMsg(ts, lastConfirmedMessageID, impl.sourceID, persist)
+	msg := NewSequencedTimeTickMsg(ts, lastConfirmedMessageID, sourceID, sequence, persist)
 	if !persist {
 		// there's no persisted message, so no need to send persistent time tick message.
 		// With the hint of not persisted message, the underlying wal will not persist it.
@@ -143,6 +177,13 @@ func (impl *timeTickSyncOperator) sendTsMsgToWAL(ctx context.Context,
 		)
 	}
 
+	if persist {
+		impl.lastPersistedTimeTick.Store(ts)
+		if sequence != 0 {
+			impl.lastPersistedSequence.Store(sequence)
+		}
+	}
+
 	// metrics updates
 	impl.metrics.CountTimeTickSync(ts, persist)
 	msgs := make([]message.ImmutableMessage, 0, impl.ackDetails.Len())
@@ -2,9 +2,12 @@ package timetick
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/errors"
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/milvus-io/milvus/internal/streamingnode/server/resource"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors"
+	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/inspector"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/timetick/mvcc"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/interceptors/wab"
 	"github.com/milvus-io/milvus/internal/streamingnode/server/wal/utility"
@@ -60,6 +64,9 @@ func TestTimeTickSyncOperator(t *testing.T) {
 	operator := newTimeTickSyncOperator(param)
 	assert.Equal(t, "test", operator.Channel().Name)
 	defer operator.Close()
+	lastPersisted, err := operator.LastPersistedTimeTick()
+	assert.NoError(t, err)
+	assert.Equal(t, ts, lastPersisted)
 	wb := operator.WriteAheadBuffer()
 
 	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
@@ -70,7 +77,10 @@ func TestTimeTickSyncOperator(t *testing.T) {
 	assert.ErrorIs(t, err, context.DeadlineExceeded)
 	assert.Nil(t, r)
 	// should not trigger any wal operation, but only update the timetick.
-	operator.Sync(context.Background(), false)
+	result, err := operator.Sync(context.Background(), false)
+	assert.NoError(t, err)
+	assert.True(t, result.IsSent())
+	assert.False(t, result.Persisted)
 	r, err = wb.ReadFromExclusiveTimeTick(context.Background(), newTs)
 	assert.NoError(t, err)
 	// should not block because timetick updates.
@@ -81,11 +91,240 @@ func TestTimeTickSyncOperator(t *testing.T) {
 
 	// should trigger wal operation.
 	l.EXPECT().Append(mock.Anything, mock.Anything).Unset()
+	sourceIDs := make(chan int64, 1)
 	l.EXPECT().Append(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, mm message.MutableMessage) (*types.AppendResult, error) {
+		ttMsg, err := message.AsMutableTimeTickMessageV1(mm)
+		assert.NoError(t, err)
+		body, err := ttMsg.Body()
+		assert.NoError(t, err)
+		sourceIDs <- body.GetBase().GetSourceID()
 		return &types.AppendResult{
 			MessageID: walimplstest.NewTestMessageID(1),
 			TimeTick:  mm.TimeTick(),
 		}, nil
 	})
-	operator.Sync(context.Background(), true)
+	result, err = operator.Sync(context.Background(), true)
+	assert.NoError(t, err)
+	assert.True(t, result.IsSent())
+	assert.True(t, result.Persisted)
+	// the time tick is stamped with the id of node by default.
+	assert.Equal(t, paramtable.GetNodeID(), <-sourceIDs)
+	lastPersisted, err = operator.LastPersistedTimeTick()
+	assert.NoError(t, err)
+	assert.Equal(t, result.TimeTick, lastPersisted)
+}
+
+func TestTimeTickSyncOperatorPersister(t *testing.T) {
+	paramtable.Init()
+	resource.InitForTest(t)
+	ctx := context.Background()
+
+	msgID := walimplstest.NewTestMessageID(1)
+	channel := types.PChannelInfo{Name: "test", Term: 1}
+	ts, _ := resource.Resource().TSOAllocator().Allocate(ctx)
+	lastMsg := NewTimeTickMsg(ts, nil, 0, true)
+
+	param := &interceptors.InterceptorBuildParam{
+		ChannelInfo: channel,
+		// the wal is never ready, the persister is used instead.
+		WAL:                  syncutil.NewFuture[wal.WAL](),
+		InitializedTimeTick:  ts,
+		InitializedMessageID: msgID,
+		WriteAheadBuffer: wab.NewWriteAheadBuffer(
+			channel.Name,
+			resource.Resource().Logger().With(),
+			1024,
+			30*time.Second,
+			lastMsg.IntoImmutableMessage(msgID),
+		),
+		MVCCManager: mvcc.NewMVCCManager(ts),
+	}
+	persister := &memPersister{}
+	operator := newTimeTickSyncOperator(param, OptPersister(persister))
+	defer operator.Close()
+
+	result, err := operator.Sync(ctx, false)
+	assert.NoError(t, err)
+	assert.False(t, result.Persisted)
+	assert.Equal(t, []uint64{result.TimeTick}, persister.notPersisted)
+	assert.Empty(t, persister.persisted)
+	// the not persisted sync doesn't move the last persisted time tick.
+	lastPersisted, err := operator.LastPersistedTimeTick()
+	assert.NoError(t, err)
+	assert.Equal(t, ts, lastPersisted)
+
+	for i := 0; i < 3; i++ {
+		result, err = operator.Sync(ctx, true)
+		assert.NoError(t, err)
+		assert.True(t, result.Persisted)
+		assert.Len(t, persister.persisted, i+1)
+		assert.Equal(t, result.TimeTick, persister.persisted[i])
+		if i > 0 {
+			assert.Greater(t, persister.persisted[i], persister.persisted[i-1])
+		}
+		lastPersisted, err = operator.LastPersistedTimeTick()
+		assert.NoError(t, err)
+		assert.Equal(t, result.TimeTick, lastPersisted)
+	}
+	assert.Len(t, persister.notPersisted, 1)
+
+	// the error of persister is returned, and the time tick is kept to be synced next time.
+	persister.err = errors.New("persist failed")
+	_, err = operator.Sync(ctx, true)
+	assert.Error(t, err)
+	// the failed sync doesn't move the last persisted time tick.
+	lastPersisted, err = operator.LastPersistedTimeTick()
+	assert.NoError(t, err)
+	assert.Equal(t, persister.persisted[len(persister.persisted)-1], lastPersisted)
+	persister.err = nil
+	result, err = operator.Sync(ctx, true)
+	assert.NoError(t, err)
+	assert.Equal(t, result.TimeTick, persister.persisted[len(persister.persisted)-1])
+}
+
+func TestTimeTickSyncOperatorRecoverSequence(t *testing.T) {
+	paramtable.Init()
+	resource.InitForTest(t)
+	ctx := context.Background()
+
+	msgID := walimplstest.NewTestMessageID(1)
+	channel := types.PChannelInfo{Name: "test-recover-sequence", Term: 1}
+	ts, _ := resource.Resource().TSOAllocator().Allocate(ctx)
+	lastMsg := NewTimeTickMsg(ts, nil, 0, true)
+
+	param := &interceptors.InterceptorBuildParam{
+		ChannelInfo:          channel,
+		WAL:                  syncutil.NewFuture[wal.WAL](),
+		InitializedTimeTick:  ts,
+		InitializedMessageID: msgID,
+		// the sequence recovered from the wal when the wal is opened.
+		InitializedTimeTickSequence: 5,
+		WriteAheadBuffer: wab.NewWriteAheadBuffer(
+			channel.Name,
+			resource.Resource().Logger().With(),
+			1024,
+			30*time.Second,
+			lastMsg.IntoImmutableMessage(msgID),
+		),
+		MVCCManager: mvcc.NewMVCCManager(ts),
+	}
+	persister := &memPersister{}
+	operator := newTimeTickSyncOperator(param, OptPersister(persister))
+	defer operator.Close()
+	sequence, err := operator.LastPersistedSequence()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), sequence)
+
+	// the sequence is recovered by the inspector on registration, and continues from the recovered one.
+	i := inspector.NewTimeTickSyncInspector()
+	defer i.Close()
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+	stats, err := i.SyncStats(channel)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, stats.LastEmittedSequence, uint64(5))
+
+	i.TriggerSync(channel, true)
+	assert.Eventually(t, func() bool {
+		return len(persister.Sequences()) > 0
+	}, 5*time.Second, time.Millisecond)
+	sequences := persister.Sequences()
+	assert.Greater(t, sequences[0], uint64(5))
+	// the last persisted sequence is updated once the append returns.
+	assert.Eventually(t, func() bool {
+		sequence, err := operator.LastPersistedSequence()
+		return err == nil && sequence >= sequences[0]
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestTimeTickSyncOperatorOnBecomeLeader(t *testing.T) {
+	paramtable.Init()
+	resource.InitForTest(t)
+	ctx := context.Background()
+
+	msgID := walimplstest.NewTestMessageID(1)
+	channel := types.PChannelInfo{Name: "test-become-leader", Term: 1}
+	ts, _ := resource.Resource().TSOAllocator().Allocate(ctx)
+	lastMsg := NewTimeTickMsg(ts, nil, 0, true)
+
+	param := &interceptors.InterceptorBuildParam{
+		ChannelInfo:          channel,
+		WAL:                  syncutil.NewFuture[wal.WAL](),
+		InitializedTimeTick:  ts,
+		InitializedMessageID: msgID,
+		WriteAheadBuffer: wab.NewWriteAheadBuffer(
+			channel.Name,
+			resource.Resource().Logger().With(),
+			1024,
+			30*time.Second,
+			lastMsg.IntoImmutableMessage(msgID),
+		),
+		MVCCManager: mvcc.NewMVCCManager(ts),
+	}
+	persister := &memPersister{}
+	operator := newTimeTickSyncOperator(param, OptPersister(persister))
+	defer operator.Close()
+
+	// the fake clock is never advanced, so no periodic sync happens.
+	i := inspector.NewTimeTickSyncInspector(inspector.OptClock(clockwork.NewFakeClock()))
+	defer i.Close()
+	i.RegisterSyncOperator(operator)
+	defer i.UnregisterSyncOperator(operator)
+
+	// the non-persisted syncs move the live watermark ahead of the last persisted time tick of the operator.
+	i.TriggerSync(channel, false)
+	var live uint64
+	assert.Eventually(t, func() bool {
+		state, err := i.ExportSyncState(channel)
+		live = state.LastEmittedTimeTick
+		return err == nil && live > ts
+	}, 5*time.Second, time.Millisecond)
+	lastPersisted, err := operator.LastPersistedTimeTick()
+	assert.NoError(t, err)
+	assert.Less(t, lastPersisted, live)
+
+	// the recovered time tick is behind the live watermark, so it's not applied on leadership change.
+	i.OnBecomeLeader(channel)
+	assert.Eventually(t, func() bool {
+		lastPersisted, err := operator.LastPersistedTimeTick()
+		return err == nil && lastPersisted > live
+	}, 5*time.Second, time.Millisecond)
+	regression, err := i.LastWatermarkRegression(channel)
+	assert.NoError(t, err)
+	assert.Nil(t, regression)
+	state, err := i.ExportSyncState(channel)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, state.LastEmittedTimeTick, live)
+}
+
+// memPersister is an in-memory persister that records the time ticks.
+type memPersister struct {
+	mu           sync.Mutex
+	err          error
+	persisted    []uint64
+	notPersisted []uint64
+	sequences    []uint64 // the sequences of the persisted time ticks.
+}
+
+func (p *memPersister) Persist(ctx context.Context, msg message.MutableMessage, persisted bool) (message.MessageID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if hint := utility.GetNotPersisted(ctx); hint != nil {
+		p.notPersisted = append(p.notPersisted, msg.TimeTick())
+		return hint.MessageID, nil
+	}
+	p.persisted = append(p.persisted, msg.TimeTick())
+	if sequence, ok := message.GetTimeTickSequence(msg); ok {
+		p.sequences = append(p.sequences, sequence)
+	}
+	return walimplstest.NewTestMessageID(int64(len(p.persisted) + 1)), nil
+}
+
+func (p *memPersister) Sequences() []uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]uint64(nil), p.sequences...)
 }
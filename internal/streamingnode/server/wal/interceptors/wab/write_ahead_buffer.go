@@ -21,6 +21,9 @@ type ROWriteAheadBuffer interface {
 	// ReadFromExclusiveTimeTick reads messages from the buffer from the exclusive time tick.
 	// Return a reader if the timetick can be consumed from the write-ahead buffer, otherwise return error.
 	ReadFromExclusiveTimeTick(ctx context.Context, timetick uint64) (*WriteAheadBufferReader, error)
+
+	// BufferedBytes returns the total size of the messages that are held in the buffer.
+	BufferedBytes() int
 }
 
 // NewWriteAheadBuffer creates a new WriteAheadBuffer.
@@ -90,6 +93,13 @@ func (w *WriteAheadBuffer) Append(msgs []message.ImmutableMessage, tsMsg message
 	)
 }
 
+// BufferedBytes returns the total size of the messages that are held in the buffer.
+func (w *WriteAheadBuffer) BufferedBytes() int {
+	w.cond.L.Lock()
+	defer w.cond.L.Unlock()
+	return w.pendingMessages.Size()
+}
+
 // ReadFromExclusiveTimeTick reads messages from the buffer from the exclusive time tick.
 func (w *WriteAheadBuffer) ReadFromExclusiveTimeTick(ctx context.Context, timetick uint64) (*WriteAheadBufferReader, error) {
 	snapshot, nextOffset, err := w.createSnapshotFromTimeTick(ctx, timetick)
@@ -26,6 +26,9 @@ const (
 	CreateConsumerLabel = "create_consumer"
 
 	msgStreamOpType = "message_op_type"
+	msgStreamTopic  = "topic"
+	msgStreamReason = "reason"
+	msgStreamBroker = "broker"
 )
 
 var (
@@ -56,6 +59,89 @@ var (
 			Name:      "op_count",
 			Help:      "count of stream message operation",
 		}, []string{msgStreamOpType, statusLabelName})
+
+	MsgStreamProduceMessageBytes = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "produce_message_bytes",
+			Help:      "payload size of produced message in bytes",
+			Buckets:   messageBytesBuckets,
+		}, []string{msgStreamTopic})
+
+	MsgStreamProduceDroppedMessageTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "produce_dropped_message_total",
+			Help:      "count of produced messages dropped because the queue of producer is full",
+		}, []string{msgStreamTopic})
+
+	MsgStreamConsumeSkippedMessageTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "consume_skipped_message_total",
+			Help:      "count of consumed messages skipped without delivery, e.g. the unparseable ones",
+		}, []string{msgStreamTopic, msgStreamReason})
+
+	MsgStreamConsumeOffsetGapTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "consume_offset_gap_total",
+			Help:      "count of jumps between the offsets of consecutive consumed messages",
+		}, []string{msgStreamTopic})
+
+	MsgStreamConsumeChecksumMismatchTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "consume_checksum_mismatch_total",
+			Help:      "count of consumed messages whose payload doesn't match the checksum stamped by the producer",
+		}, []string{msgStreamTopic})
+
+	MsgStreamConsumeReturnLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "consume_return_latency",
+			Help:      "latency from the message is fetched to it's returned to the caller in milliseconds, including the payload transform",
+			Buckets:   buckets,
+		}, []string{msgStreamTopic})
+
+	MsgStreamConsumeProcessLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "consume_process_latency",
+			Help:      "latency from the message is returned to the caller to it's reported done in milliseconds",
+			Buckets:   buckets,
+		}, []string{msgStreamTopic})
+
+	MsgStreamProduceInflightMessages = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "produce_inflight_messages",
+			Help:      "number of produced messages whose delivery report is not arrived yet, only tracked by the producers with the in-flight limit",
+		}, []string{msgStreamTopic})
+
+	MsgStreamConsumeBrokerThrottleTime = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "consume_broker_throttle_time",
+			Help:      "max throttle time of consumer by the broker in the latest statistics window in milliseconds, 0 if not throttled",
+		}, []string{msgStreamTopic, msgStreamBroker})
+
+	MsgStreamProducerInitDuration = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "producer_init_duration",
+			Help:      "duration of the first producer creation of the process in milliseconds",
+		})
 )
 
 // RegisterMsgStreamMetrics registers msg stream metrics
@@ -63,4 +149,14 @@ func RegisterMsgStreamMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(NumConsumers)
 	registry.MustRegister(MsgStreamRequestLatency)
 	registry.MustRegister(MsgStreamOpCounter)
+	registry.MustRegister(MsgStreamProduceMessageBytes)
+	registry.MustRegister(MsgStreamProduceDroppedMessageTotal)
+	registry.MustRegister(MsgStreamConsumeSkippedMessageTotal)
+	registry.MustRegister(MsgStreamConsumeOffsetGapTotal)
+	registry.MustRegister(MsgStreamConsumeChecksumMismatchTotal)
+	registry.MustRegister(MsgStreamConsumeReturnLatency)
+	registry.MustRegister(MsgStreamConsumeProcessLatency)
+	registry.MustRegister(MsgStreamProduceInflightMessages)
+	registry.MustRegister(MsgStreamConsumeBrokerThrottleTime)
+	registry.MustRegister(MsgStreamProducerInitDuration)
 }
@@ -27,6 +27,9 @@ const (
 	WALScannerModelLabelName          = "scanner_model"
 	TimeTickSyncTypeLabelName         = "type"
 	TimeTickAckTypeLabelName          = "type"
+	TimeTickErrorCategoryLabelName    = "category"
+	TimeTickAuditKindLabelName        = "kind"
+	TimeTickSkewDirectionLabelName    = "direction"
 	WALInterceptorLabelName           = "interceptor_name"
 	WALTxnStateLabelName              = "state"
 	WALFlusherStateLabelName          = "state"
@@ -219,6 +222,59 @@ var (
 		Help: "Max time tick of time tick sync sent",
 	}, WALChannelLabelName, TimeTickSyncTypeLabelName)
 
+	WALTimeTickWatermarkRegressionTotal = newWALCounterVec(prometheus.CounterOpts{
+		Name: "time_tick_watermark_regression_total",
+		Help: "Total of rejected watermark regression of time tick sync",
+	}, WALChannelLabelName)
+
+	WALTimeTickSyncStallTotal = newWALCounterVec(prometheus.CounterOpts{
+		Name: "time_tick_sync_stall_total",
+		Help: "Total of time tick syncs that are stalled longer than the threshold",
+	}, WALChannelLabelName)
+
+	WALTimeTickSyncErrorTotal = newWALCounterVec(prometheus.CounterOpts{
+		Name: "time_tick_sync_error_total",
+		Help: "Total of failed time tick syncs by the category of error",
+	}, WALChannelLabelName, TimeTickErrorCategoryLabelName)
+
+	WALTimeTickAuditFindingTotal = newWALCounterVec(prometheus.CounterOpts{
+		Name: "time_tick_audit_finding_total",
+		Help: "Total of discrepancies of the time tick watermark found by the audit",
+	}, WALChannelLabelName, TimeTickAuditKindLabelName)
+
+	WALTimeTickShedTriggerTotal = newWALCounterVec(prometheus.CounterOpts{
+		Name: "time_tick_shed_trigger_total",
+		Help: "Total of triggered time tick syncs shed because the backlog of triggers is full",
+	}, TimeTickSyncTypeLabelName)
+
+	WALTimeTickBufferPressure = newWALGaugeVec(prometheus.GaugeOpts{
+		Name: "time_tick_buffer_pressure",
+		Help: "Whether the write ahead buffers of the streaming node are under pressure, 1 if the total buffered size exceeds the high watermark",
+	})
+
+	WALTimeTickDryRunSyncTotal = newWALCounterVec(prometheus.CounterOpts{
+		Name: "time_tick_dry_run_sync_total",
+		Help: "Total of time tick syncs that would be performed by the inspector in dry-run mode",
+	}, WALChannelLabelName, TimeTickSyncTypeLabelName)
+
+	WALTimeTickPersistedSyncWaitSeconds = newWALHistogramVec(prometheus.HistogramOpts{
+		Name:    "time_tick_persisted_sync_wait_seconds",
+		Help:    "Duration of persisted time tick sync waiting for the concurrency limit",
+		Buckets: secondsBuckets,
+	}, WALChannelLabelName)
+
+	WALTimeTickWatermarkDeltaSeconds = newWALHistogramVec(prometheus.HistogramOpts{
+		Name:    "time_tick_watermark_delta_seconds",
+		Help:    "Physical delta of the watermark between consecutive emitted time ticks",
+		Buckets: secondsBuckets,
+	}, WALChannelLabelName)
+
+	WALTimeTickClockSkewSeconds = newWALHistogramVec(prometheus.HistogramOpts{
+		Name:    "time_tick_clock_skew_seconds",
+		Help:    "Physical skew of the synced time tick, behind the watermark or ahead of the clock of the streaming node",
+		Buckets: secondsBuckets,
+	}, WALChannelLabelName, TimeTickSkewDirectionLabelName)
+
 	// Txn Related Metrics
 	WALInflightTxn = newWALGaugeVec(prometheus.GaugeOpts{
 		Name: "inflight_txn",
@@ -432,6 +488,16 @@ func registerWAL(registry *prometheus.Registry) {
 	registry.MustRegister(WALSyncTimeTickTotal)
 	registry.MustRegister(WALTimeTickSyncTotal)
 	registry.MustRegister(WALTimeTickSyncTimeTick)
+	registry.MustRegister(WALTimeTickWatermarkRegressionTotal)
+	registry.MustRegister(WALTimeTickSyncStallTotal)
+	registry.MustRegister(WALTimeTickSyncErrorTotal)
+	registry.MustRegister(WALTimeTickAuditFindingTotal)
+	registry.MustRegister(WALTimeTickShedTriggerTotal)
+	registry.MustRegister(WALTimeTickBufferPressure)
+	registry.MustRegister(WALTimeTickPersistedSyncWaitSeconds)
+	registry.MustRegister(WALTimeTickWatermarkDeltaSeconds)
+	registry.MustRegister(WALTimeTickClockSkewSeconds)
+	registry.MustRegister(WALTimeTickDryRunSyncTotal)
 	registry.MustRegister(WALInflightTxn)
 	registry.MustRegister(WALTxnDurationSeconds)
 	registry.MustRegister(WALSegmentAllocTotal)
@@ -18,6 +18,7 @@ package common
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"google.golang.org/protobuf/proto"
@@ -33,6 +34,94 @@ type ProducerOptions struct {
 	// Enable compression
 	// For Pulsar, this enables ZSTD compression with default compression level
 	EnableCompression bool
+
+	// LingerMs overrides the client default linger time of the producer in milliseconds.
+	// Zero means using the client default, only used by kafka now.
+	LingerMs int
+
+	// Durability is the delivery guarantee of the producer, at-least-once by default, only used by kafka now.
+	Durability DurabilityMode
+
+	// QueueFullPolicy is the behavior of the send when the local queue of the producer is full,
+	// an error is returned by default, only used by kafka now.
+	QueueFullPolicy QueueFullPolicy
+
+	// QueueFullBlockTimeout is the max time to wait for the space of the local queue with QueueFullBlock,
+	// zero means waiting until the context is done.
+	QueueFullBlockTimeout time.Duration
+
+	// SchemaVersion is stamped into the header of every message sent by the producer,
+	// so the consumer can tell the version of the payload. Empty means unset, only used by kafka now.
+	SchemaVersion string
+
+	// MaxInflightMessages is the max number of messages that are produced but not confirmed by the delivery report yet,
+	// the send blocks until a delivery report arrives once the limit is reached. Zero means unlimited, only used by kafka now.
+	MaxInflightMessages int
+
+	// Checksum makes the producer stamp the CRC-32C checksum of the payload into the header of every message,
+	// so the consumer can verify the integrity of the payload. Only used by kafka now.
+	Checksum bool
+}
+
+// DurabilityMode is the delivery guarantee of a producer.
+type DurabilityMode int
+
+const (
+	// DurabilityAtLeastOnce waits for the acknowledgement of all in-sync replicas and retries on failure,
+	// the message may be duplicated by the retry.
+	DurabilityAtLeastOnce DurabilityMode = iota
+
+	// DurabilityAtMostOnce sends the message without acknowledgement and retry, the message may be lost.
+	// It's only used by the best-effort streams, the send doesn't wait for the delivery confirmation.
+	DurabilityAtMostOnce
+
+	// DurabilityExactlyOnce enables the idempotent producer, the message is not duplicated by the retry.
+	DurabilityExactlyOnce
+)
+
+// String implements fmt.Stringer.
+func (m DurabilityMode) String() string {
+	switch m {
+	case DurabilityAtLeastOnce:
+		return "AtLeastOnce"
+	case DurabilityAtMostOnce:
+		return "AtMostOnce"
+	case DurabilityExactlyOnce:
+		return "ExactlyOnce"
+	default:
+		return fmt.Sprintf("Unknown(%d)", int(m))
+	}
+}
+
+// QueueFullPolicy is the behavior of the send when the local queue of the producer is full.
+type QueueFullPolicy int
+
+const (
+	// QueueFullError returns the queue full error to the caller immediately, even if the context of send has a deadline.
+	QueueFullError QueueFullPolicy = iota
+
+	// QueueFullBlock retries the enqueue until the block timeout or the deadline of the context of send,
+	// the queue full error is returned if the queue is still full,
+	// which is marked as the context error if the context is done first.
+	QueueFullBlock
+
+	// QueueFullDrop drops the message, the send returns the dropped error of the producer.
+	// It's only used by the best-effort streams.
+	QueueFullDrop
+)
+
+// String implements fmt.Stringer.
+func (p QueueFullPolicy) String() string {
+	switch p {
+	case QueueFullError:
+		return "Error"
+	case QueueFullBlock:
+		return "Block"
+	case QueueFullDrop:
+		return "Drop"
+	default:
+		return fmt.Sprintf("Unknown(%d)", int(p))
+	}
 }
 
 // ProducerMessage contains the messages of a producer
@@ -42,6 +131,17 @@ type ProducerMessage struct {
 	// Properties are application defined key/value pairs that will be attached to the message.
 	// Return the properties attached to the message.
 	Properties map[string]string
+	// Timestamp is the create time of the message set by the producer.
+	// Zero means the timestamp is set by the client when producing, only used by kafka now.
+	// It's overwritten by the broker time if the topic is configured with `message.timestamp.type=LogAppendTime`.
+	Timestamp time.Time
+	// TTL is the time to live of the message since its timestamp, zero means the message never expires.
+	// The expiry is stamped into the message and the consumer may skip the expired message, only used by kafka now.
+	TTL time.Duration
+	// DedupKey is the application-level key to identify the message across the retries, empty means no key.
+	// The key is stamped into the message and the consumer may filter the duplicates with the same key,
+	// e.g. the retried writes that span the restart of producer. Only used by kafka now.
+	DedupKey string
 }
 
 // Message is the interface that provides operations of a consumer
@@ -186,7 +186,7 @@ type KmsFactory struct {
 }
 
 func (f *KmsFactory) NewMsgStream(ctx context.Context) (MsgStream, error) {
-	kafkaClient, err := kafkawrapper.NewKafkaClientInstanceWithConfig(ctx, f.config)
+	kafkaClient, err := kafkawrapper.GetKafkaClientInstanceWithConfig(ctx, f.config)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +194,7 @@ func (f *KmsFactory) NewMsgStream(ctx context.Context) (MsgStream, error) {
 }
 
 func (f *KmsFactory) NewTtMsgStream(ctx context.Context) (MsgStream, error) {
-	kafkaClient, err := kafkawrapper.NewKafkaClientInstanceWithConfig(ctx, f.config)
+	kafkaClient, err := kafkawrapper.GetKafkaClientInstanceWithConfig(ctx, f.config)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +37,11 @@ type ConsumerOptions struct {
 
 	// Set receive channel size
 	BufSize int64
+
+	// CatchUp consumes with an aggressive prefetch until the consumer is caught up with the latest message,
+	// and then switches to the latency-optimized tailing, e.g. for the cold-start recovery.
+	// Only supported by kafka, ignored by other mq.
+	CatchUp bool
 }
 
 // Consumer is the interface that provides operations of a consumer
@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+)
+
+// newCatchUpConsumerConfig creates the config of the catch up phase from the config of tailing,
+// the prefetch is enlarged to consume the backlog quickly, and the partition EOF is reported to detect the catch up.
+func newCatchUpConsumerConfig(tailingConfig *kafka.ConfigMap) *kafka.ConfigMap {
+	config := cloneKafkaConfig(*tailingConfig)
+	config.SetKey("enable.partition.eof", true)
+	config.SetKey("fetch.min.bytes", 1048576)
+	config.SetKey("max.partition.fetch.bytes", 10485760)
+	config.SetKey("queued.min.messages", 1000000)
+	config.SetKey("queued.max.messages.kbytes", 262144)
+	return config
+}
+
+// enableCatchUp makes the consumer switch to a new underlying consumer with the tailing config
+// once it's caught up with the latest message.
+// The consumer should be created with the config of newCatchUpConsumerConfig.
+func (kc *Consumer) enableCatchUp(tailingConfig *kafka.ConfigMap) {
+	kc.config = tailingConfig
+	kc.caughtUp = make(chan struct{})
+}
+
+// CaughtUp returns a channel that is closed once the consumer is caught up with the latest message
+// at the first partition EOF and switches to tailing, nil if the consumer is not subscribed with CatchUp.
+func (kc *Consumer) CaughtUp() <-chan struct{} {
+	return kc.caughtUp
+}
+
+// isCatchingUp returns whether the consumer is in the catch up phase.
+func (kc *Consumer) isCatchingUp() bool {
+	if kc.caughtUp == nil {
+		return false
+	}
+	select {
+	case <-kc.caughtUp:
+		return false
+	default:
+		return true
+	}
+}
+
+// readMessage reads the next message, the partition EOF is handled if the consumer is catching up,
+// and the statistics are observed for the broker throttling.
+func (kc *Consumer) readMessage(readTimeout time.Duration) (*kafka.Message, error) {
+	deadline := time.Now().Add(readTimeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, kafka.NewError(kafka.ErrTimedOut, "read message timed out", false)
+		}
+		// poll in slices, so the consumer can be reconfigured between the polls.
+		kc.mu.RLock()
+		e := kc.c.Poll(int(min(remaining, reconfigurePollInterval).Milliseconds()))
+		kc.mu.RUnlock()
+		switch e := e.(type) {
+		case *kafka.Message:
+			if e.TopicPartition.Error != nil {
+				return nil, e.TopicPartition.Error
+			}
+			return e, nil
+		case kafka.Error:
+			return nil, e
+		case kafka.PartitionEOF:
+			if kc.isCatchingUp() {
+				kc.switchToTailing(e)
+			}
+		case *kafka.Stats:
+			kc.observeStats(e.String())
+		}
+	}
+}
+
+// switchToTailing replaces the underlying consumer with a new one created by the tailing config,
+// which continues from the offset of the partition EOF.
+// The catch up consumer is kept if the tailing consumer can't be created.
+func (kc *Consumer) switchToTailing(eof kafka.PartitionEOF) {
+	defer close(kc.caughtUp)
+
+	logger := log.With(zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Any("offset", eof.Offset))
+	c, err := kafka.NewConsumer(kc.config)
+	if err != nil {
+		logger.Warn("create tailing kafka consumer failed, keep consuming with the catch up consumer", zap.Error(err))
+		return
+	}
+	if err := c.Assign([]kafka.TopicPartition{{Topic: &kc.topic, Partition: mqwrapper.DefaultPartitionIdx, Offset: eof.Offset}}); err != nil {
+		logger.Warn("assign tailing kafka consumer failed, keep consuming with the catch up consumer", zap.Error(err))
+		c.Close()
+		return
+	}
+	kc.mu.Lock()
+	catchUp := kc.c
+	kc.c = c
+	kc.assigned = eof.Offset
+	kc.mu.Unlock()
+	if err := catchUp.Close(); err != nil {
+		logger.Warn("close catch up kafka consumer failed", zap.Error(err))
+	}
+	logger.Info("kafka consumer is caught up, switch to tailing")
+}
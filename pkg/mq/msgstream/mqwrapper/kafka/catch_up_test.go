@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+)
+
+func TestKafkaConsumer_CatchUp(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	send := func(i int) {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte(fmt.Sprint(i))})
+		assert.NoError(t, err)
+	}
+	backlog := 10
+	for i := 0; i < backlog; i++ {
+		send(i)
+	}
+
+	// the consumer is not in catch up mode by default.
+	consumer, err := kc.Subscribe(context.TODO(), mqwrapper.ConsumerOptions{
+		Topic:                       topic,
+		SubscriptionName:            fmt.Sprintf("test-group-%d", rand.Int()),
+		SubscriptionInitialPosition: mqcommon.SubscriptionPositionEarliest,
+		BufSize:                     1,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, consumer.(*Consumer).CaughtUp())
+	consumer.Close()
+
+	consumer, err = kc.Subscribe(context.TODO(), mqwrapper.ConsumerOptions{
+		Topic:                       topic,
+		SubscriptionName:            fmt.Sprintf("test-group-%d", rand.Int()),
+		SubscriptionInitialPosition: mqcommon.SubscriptionPositionEarliest,
+		BufSize:                     1,
+		CatchUp:                     true,
+	})
+	assert.NoError(t, err)
+	defer consumer.Close()
+	kafkaConsumer := consumer.(*Consumer)
+	catchUpConsumer := kafkaConsumer.c
+	caughtUp := kafkaConsumer.CaughtUp()
+
+	// the backlog is not consumed yet.
+	msgChan := consumer.Chan()
+	select {
+	case <-caughtUp:
+		t.Fatal("the consumer is caught up before the backlog is consumed")
+	case <-time.After(200 * time.Millisecond):
+	}
+	for i := 0; i < backlog; i++ {
+		msg := <-msgChan
+		assert.Equal(t, []byte(fmt.Sprint(i)), msg.Payload())
+	}
+
+	// the consumer switches to tailing at EOF.
+	select {
+	case <-caughtUp:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the consumer is not caught up after the backlog is consumed")
+	}
+	kafkaConsumer.mu.RLock()
+	assert.NotSame(t, catchUpConsumer, kafkaConsumer.c)
+	kafkaConsumer.mu.RUnlock()
+
+	// the new messages are received after switching to tailing.
+	for i := backlog; i < backlog+5; i++ {
+		send(i)
+		msg := <-msgChan
+		assert.Equal(t, []byte(fmt.Sprint(i)), msg.Payload())
+	}
+	positions, err := kafkaConsumer.Assignment()
+	assert.NoError(t, err)
+	assert.Equal(t, []PartitionPosition{{Topic: topic, Partition: 0, Offset: int64(backlog + 5)}}, positions)
+}
@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/v2/util/typeutil"
+)
+
+// clients are the registered kafka clients, keyed by the normalized config of client.
+var clients = typeutil.NewConcurrentMap[string, *kafkaClient]()
+
+// clientKey returns the registry key of the kafka client with the basic config, the extra consumer config and the extra producer config.
+// The bootstrap servers are resolved into a sorted and deduplicated list, so the same cluster is identified
+// regardless of the order of servers, and all the other settings, including the credentials, tell apart the clients.
+// The key is the hash of the normalized config, so the secrets are not kept in plain text by the registry.
+func clientKey(basicConfig kafka.ConfigMap, consumerConfig kafka.ConfigMap, producerConfig kafka.ConfigMap) string {
+	h := sha256.New()
+	write := func(prefix string, config kafka.ConfigMap) {
+		keys := lo.Keys(config)
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := fmt.Sprint(config[key])
+			if prefix == "basic" && key == "bootstrap.servers" {
+				servers := lo.Uniq(lo.FilterMap(strings.Split(value, ","), func(server string, _ int) (string, bool) {
+					server = strings.TrimSpace(server)
+					return server, server != ""
+				}))
+				sort.Strings(servers)
+				value = strings.Join(servers, ",")
+			}
+			// the length prefix keeps the boundaries of the entries unambiguous.
+			fmt.Fprintf(h, "%s.%d:%s=%d:%s;", prefix, len(key), key, len(value), value)
+		}
+	}
+	write("basic", basicConfig)
+	write("consumer", consumerConfig)
+	write("producer", producerConfig)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetKafkaClientInstanceWithConfig returns the registered kafka client of the config,
+// a new client is created and registered if there's no client of the same config.
+// The underlying producers are shared by all kafka clients anyway, so the callers of the same config
+// share one client explicitly instead of creating many clients that look isolated but are not.
+func GetKafkaClientInstanceWithConfig(ctx context.Context, config *paramtable.KafkaConfig) (mqwrapper.Client, error) {
+	basicConfig, consumerConfig, producerConfig, err := getConfigMaps(config)
+	if err != nil {
+		return nil, err
+	}
+	key := clientKey(basicConfig, consumerConfig, producerConfig)
+	if client, ok := clients.Get(key); ok {
+		return client, nil
+	}
+	client, err := NewKafkaClientInstanceWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	client, loaded := clients.GetOrInsert(key, client)
+	if !loaded {
+		log.Info("kafka client is registered", zap.String("servers", config.Address.GetValue()))
+	}
+	return client, nil
+}
@@ -0,0 +1,41 @@
+package kafka
+
+import "container/list"
+
+// newDedupFilter creates a dedup filter that remembers the last capacity distinct keys, nil if the capacity is not positive.
+func newDedupFilter(capacity int) *dedupFilter {
+	if capacity <= 0 {
+		return nil
+	}
+	return &dedupFilter{
+		capacity: capacity,
+		order:    list.New(),
+		seen:     make(map[string]*list.Element, capacity),
+	}
+}
+
+// dedupFilter is a bounded LRU of the recently seen dedup keys, the least recently seen key is evicted once it's full.
+// It's only accessed by the goroutine that consumes the messages, so it's not thread-safe.
+type dedupFilter struct {
+	capacity int
+	order    *list.List // the keys from the most recently seen to the least.
+	seen     map[string]*list.Element
+}
+
+// Seen records the key and returns whether it's already seen, a nil filter never sees any key.
+func (f *dedupFilter) Seen(key string) bool {
+	if f == nil {
+		return false
+	}
+	if elem, ok := f.seen[key]; ok {
+		f.order.MoveToFront(elem)
+		return true
+	}
+	f.seen[key] = f.order.PushFront(key)
+	if f.order.Len() > f.capacity {
+		oldest := f.order.Back()
+		f.order.Remove(oldest)
+		delete(f.seen, oldest.Value.(string))
+	}
+	return false
+}
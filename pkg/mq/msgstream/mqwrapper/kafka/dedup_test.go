@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestDedupFilter(t *testing.T) {
+	f := newDedupFilter(2)
+	assert.False(t, f.Seen("a"))
+	assert.False(t, f.Seen("b"))
+	assert.True(t, f.Seen("a"))
+
+	// the least recently seen key b is evicted.
+	assert.False(t, f.Seen("c"))
+	assert.True(t, f.Seen("a"))
+	assert.False(t, f.Seen("b"))
+	assert.Equal(t, 2, len(f.seen))
+
+	// the dedup is disabled by a non-positive capacity.
+	assert.Nil(t, newDedupFilter(0))
+	assert.False(t, newDedupFilter(0).Seen("a"))
+}
+
+func TestKafkaConsumer_Dedup(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	// the first write of key-1 is retried by the restarted producer.
+	for _, msg := range []*mqcommon.ProducerMessage{
+		{Payload: []byte("first"), DedupKey: "key-1"},
+		{Payload: []byte("second"), DedupKey: "key-2"},
+		{Payload: []byte("no key")},
+		{Payload: []byte("first"), DedupKey: "key-1"},
+		{Payload: []byte("no key")},
+		{Payload: []byte("third"), DedupKey: "key-3"},
+	} {
+		_, err := producer.Send(context.TODO(), msg)
+		assert.NoError(t, err)
+	}
+	payloads := func(msgs []mqcommon.Message) []string {
+		result := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			result = append(result, string(msg.Payload()))
+		}
+		return result
+	}
+
+	// the duplicates are delivered if the dedup is not enabled.
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	msgs, err := consumer.ReceiveBatch(context.TODO(), 6, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "no key", "first", "no key", "third"}, payloads(msgs))
+
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	dedupConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer dedupConsumer.Close()
+	dedupConsumer.SetDedup(16)
+	msgs, err = dedupConsumer.ReceiveBatch(context.TODO(), 5, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "no key", "no key", "third"}, payloads(msgs))
+}
@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+)
+
+const (
+	// ControlHeaderKey is the header key of the control message, which carries no data and is never returned by the consumer.
+	ControlHeaderKey = "milvus-control"
+	// controlEndOfStream is the control header value of the end-of-stream marker.
+	controlEndOfStream = "end-of-stream"
+)
+
+// ErrEndOfStream is returned by the consumer with StopOnEndOfStream set once the end-of-stream marker is consumed.
+var ErrEndOfStream = errors.New("end of kafka stream")
+
+// SendEndOfStream writes the end-of-stream marker into the default partition of the topic after the sent messages,
+// so the bounded replay of the topic stops at the marker regardless of the end of partition reported by the broker.
+// The marker is a control message with empty payload, it's never routed to the retry topics.
+func (kp *kafkaProducer) SendEndOfStream(ctx context.Context) (mqcommon.MessageID, error) {
+	id, err := kp.send(ctx, mqwrapper.DefaultPartitionIdx, nil, &mqcommon.ProducerMessage{
+		Properties: map[string]string{ControlHeaderKey: controlEndOfStream},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "send end-of-stream marker to topic %s", kp.topic)
+	}
+	log.Info("kafka end-of-stream marker is sent", zap.String("topic", kp.topic), zap.Any("id", id))
+	return id, nil
+}
+
+// isEndOfStream returns whether the message is the end-of-stream marker.
+func isEndOfStream(msg *kafka.Message) bool {
+	for _, header := range msg.Headers {
+		if header.Key == ControlHeaderKey {
+			return string(header.Value) == controlEndOfStream
+		}
+	}
+	return false
+}
+
+// SetStopOnEndOfStream makes the consumer stop at the end-of-stream marker, the messages after the marker are not consumed.
+// The channel of Chan is closed once the marker is consumed, and Err, ReceiveBatch, Skip and ScanRange return ErrEndOfStream.
+// The marker is not acked, so the consumer that resumes the group stops at the marker again.
+// If it's not set, the marker is skipped as Skip does. It should be set before Chan is called.
+func (kc *Consumer) SetStopOnEndOfStream(stop bool) {
+	kc.stopOnEndOfStream = stop
+}
+
+// Err returns ErrEndOfStream if the consumer is stopped by the end-of-stream marker, nil otherwise.
+func (kc *Consumer) Err() error {
+	if kc.endOfStream.Load() {
+		return ErrEndOfStream
+	}
+	return nil
+}
+
+// consumeEndOfStream handles the message if it's the end-of-stream marker, false if it's not a marker.
+func (kc *Consumer) consumeEndOfStream(msg *kafka.Message) bool {
+	if !isEndOfStream(msg) {
+		return false
+	}
+	if !kc.stopOnEndOfStream {
+		kc.skip(&kafkaMessage{msg: msg, payload: msg.Value}, skipReasonEndOfStream)
+		return true
+	}
+	log.Info("kafka consumer is stopped by the end-of-stream marker", zap.String("topic", kc.topic),
+		zap.String("groupID", kc.groupID), zap.Any("offset", msg.TopicPartition.Offset))
+	kc.endOfStream.Store(true)
+	return true
+}
+
+// closedErr returns the error of receiving from the closed channel of Chan.
+func (kc *Consumer) closedErr() error {
+	if err := kc.Err(); err != nil {
+		return err
+	}
+	return errors.New("kafka consumer is closed")
+}
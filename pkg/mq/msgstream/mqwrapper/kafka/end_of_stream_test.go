@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestKafkaConsumer_EndOfStream(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	for _, payload := range []string{"a", "b", "c"} {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte(payload)})
+		assert.NoError(t, err)
+	}
+	_, err := producer.(*kafkaProducer).SendEndOfStream(context.TODO())
+	assert.NoError(t, err)
+	_, err = producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte("d")})
+	assert.NoError(t, err)
+
+	payloads := func(msgs []mqcommon.Message) []string {
+		result := make([]string, 0, len(msgs))
+		for _, msg := range msgs {
+			result = append(result, string(msg.Payload()))
+		}
+		return result
+	}
+
+	// the consumer stops after the last data message before the marker.
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	consumer.SetStopOnEndOfStream(true)
+	assert.NoError(t, consumer.Err())
+	msgs, err := consumer.ReceiveBatch(context.TODO(), 10, 10*time.Second)
+	assert.ErrorIs(t, err, ErrEndOfStream)
+	assert.Equal(t, []string{"a", "b", "c"}, payloads(msgs))
+	assert.ErrorIs(t, consumer.Err(), ErrEndOfStream)
+	_, ok := <-consumer.Chan()
+	assert.False(t, ok)
+
+	// the marker is skipped if the consumer doesn't stop on it.
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	tailingConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer tailingConsumer.Close()
+	msgs, err = tailingConsumer.ReceiveBatch(context.TODO(), 4, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, payloads(msgs))
+	assert.NoError(t, tailingConsumer.Err())
+
+	// the scan without chan stops at the marker too.
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	scanConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer scanConsumer.Close()
+	scanConsumer.SetStopOnEndOfStream(true)
+	assert.NoError(t, scanConsumer.Skip(3))
+	assert.ErrorIs(t, scanConsumer.Skip(1), ErrEndOfStream)
+}
@@ -0,0 +1,199 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+const (
+	// consumerOffsetsTopic is the internal topic where the broker keeps the committed offsets of all consumer groups.
+	consumerOffsetsTopic = "__consumer_offsets"
+	// groupOffsetsAuditGroup is the group of the consumer that reads the committed offsets, it never commits.
+	groupOffsetsAuditGroup = "milvus-group-offsets-audit"
+)
+
+// ListGroupOffsets returns the committed offsets of all consumer groups on the topic, keyed by group and then partition,
+// for auditing the progress of groups. The offset of a partition is the offset of the next message to consume.
+// The committed offsets are read from the internal __consumer_offsets topic up to its high watermarks when the call starts,
+// so the commits after the call may be missed, and the offsets deleted by the broker are not reported.
+// An error is returned if the context is done before all the commits are read.
+func (kc *kafkaClient) ListGroupOffsets(ctx context.Context, topic string) (map[string]map[int32]int64, error) {
+	return kc.listGroupOffsets(ctx, consumerOffsetsTopic, topic)
+}
+
+// listGroupOffsets reads the committed offsets of the topic from the offsets topic.
+func (kc *kafkaClient) listGroupOffsets(ctx context.Context, offsetsTopic string, topic string) (map[string]map[int32]int64, error) {
+	c, err := kafka.NewConsumer(kc.newConsumerConfig(groupOffsetsAuditGroup, common.SubscriptionPositionEarliest))
+	if err != nil {
+		return nil, errors.Wrap(err, "create kafka consumer to read group offsets")
+	}
+	defer c.Close()
+
+	metadata, err := c.GetMetadata(&offsetsTopic, false, timeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get metadata of topic %s", offsetsTopic)
+	}
+	topicMeta, ok := metadata.Topics[offsetsTopic]
+	if !ok || topicMeta.Error.Code() != kafka.ErrNoError {
+		return nil, errors.Newf("topic %s of group offsets is not found: %s", offsetsTopic, topicMeta.Error)
+	}
+
+	// bound the read of each partition by its high watermark, so the read always finishes however many groups commit.
+	remaining := make(map[int32]kafka.Offset, len(topicMeta.Partitions))
+	assignment := make([]kafka.TopicPartition, 0, len(topicMeta.Partitions))
+	for _, partition := range topicMeta.Partitions {
+		low, high, err := c.QueryWatermarkOffsets(offsetsTopic, partition.ID, timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "query watermarks of partition %d of topic %s", partition.ID, offsetsTopic)
+		}
+		if high <= low {
+			continue
+		}
+		remaining[partition.ID] = kafka.Offset(high)
+		assignment = append(assignment, kafka.TopicPartition{Topic: &offsetsTopic, Partition: partition.ID, Offset: kafka.Offset(low)})
+	}
+	if err := c.Assign(assignment); err != nil {
+		return nil, errors.Wrapf(err, "assign partitions of topic %s", offsetsTopic)
+	}
+
+	offsets := make(map[string]map[int32]int64)
+	readTimeout := paramtable.Get().KafkaCfg.ReadTimeout.GetAsDuration(time.Second)
+	read := 0
+	for len(remaining) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrapf(err, "list group offsets of topic %s stopped after %d commits", topic, read)
+		}
+		msg, err := c.ReadMessage(readTimeout)
+		if err != nil {
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrTimedOut {
+				continue
+			}
+			return nil, errors.Wrapf(err, "read group offsets of topic %s", topic)
+		}
+		partition := msg.TopicPartition.Partition
+		if high, ok := remaining[partition]; ok && msg.TopicPartition.Offset+1 >= high {
+			delete(remaining, partition)
+		}
+		read++
+
+		commit, ok, err := decodeOffsetCommit(msg.Key, msg.Value)
+		if err != nil {
+			log.Warn("skip malformed record of group offsets", zap.String("topic", offsetsTopic),
+				zap.Int32("partition", partition), zap.Any("offset", msg.TopicPartition.Offset), zap.Error(err))
+			continue
+		}
+		if !ok || commit.topic != topic {
+			continue
+		}
+		if commit.deleted {
+			delete(offsets[commit.group], commit.partition)
+			if len(offsets[commit.group]) == 0 {
+				delete(offsets, commit.group)
+			}
+			continue
+		}
+		if offsets[commit.group] == nil {
+			offsets[commit.group] = make(map[int32]int64)
+		}
+		offsets[commit.group][commit.partition] = commit.offset
+	}
+	log.Info("list group offsets", zap.String("topic", topic), zap.Int("groups", len(offsets)), zap.Int("commits", read))
+	return offsets, nil
+}
+
+// offsetCommit is the committed offset of a group on a partition decoded from the offsets topic.
+type offsetCommit struct {
+	group     string
+	topic     string
+	partition int32
+	offset    int64
+	deleted   bool // the commit is a tombstone, the offset is deleted.
+}
+
+// decodeOffsetCommit decodes the record of offsets topic, false if it's not an offset commit, e.g. the group metadata.
+// The key of an offset commit is `version(int16), group(string), topic(string), partition(int32)` with version 0 or 1,
+// and the value of every version starts with `version(int16), offset(int64)`, a nil value is a tombstone.
+func decodeOffsetCommit(key, value []byte) (offsetCommit, bool, error) {
+	r := &recordReader{buf: key}
+	version := r.int16()
+	if r.err == nil && version != 0 && version != 1 {
+		return offsetCommit{}, false, nil
+	}
+	commit := offsetCommit{
+		group:     r.string(),
+		topic:     r.string(),
+		partition: r.int32(),
+	}
+	if r.err != nil {
+		return offsetCommit{}, false, errors.Wrap(r.err, "decode key of offset commit")
+	}
+	if value == nil {
+		commit.deleted = true
+		return commit, true, nil
+	}
+	r = &recordReader{buf: value}
+	r.int16()
+	commit.offset = r.int64()
+	if r.err != nil {
+		return offsetCommit{}, false, errors.Wrap(r.err, "decode value of offset commit")
+	}
+	return commit, true, nil
+}
+
+// recordReader reads the big-endian fields of the records of offsets topic, the first error is kept.
+type recordReader struct {
+	buf []byte
+	err error
+}
+
+func (r *recordReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = errors.Newf("record is truncated, %d bytes expected but %d left", n, len(r.buf))
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *recordReader) int16() int16 {
+	if b := r.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *recordReader) int32() int32 {
+	if b := r.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *recordReader) int64() int64 {
+	if b := r.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *recordReader) string() string {
+	n := r.int16()
+	if r.err == nil && n < 0 {
+		r.err = errors.Newf("invalid string length %d of record", n)
+	}
+	return string(r.next(int(n)))
+}
@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeOffsetCommit encodes the offset commit into the record of offsets topic, a negative offset is encoded as a tombstone.
+func encodeOffsetCommit(group, topic string, partition int32, offset int64) (key []byte, value []byte) {
+	appendString := func(b []byte, s string) []byte {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+		return append(b, s...)
+	}
+	key = binary.BigEndian.AppendUint16(nil, 1)
+	key = appendString(key, group)
+	key = appendString(key, topic)
+	key = binary.BigEndian.AppendUint32(key, uint32(partition))
+	if offset < 0 {
+		return key, nil
+	}
+	// the value of version 3 with the leader epoch, metadata and commit timestamp.
+	value = binary.BigEndian.AppendUint16(nil, 3)
+	value = binary.BigEndian.AppendUint64(value, uint64(offset))
+	value = binary.BigEndian.AppendUint32(value, 0)
+	value = appendString(value, "")
+	value = binary.BigEndian.AppendUint64(value, uint64(time.Now().UnixMilli()))
+	return key, value
+}
+
+func TestKafkaClient_ListGroupOffsets(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	// the mock cluster keeps the committed offsets in memory, so the records of offsets topic are produced into a normal topic.
+	offsetsTopic := fmt.Sprintf("test-offsets-%d", rand.Int())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	groupMetadataKey := binary.BigEndian.AppendUint16(nil, 2)
+	groupMetadataKey = binary.BigEndian.AppendUint16(groupMetadataKey, 7)
+	groupMetadataKey = append(groupMetadataKey, "group-a"...)
+
+	records := [][2][]byte{{groupMetadataKey, []byte("group metadata")}}
+	for _, commit := range []struct {
+		group     string
+		topic     string
+		partition int32
+		offset    int64
+	}{
+		{"group-a", topic, 0, 3},
+		{"group-b", topic, 0, 7},
+		{"group-a", topic, 0, 5}, // the later commit overrides the earlier one.
+		{"group-a", "other-topic", 0, 9},
+		{"group-c", topic, 0, 1},
+		{"group-c", topic, 0, -1}, // the offset of group-c is deleted.
+	} {
+		key, value := encodeOffsetCommit(commit.group, commit.topic, commit.partition, commit.offset)
+		records = append(records, [2][]byte{key, value})
+	}
+	// a truncated record is skipped.
+	records = append(records, [2][]byte{{0, 1, 0}, nil})
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": getKafkaBrokerList()})
+	assert.NoError(t, err)
+	defer producer.Close()
+	delivery := make(chan kafka.Event, len(records))
+	for _, record := range records {
+		err := producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &offsetsTopic, Partition: kafka.PartitionAny},
+			Key:            record[0],
+			Value:          record[1],
+		}, delivery)
+		assert.NoError(t, err)
+	}
+	for range records {
+		assert.NoError(t, (<-delivery).(*kafka.Message).TopicPartition.Error)
+	}
+
+	offsets, err := kc.listGroupOffsets(context.Background(), offsetsTopic, topic)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[int32]int64{
+		"group-a": {0: 5},
+		"group-b": {0: 7},
+	}, offsets)
+
+	offsets, err = kc.listGroupOffsets(context.Background(), offsetsTopic, "other-topic")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[int32]int64{"group-a": {0: 9}}, offsets)
+
+	// the read stops once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = kc.listGroupOffsets(ctx, offsetsTopic, topic)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/milvus/pkg/v2/common"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+)
+
+// inflightLimiter bounds the messages of a producer that are produced but not confirmed by the delivery report yet,
+// so the memory held by the local queue doesn't grow without bound when the broker is slow.
+// Each in-flight message holds a slot of the limiter until its delivery report arrives.
+type inflightLimiter struct {
+	topic string
+	slots chan struct{}
+}
+
+// newInflightLimiter creates a new limiter of the topic, nil is returned if the limit is not positive, which means unlimited.
+func newInflightLimiter(topic string, limit int) *inflightLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &inflightLimiter{
+		topic: topic,
+		slots: make(chan struct{}, limit),
+	}
+}
+
+// Acquire blocks until a slot is available, the context is done or the producer is closed.
+func (l *inflightLimiter) Acquire(ctx context.Context, stopCh <-chan struct{}) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		metrics.MsgStreamProduceInflightMessages.WithLabelValues(l.topic).Inc()
+		return nil
+	case <-ctx.Done():
+		return errors.Mark(errors.Wrapf(ctx.Err(), "context done while waiting for the in-flight messages of topic %s, limit %d", l.topic, cap(l.slots)), ctx.Err())
+	case <-stopCh:
+		return common.NewIgnorableError(errors.New("kafka producer is closed"))
+	}
+}
+
+// Release releases the slot of a message once its delivery report arrives, or it's never produced.
+func (l *inflightLimiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+	metrics.MsgStreamProduceInflightMessages.WithLabelValues(l.topic).Dec()
+}
+
+// Len returns the number of in-flight messages.
+func (l *inflightLimiter) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.slots)
+}
@@ -3,38 +3,57 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
-	"go.uber.org/atomic"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 
 	"github.com/milvus-io/milvus/pkg/v2/log"
 	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	"github.com/milvus-io/milvus/pkg/v2/mq/common"
 	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
-	"github.com/milvus-io/milvus/pkg/v2/util/conc"
 	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+	"github.com/milvus-io/milvus/pkg/v2/util/retry"
 	"github.com/milvus-io/milvus/pkg/v2/util/timerecord"
 )
 
-var (
-	producer atomic.Pointer[kafka.Producer]
-	sf       conc.Singleflight[*kafka.Producer]
+const (
+	defaultProducerKey  = "kafka_producer"
+	saslMechanismGSSAPI = "GSSAPI"
 )
 
-var once sync.Once
+var (
+	// producers are shared by all kafka clients, keyed by the producer config overrides,
+	// the producer is closed once its last user releases it.
+	producersMu sync.Mutex
+	producers   = make(map[string]*sharedKafkaProducer)
+
+	// producerInitOnce records the duration of the first producer creation, which is the cold start latency.
+	producerInitOnce sync.Once
+)
 
 type kafkaClient struct {
 	// more configs you can see https://github.com/edenhill/librdkafka/blob/master/CONFIGURATION.md
 	basicConfig    kafka.ConfigMap
 	consumerConfig kafka.ConfigMap
 	producerConfig kafka.ConfigMap
+
+	mu                  sync.Mutex
+	topicProducers      map[string]*kafkaProducer  // the reference counted producers keyed by topic and options.
+	topicProducerConfig map[string]kafka.ConfigMap // the producer config overrides of each topic.
+
+	consumerFactory consumerFactory // create the consumer of Subscribe, replaced in test.
 }
 
+// consumerFactory creates a kafka consumer.
+type consumerFactory func(config *kafka.ConfigMap, bufSize int64, topic string, groupID string, position common.SubscriptionInitialPosition) (*Consumer, error)
+
 func getBasicConfig(address string) kafka.ConfigMap {
 	return kafka.ConfigMap{
 		"bootstrap.servers":        address,
@@ -71,7 +90,15 @@ func NewKafkaClientInstanceWithConfigMap(config kafka.ConfigMap, extraConsumerCo
 		zap.String("extraConsumerConfig", ConfigtoString(extraConsumerConfig)),
 		zap.String("extraProducerConfig", ConfigtoString(extraProducerConfig)),
 	)
-	return &kafkaClient{basicConfig: config, consumerConfig: extraConsumerConfig, producerConfig: extraProducerConfig}
+	return &kafkaClient{
+		basicConfig:     config,
+		consumerConfig:  extraConsumerConfig,
+		producerConfig:  extraProducerConfig,
+		topicProducers:  make(map[string]*kafkaProducer),
+		consumerFactory: newKafkaConsumer,
+
+		topicProducerConfig: make(map[string]kafka.ConfigMap),
+	}
 }
 
 func GetBasicConfig(config *paramtable.KafkaConfig) kafka.ConfigMap {
@@ -92,10 +119,22 @@ func GetBasicConfig(config *paramtable.KafkaConfig) kafka.ConfigMap {
 		kafkaConfig.SetKey("sasl.password", config.SaslPassword.GetValue())
 	}
 
+	if isGSSAPI(config) {
+		kafkaConfig.SetKey("sasl.mechanisms", saslMechanismGSSAPI)
+		kafkaConfig.SetKey("sasl.kerberos.service.name", config.SaslKerberosServiceName.GetValue())
+		kafkaConfig.SetKey("sasl.kerberos.keytab", config.SaslKerberosKeytab.GetValue())
+		kafkaConfig.SetKey("sasl.kerberos.principal", config.SaslKerberosPrincipal.GetValue())
+	}
+
 	if config.KafkaUseSSL.GetAsBool() {
-		kafkaConfig.SetKey("ssl.certificate.location", config.KafkaTLSCert.GetValue())
-		kafkaConfig.SetKey("ssl.key.location", config.KafkaTLSKey.GetValue())
-		kafkaConfig.SetKey("ssl.ca.location", config.KafkaTLSCACert.GetValue())
+		// the in-memory PEM form takes the place of the file form if it's given.
+		for _, credential := range sslCredentials(config) {
+			if credential.pem != "" {
+				kafkaConfig.SetKey(credential.pemKey, credential.pem)
+			} else {
+				kafkaConfig.SetKey(credential.locationKey, credential.location)
+			}
+		}
 		if config.KafkaTLSKeyPassword.GetValue() != "" {
 			kafkaConfig.SetKey("ssl.key.password", config.KafkaTLSKeyPassword.GetValue())
 		}
@@ -114,7 +153,22 @@ func NewKafkaClientInstanceWithConfig(ctx context.Context, config *paramtable.Ka
 		// kafkaConfig.SetKey("socket.connection.setup.timeout.ms", strconv.FormatInt(timeout, 10))
 	}
 
+	basicConfig, consumerConfig, producerConfig, err := getConfigMaps(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewKafkaClientInstanceWithConfigMap(basicConfig, consumerConfig, producerConfig), nil
+}
+
+// getConfigMaps validates the kafka config and returns the basic config, the extra consumer config and the extra producer config of it.
+func getConfigMaps(config *paramtable.KafkaConfig) (kafka.ConfigMap, kafka.ConfigMap, kafka.ConfigMap, error) {
 	kafkaConfig := GetBasicConfig(config)
+	if err := validateGSSAPIConfig(config); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := validateSSLConfig(config); err != nil {
+		return nil, nil, nil, err
+	}
 	specExtraConfig := func(config map[string]string) kafka.ConfigMap {
 		kafkaConfigMap := make(kafka.ConfigMap, len(config))
 		for k, v := range config {
@@ -122,11 +176,63 @@ func NewKafkaClientInstanceWithConfig(ctx context.Context, config *paramtable.Ka
 		}
 		return kafkaConfigMap
 	}
+	return kafkaConfig, specExtraConfig(config.ConsumerExtraConfig.GetValue()), specExtraConfig(config.ProducerExtraConfig.GetValue()), nil
+}
+
+// isGSSAPI returns true if the kerberos authentication is selected.
+func isGSSAPI(config *paramtable.KafkaConfig) bool {
+	return strings.EqualFold(config.SaslMechanisms.GetValue(), saslMechanismGSSAPI)
+}
+
+// validateGSSAPIConfig checks that both keytab and principal are given when the kerberos authentication is selected.
+func validateGSSAPIConfig(config *paramtable.KafkaConfig) error {
+	if !isGSSAPI(config) {
+		return nil
+	}
+	if config.SaslKerberosKeytab.GetValue() == "" {
+		return errors.Newf("kerberos keytab is required when sasl mechanism is %s", saslMechanismGSSAPI)
+	}
+	if config.SaslKerberosPrincipal.GetValue() == "" {
+		return errors.Newf("kerberos principal is required when sasl mechanism is %s", saslMechanismGSSAPI)
+	}
+	return nil
+}
+
+// sslCredential is a credential of ssl, which is given by either a file location or the in-memory PEM material.
+type sslCredential struct {
+	name        string
+	locationKey string
+	location    string
+	pemKey      string
+	pem         string
+}
+
+// sslCredentials returns the certificate, key and CA certificate of ssl in order.
+func sslCredentials(config *paramtable.KafkaConfig) []sslCredential {
+	return []sslCredential{
+		{name: "certificate", locationKey: "ssl.certificate.location", location: config.KafkaTLSCert.GetValue(), pemKey: "ssl.certificate.pem", pem: config.KafkaTLSCertPem.GetValue()},
+		{name: "key", locationKey: "ssl.key.location", location: config.KafkaTLSKey.GetValue(), pemKey: "ssl.key.pem", pem: config.KafkaTLSKeyPem.GetValue()},
+		{name: "CA certificate", locationKey: "ssl.ca.location", location: config.KafkaTLSCACert.GetValue(), pemKey: "ssl.ca.pem", pem: config.KafkaTLSCACertPem.GetValue()},
+	}
+}
 
-	return NewKafkaClientInstanceWithConfigMap(
-		kafkaConfig,
-		specExtraConfig(config.ConsumerExtraConfig.GetValue()),
-		specExtraConfig(config.ProducerExtraConfig.GetValue())), nil
+// validateSSLConfig checks that each ssl credential is given by only one of the file and PEM forms,
+// and the client certificate and key are given together.
+func validateSSLConfig(config *paramtable.KafkaConfig) error {
+	if !config.KafkaUseSSL.GetAsBool() {
+		return nil
+	}
+	credentials := sslCredentials(config)
+	for _, credential := range credentials {
+		if credential.location != "" && credential.pem != "" {
+			return errors.Newf("ssl %s is given by both file and PEM, only one of them is allowed", credential.name)
+		}
+	}
+	certificate, key := credentials[0], credentials[1]
+	if (certificate.location == "" && certificate.pem == "") != (key.location == "" && key.pem == "") {
+		return errors.New("ssl certificate and key of client should be given together")
+	}
+	return nil
 }
 
 func cloneKafkaConfig(config kafka.ConfigMap) *kafka.ConfigMap {
@@ -137,58 +243,216 @@ func cloneKafkaConfig(config kafka.ConfigMap) *kafka.ConfigMap {
 	return &newConfig
 }
 
-func (kc *kafkaClient) getKafkaProducer() (*kafka.Producer, error) {
-	if p := producer.Load(); p != nil {
-		return p, nil
+// producerOverrides returns the producer level config overrides of the producer options.
+func producerOverrides(options common.ProducerOptions) kafka.ConfigMap {
+	overrides := kafka.ConfigMap{}
+	if options.LingerMs > 0 {
+		overrides.SetKey("linger.ms", options.LingerMs)
 	}
-	log := log.Ctx(context.TODO())
-	p, err, _ := sf.Do("kafka_producer", func() (*kafka.Producer, error) {
-		if p := producer.Load(); p != nil {
-			return p, nil
+	switch options.Durability {
+	case common.DurabilityAtMostOnce:
+		overrides.SetKey("acks", 0)
+		overrides.SetKey("enable.idempotence", false)
+		overrides.SetKey("retries", 0)
+		// the delivery is not waited, so the delivery report is useless.
+		overrides.SetKey("go.delivery.reports", false)
+	case common.DurabilityExactlyOnce:
+		overrides.SetKey("acks", "all")
+		overrides.SetKey("enable.idempotence", true)
+	}
+	// at-least-once uses the default config of producer, which is acks=all with retries.
+	return overrides
+}
+
+// SetTopicProducerConfig sets the producer config overrides of the topic, e.g. the compression of a large payload topic,
+// which are applied to the producers of the topic created or rotated afterwards.
+// The precedence of the producer config is: the overrides of producer options, such as linger and durability,
+// then the per-topic overrides, then the extra producer config of client, and the defaults at last.
+// The producers of the topics with the same overrides share the underlying producer.
+func (kc *kafkaClient) SetTopicProducerConfig(topic string, config kafka.ConfigMap) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if len(config) == 0 {
+		delete(kc.topicProducerConfig, topic)
+		return
+	}
+	kc.topicProducerConfig[topic] = *cloneKafkaConfig(config)
+}
+
+// topicProducerOverrides merges the producer level overrides into the per-topic overrides of the topic,
+// the producer level overrides take precedence.
+func (kc *kafkaClient) topicProducerOverrides(topic string, overrides kafka.ConfigMap) kafka.ConfigMap {
+	kc.mu.Lock()
+	topicConfig, ok := kc.topicProducerConfig[topic]
+	kc.mu.Unlock()
+	if !ok {
+		return overrides
+	}
+	merged := cloneKafkaConfig(topicConfig)
+	kc.specialExtraConfig(merged, overrides)
+	return *merged
+}
+
+// validateDurability checks whether the durability mode conflicts with the extra producer config.
+func (kc *kafkaClient) validateDurability(mode common.DurabilityMode) error {
+	extraConfig := func(key string) (string, bool) {
+		v, ok := kc.producerConfig[key]
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprint(v), true
+	}
+	switch mode {
+	case common.DurabilityAtLeastOnce:
+	case common.DurabilityAtMostOnce:
+		if idempotence, ok := extraConfig("enable.idempotence"); ok && idempotence == "true" {
+			return errors.Newf("durability %s conflicts with producer config enable.idempotence=%s", mode, idempotence)
 		}
-		config := kc.newProducerConfig()
-		p, err := kafka.NewProducer(config)
-		if err != nil {
-			log.Error("create sync kafka producer failed", zap.Error(err))
-			return nil, err
+	case common.DurabilityExactlyOnce:
+		if acks, ok := extraConfig("acks"); ok && acks != "all" && acks != "-1" {
+			return errors.Newf("durability %s conflicts with producer config acks=%s", mode, acks)
+		}
+		if retries, ok := extraConfig("retries"); ok && retries == "0" {
+			return errors.Newf("durability %s conflicts with producer config retries=%s", mode, retries)
+		}
+		// the idempotent producer requires at most 5 in-flight requests.
+		if inflight, ok := extraConfig("max.in.flight.requests.per.connection"); ok {
+			if n, err := strconv.Atoi(inflight); err != nil || n > 5 {
+				return errors.Newf("durability %s conflicts with producer config max.in.flight.requests.per.connection=%s", mode, inflight)
+			}
 		}
-		go func() {
-			for e := range p.Events() {
-				switch ev := e.(type) {
-				case kafka.Error:
-					// Generic client instance-level errors, such as broker connection failures,
-					// authentication issues, etc.
-					// After a fatal error has been raised, any subsequent Produce*() calls will fail with
-					// the original error code.
-					log.Error("kafka error", zap.String("error msg", ev.Error()))
-					if ev.IsFatal() {
-						panic(ev)
-					}
-				default:
+	default:
+		return errors.Newf("unknown durability mode %s", mode)
+	}
+	return nil
+}
+
+// producerKey returns the key of the shared kafka producer with the given overrides.
+func producerKey(overrides kafka.ConfigMap) string {
+	if len(overrides) == 0 {
+		return defaultProducerKey
+	}
+	keys := lo.Keys(overrides)
+	sort.Strings(keys)
+	key := defaultProducerKey
+	for _, k := range keys {
+		key += fmt.Sprintf(",%s=%v", k, overrides[k])
+	}
+	return key
+}
+
+// sharedKafkaProducer is the underlying producer shared by the users of the same config overrides.
+type sharedKafkaProducer struct {
+	p      *kafka.Producer
+	refCnt int // protected by producersMu.
+}
+
+// acquireKafkaProducer returns the underlying producer of the overrides and its key, a new one is created if there's none.
+// The producer should be released by releaseKafkaProducer with the key once it's not used.
+func (kc *kafkaClient) acquireKafkaProducer(overrides kafka.ConfigMap) (*kafka.Producer, string, error) {
+	key := producerKey(overrides)
+	producersMu.Lock()
+	defer producersMu.Unlock()
+	if shared, ok := producers[key]; ok {
+		shared.refCnt++
+		return shared.p, key, nil
+	}
+
+	log := log.Ctx(context.TODO())
+	start := time.Now()
+	config := kc.newProducerConfig(overrides)
+	p, err := kafka.NewProducer(config)
+	if err != nil {
+		log.Error("create sync kafka producer failed", zap.Error(err))
+		return nil, "", err
+	}
+	producerInitOnce.Do(func() {
+		elapsed := time.Since(start)
+		metrics.MsgStreamProducerInitDuration.Set(float64(elapsed) / float64(time.Millisecond))
+		log.Info("first kafka producer is created", zap.Duration("elapsed", elapsed))
+	})
+	go func() {
+		for e := range p.Events() {
+			switch ev := e.(type) {
+			case kafka.Error:
+				// Generic client instance-level errors, such as broker connection failures,
+				// authentication issues, etc.
+				// After a fatal error has been raised, any subsequent Produce*() calls will fail with
+				// the original error code.
+				log.Error("kafka error", zap.String("error msg", ev.Error()))
+				if ev.IsFatal() {
+					panic(ev)
+				}
+			case *kafka.Message:
+				// the delivery report of the message sent by SendAsync.
+				if !dispatchDelivery(ev) {
 					log.Debug("kafka producer event", zap.Any("event", ev))
 				}
+			default:
+				log.Debug("kafka producer event", zap.Any("event", ev))
 			}
-		}()
-		producer.Store(p)
-		return p, nil
-	})
-	if err != nil {
-		return nil, err
+		}
+	}()
+	producers[key] = &sharedKafkaProducer{p: p, refCnt: 1}
+	return p, key, nil
+}
+
+// retainKafkaProducer acquires one more reference of the underlying producer acquired with the key,
+// false is returned if the producer is not shared.
+func retainKafkaProducer(key string, p *kafka.Producer) bool {
+	producersMu.Lock()
+	defer producersMu.Unlock()
+	shared, ok := producers[key]
+	if !ok || shared.p != p {
+		return false
 	}
-	return p, nil
+	shared.refCnt++
+	return true
 }
 
-func (kc *kafkaClient) newProducerConfig() *kafka.ConfigMap {
+// releaseKafkaProducer releases one reference of the underlying producer acquired with the key,
+// the producer is flushed and closed once its last reference is released.
+func releaseKafkaProducer(key string, p *kafka.Producer) {
+	producersMu.Lock()
+	shared, ok := producers[key]
+	if !ok || shared.p != p {
+		producersMu.Unlock()
+		return
+	}
+	shared.refCnt--
+	if shared.refCnt > 0 {
+		producersMu.Unlock()
+		return
+	}
+	delete(producers, key)
+	producersMu.Unlock()
+
+	if remain := p.Flush(10000); remain > 0 {
+		log.Warn("There are still un-flushed outstanding events before the kafka producer is closed", zap.Int("event_num", remain), zap.String("key", key))
+	}
+	p.Close()
+	log.Info("kafka producer is closed since it's not used", zap.String("key", key))
+}
+
+func (kc *kafkaClient) newProducerConfig(overrides kafka.ConfigMap) *kafka.ConfigMap {
 	newConf := cloneKafkaConfig(kc.basicConfig)
 	// default max message size 5M
 	newConf.SetKey("message.max.bytes", 10485760)
 	newConf.SetKey("compression.codec", "zstd")
-	// we want to ensure tt send out as soon as possible
-	newConf.SetKey("linger.ms", 2)
+	// we want to ensure tt send out as soon as possible by default
+	newConf.SetKey("linger.ms", paramtable.Get().KafkaCfg.ProducerLingerMs.GetAsInt())
+	setNonNegativeConfig(newConf, "connections.max.idle.ms", &paramtable.Get().KafkaCfg.ConnectionsMaxIdleMs)
+	setPositiveConfig(newConf, "socket.connection.setup.timeout.ms", &paramtable.Get().KafkaCfg.ConnectionSetupTimeoutMs)
+	newConf.SetKey("socket.keepalive.enable", paramtable.Get().KafkaCfg.SocketKeepaliveEnable.GetAsBool())
+	newConf.SetKey("socket.nagle.disable", paramtable.Get().KafkaCfg.SocketNagleDisable.GetAsBool())
 
 	// special producer config
 	kc.specialExtraConfig(newConf, kc.producerConfig)
 
+	// producer level overrides take precedence over the client config.
+	for k, v := range overrides {
+		newConf.SetKey(k, v)
+	}
 	return newConf
 }
 
@@ -201,16 +465,62 @@ func (kc *kafkaClient) newConsumerConfig(group string, offset common.Subscriptio
 	// In order to compatible with other MQ, we need to enable the following configuration,
 	// meanwhile, some implementation also try to consume a non-exist topic, such as dataCoordTimeTick.
 	newConf.SetKey("allow.auto.create.topics", true)
+	// trade latency for fewer fetch requests on low-traffic channels.
+	setNonNegativeConfig(newConf, "fetch.wait.max.ms", &paramtable.Get().KafkaCfg.ConsumerFetchWaitMaxMs)
+	setNonNegativeConfig(newConf, "fetch.min.bytes", &paramtable.Get().KafkaCfg.ConsumerFetchMinBytes)
+	// fetch from the closest replica, the broker falls back to the leader if it's not rack-aware.
+	if rack := paramtable.Get().KafkaCfg.ConsumerClientRack.GetValue(); rack != "" {
+		newConf.SetKey("client.rack", rack)
+	}
+	setNonNegativeConfig(newConf, "connections.max.idle.ms", &paramtable.Get().KafkaCfg.ConnectionsMaxIdleMs)
+	setPositiveConfig(newConf, "socket.connection.setup.timeout.ms", &paramtable.Get().KafkaCfg.ConnectionSetupTimeoutMs)
+	newConf.SetKey("socket.keepalive.enable", paramtable.Get().KafkaCfg.SocketKeepaliveEnable.GetAsBool())
+	newConf.SetKey("socket.nagle.disable", paramtable.Get().KafkaCfg.SocketNagleDisable.GetAsBool())
+	// the statistics carry the throttle time by each broker.
+	setNonNegativeConfig(newConf, "statistics.interval.ms", &paramtable.Get().KafkaCfg.ConsumerStatsIntervalMs)
 	kc.specialExtraConfig(newConf, kc.consumerConfig)
 
 	return newConf
 }
 
+// setNonNegativeConfig sets the config from the param item, the negative value is ignored and the default of kafka is used.
+func setNonNegativeConfig(config *kafka.ConfigMap, key string, item *paramtable.ParamItem) {
+	v := item.GetAsInt()
+	if v < 0 {
+		log.Warn("ignore the negative kafka config", zap.String("param", item.Key), zap.Int("value", v))
+		return
+	}
+	config.SetKey(key, v)
+}
+
+// setPositiveConfig sets the config from the param item, the non-positive value is ignored and the default of kafka is used.
+func setPositiveConfig(config *kafka.ConfigMap, key string, item *paramtable.ParamItem) {
+	v := item.GetAsInt()
+	if v <= 0 {
+		log.Warn("ignore the non-positive kafka config", zap.String("param", item.Key), zap.Int("value", v))
+		return
+	}
+	config.SetKey(key, v)
+}
+
 func (kc *kafkaClient) CreateProducer(ctx context.Context, options common.ProducerOptions) (mqwrapper.Producer, error) {
 	start := timerecord.NewTimeRecorder("create producer")
 	metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateProducerLabel, metrics.TotalLabel).Inc()
 
-	pp, err := kc.getKafkaProducer()
+	if err := kc.validateDurability(options.Durability); err != nil {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateProducerLabel, metrics.FailLabel).Inc()
+		return nil, err
+	}
+	if options.MaxInflightMessages > 0 && options.Durability == common.DurabilityAtMostOnce {
+		// the in-flight messages are released by the delivery reports, which are disabled in at-most-once mode.
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateProducerLabel, metrics.FailLabel).Inc()
+		return nil, errors.Newf("in-flight limit of producer conflicts with durability %s", options.Durability)
+	}
+	overrides := kc.topicProducerOverrides(options.Topic, producerOverrides(options))
+	// the producers of different queue full behavior share the underlying producer, but not the delivery channel.
+	cacheKey := fmt.Sprintf("%s/%s/%s/%s/%s/%d/%t", options.Topic, producerKey(overrides), options.QueueFullPolicy, options.QueueFullBlockTimeout, options.SchemaVersion, max(options.MaxInflightMessages, 0), options.Checksum)
+
+	producer, err := kc.acquireProducer(cacheKey, overrides, options)
 	if err != nil {
 		metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateProducerLabel, metrics.FailLabel).Inc()
 		return nil, err
@@ -219,27 +529,125 @@ func (kc *kafkaClient) CreateProducer(ctx context.Context, options common.Produc
 	elapsed := start.ElapseSpan()
 	metrics.MsgStreamRequestLatency.WithLabelValues(metrics.CreateProducerLabel).Observe(float64(elapsed.Milliseconds()))
 	metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateProducerLabel, metrics.SuccessLabel).Inc()
+	return producer, nil
+}
 
-	producer := &kafkaProducer{p: pp, stopCh: make(chan struct{}), topic: options.Topic}
+// acquireProducer returns the cached producer of the same topic and options with one more reference,
+// a new one is created if there's none, so the repeated CreateProducer calls don't leak the producers.
+func (kc *kafkaClient) acquireProducer(cacheKey string, overrides kafka.ConfigMap, options common.ProducerOptions) (*kafkaProducer, error) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if producer, ok := kc.topicProducers[cacheKey]; ok {
+		producer.refCnt++
+		return producer, nil
+	}
+
+	pp, ppKey, err := kc.acquireKafkaProducer(overrides)
+	if err != nil {
+		return nil, err
+	}
+	producer := &kafkaProducer{
+		p:          pp,
+		pKey:       ppKey,
+		stopCh:     make(chan struct{}),
+		topic:      options.Topic,
+		client:     kc,
+		cacheKey:   cacheKey,
+		refCnt:     1,
+		options:    options,
+		durability: options.Durability,
+
+		queueFullPolicy:       options.QueueFullPolicy,
+		queueFullBlockTimeout: options.QueueFullBlockTimeout,
+		schemaVersion:         options.SchemaVersion,
+		checksum:              options.Checksum,
+		inflight:              newInflightLimiter(options.Topic, options.MaxInflightMessages),
+	}
+	kc.topicProducers[cacheKey] = producer
 	return producer, nil
 }
 
+// releaseProducer releases one reference of the producer,
+// returns true if the last reference is released and the producer should be closed.
+func (kc *kafkaClient) releaseProducer(producer *kafkaProducer) bool {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	if producer.refCnt > 0 {
+		producer.refCnt--
+	}
+	if producer.refCnt > 0 {
+		return false
+	}
+	if kc.topicProducers[producer.cacheKey] == producer {
+		delete(kc.topicProducers, producer.cacheKey)
+	}
+	return true
+}
+
 func (kc *kafkaClient) Subscribe(ctx context.Context, options mqwrapper.ConsumerOptions) (mqwrapper.Consumer, error) {
 	start := timerecord.NewTimeRecorder("create consumer")
 	metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateConsumerLabel, metrics.TotalLabel).Inc()
 
 	config := kc.newConsumerConfig(options.SubscriptionName, options.SubscriptionInitialPosition)
-	consumer, err := newKafkaConsumer(config, options.BufSize, options.Topic, options.SubscriptionName, options.SubscriptionInitialPosition)
+	consumerConfig := config
+	if options.CatchUp {
+		consumerConfig = newCatchUpConsumerConfig(config)
+	}
+	var consumer *Consumer
+	err := retry.Do(ctx, func() error {
+		var err error
+		consumer, err = kc.consumerFactory(consumerConfig, options.BufSize, options.Topic, options.SubscriptionName, options.SubscriptionInitialPosition)
+		return err
+	},
+		retry.Attempts(uint(max(paramtable.Get().KafkaCfg.SubscribeRetryAttempts.GetAsInt(), 1))),
+		retry.Sleep(paramtable.Get().KafkaCfg.SubscribeRetryBackoffMs.GetAsDuration(time.Millisecond)),
+		retry.RetryErr(isRetryableSubscribeError),
+	)
 	if err != nil {
 		metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateConsumerLabel, metrics.FailLabel).Inc()
 		return nil, err
 	}
+	if options.CatchUp {
+		consumer.enableCatchUp(config)
+	}
 	elapsed := start.ElapseSpan()
 	metrics.MsgStreamRequestLatency.WithLabelValues(metrics.CreateConsumerLabel).Observe(float64(elapsed.Milliseconds()))
 	metrics.MsgStreamOpCounter.WithLabelValues(metrics.CreateConsumerLabel, metrics.SuccessLabel).Inc()
 	return consumer, nil
 }
 
+// isRetryableSubscribeError returns true if the subscribe error is transient,
+// e.g. the metadata is not ready because the leader election is in progress.
+// Other errors such as authentication failure or invalid topic fail fast.
+func isRetryableSubscribeError(err error) bool {
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) {
+		return false
+	}
+	if kafkaErr.IsFatal() {
+		return false
+	}
+	if kafkaErr.IsRetriable() {
+		return true
+	}
+	switch kafkaErr.Code() {
+	case kafka.ErrTransport,
+		kafka.ErrAllBrokersDown,
+		kafka.ErrLeaderNotAvailable,
+		kafka.ErrNotLeaderForPartition,
+		kafka.ErrBrokerNotAvailable,
+		kafka.ErrNetworkException,
+		kafka.ErrCoordinatorLoadInProgress,
+		kafka.ErrNotCoordinator,
+		kafka.ErrTimedOut,
+		kafka.ErrRequestTimedOut:
+		return true
+	default:
+		return false
+	}
+}
+
 func (kc *kafkaClient) EarliestMessageID() common.MessageID {
 	return &KafkaID{MessageID: int64(kafka.OffsetBeginning)}
 }
@@ -1,37 +1,84 @@
 package kafka
 
 import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/samber/lo"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
 	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	"github.com/milvus-io/milvus/pkg/v2/mq/common"
 	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
 	"github.com/milvus-io/milvus/pkg/v2/util/merr"
 	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
 )
 
+const (
+	skipReasonManual         = "manual"
+	skipReasonTransformError = "transform_error"
+	skipReasonExpired        = "expired"
+	skipReasonChecksum       = "checksum_mismatch"
+	skipReasonEndOfStream    = "end_of_stream"
+	skipReasonDuplicate      = "duplicate"
+)
+
+// MessageTransform transforms the payload of the consumed message before it's returned,
+// e.g. decompress the application-level payload.
+type MessageTransform func([]byte) ([]byte, error)
+
 type Consumer struct {
+	mu         sync.RWMutex // protect c from being replaced when the consumer switches to tailing or is reconfigured.
 	c          *kafka.Consumer
 	config     *kafka.ConfigMap
 	msgChannel chan common.Message
 	hasAssign  bool
 	skipMsg    bool
+	assigned   kafka.Offset // the offset of the last assignment, the position to resume if nothing is consumed since then.
 	topic      string
 	groupID    string
 	chanOnce   sync.Once
+	started    bool // the background goroutine of Chan is started.
+	closed     bool // the consumer is closed, protected by mu so the background goroutine of Chan never starts after closing.
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 	wg         sync.WaitGroup
+	committer  *offsetCommitter // commit the acked offsets asynchronously, nil if disabled.
+	transform  MessageTransform // transform the payload of consumed message, nil means identity.
+
+	skipOnTransformError bool // skip the message that fails to transform instead of returning it.
+	skipExpired          bool // skip the message whose expiry stamped by the producer has passed.
+	verifyChecksum       bool // skip the message whose payload doesn't match the checksum stamped by the producer.
+
+	dedup *dedupFilter // skip the message whose dedup key is recently seen, nil if disabled.
+
+	manualOffsetStorage bool // nothing is committed to broker, the offsets are stored by the caller and restored by seek.
+
+	stopOnEndOfStream bool        // stop the consumption at the end-of-stream marker instead of skipping it.
+	endOfStream       atomic.Bool // the consumption is stopped by the end-of-stream marker.
+
+	gapDetection bool             // detect the offset gap between consecutive consumed messages.
+	gapHandler   OffsetGapHandler // called when an offset gap is detected, nil if not set.
+	lastOffset   kafka.Offset     // the offset of the last consumed message, used by the offset gap detection.
+
+	caughtUp chan struct{} // closed once the consumer is caught up, nil if the consumer is not subscribed with CatchUp.
+
+	throttle *throttleSignal // the broker throttling observed from the statistics.
+
+	yield func() // yield to other goroutines once the max poll records are handed over in a row.
 }
 
 const timeout = 3000
 
-func newKafkaConsumer(config *kafka.ConfigMap, bufSize int64, topic string, groupID string, position common.SubscriptionInitialPosition) (*Consumer, error) {
+func newKafkaConsumer(config *kafka.ConfigMap, bufSize int64, topic string, groupID string, position common.SubscriptionInitialPosition) (_ *Consumer, err error) {
 	msgChannel := make(chan common.Message, bufSize)
 	kc := &Consumer{
 		config:     config,
@@ -39,12 +86,26 @@ func newKafkaConsumer(config *kafka.ConfigMap, bufSize int64, topic string, grou
 		topic:      topic,
 		groupID:    groupID,
 		closeCh:    make(chan struct{}),
+		yield:      runtime.Gosched,
+		throttle:   newThrottleSignal(),
+
+		manualOffsetStorage: paramtable.Get().KafkaCfg.ConsumerManualOffsetStorage.GetAsBool(),
+	}
+	if kc.manualOffsetStorage {
+		// override the extra config, so the offsets are never committed by librdkafka, neither on poll nor on close.
+		config.SetKey("enable.auto.commit", false)
+		config.SetKey("enable.auto.offset.store", false)
 	}
 
-	err := kc.createKafkaConsumer()
-	if err != nil {
+	if err = kc.createKafkaConsumer(); err != nil {
 		return nil, err
 	}
+	defer func() {
+		// release the underlying consumer if the assignment fails, the subscription may be retried.
+		if err != nil {
+			kc.c.Close()
+		}
+	}()
 
 	// if it's unknown, we leave the assign to seek
 	if position != common.SubscriptionPositionUnknown {
@@ -86,6 +147,7 @@ func newKafkaConsumer(config *kafka.ConfigMap, bufSize int64, topic string, grou
 			log.Error("kafka consumer assign failed ", zap.String("topic name", topic), zap.Any("Msg position", position), zap.Error(err))
 			return nil, err
 		}
+		kc.assigned = offset
 		cost := time.Since(start).Milliseconds()
 		if cost > 200 {
 			log.Warn("kafka consumer assign take too long!", zap.String("topic name", topic), zap.Any("Msg position", position), zap.Int64("time cost(ms)", cost))
@@ -94,9 +156,160 @@ func newKafkaConsumer(config *kafka.ConfigMap, bufSize int64, topic string, grou
 		kc.hasAssign = true
 	}
 
+	asyncCommit := paramtable.Get().KafkaCfg.ConsumerAsyncCommitEnabled.GetAsBool()
+	if asyncCommit && kc.manualOffsetStorage {
+		log.Warn("kafka consumer async commit is ignored, the offsets are stored by the caller", zap.String("topic", topic), zap.String("groupID", groupID))
+	} else if asyncCommit {
+		kc.committer = newOffsetCommitter(topic,
+			kc.commitOffset,
+			paramtable.Get().KafkaCfg.ConsumerAsyncCommitIntervalMs.GetAsDuration(time.Millisecond),
+			paramtable.Get().KafkaCfg.ConsumerAsyncCommitBatchSize.GetAsInt(),
+		)
+	}
 	return kc, nil
 }
 
+// commitOffset commits the offset of the default partition to the broker, it's skipped if the offsets are stored by the caller.
+func (kc *Consumer) commitOffset(offset kafka.Offset) error {
+	if kc.manualOffsetStorage {
+		return nil
+	}
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	_, err := kc.c.CommitOffsets([]kafka.TopicPartition{{Topic: &kc.topic, Partition: mqwrapper.DefaultPartitionIdx, Offset: offset}})
+	return err
+}
+
+// ErrBrokerCommitDisabled is returned by CommitOffsets if the offsets are stored by the caller rather than the broker.
+var ErrBrokerCommitDisabled = errors.New("kafka broker commit is disabled, the offsets are stored by the caller")
+
+// UnassignedPartitionsError is returned by CommitOffsets, Pause and Resume if some of the given partitions are not assigned to the consumer.
+type UnassignedPartitionsError struct {
+	Topic      string
+	Partitions []int32 // the unassigned partitions in ascending order.
+}
+
+func (e *UnassignedPartitionsError) Error() string {
+	return fmt.Sprintf("partitions %v of topic %s are not assigned to the consumer", e.Partitions, e.Topic)
+}
+
+// assignedPartitions returns the assigned partitions of the topic, the caller should hold the lock.
+func (kc *Consumer) assignedPartitions() (map[int32]struct{}, error) {
+	assignment, err := kc.c.Assignment()
+	if err != nil {
+		return nil, errors.Wrapf(err, "get assignment of kafka consumer of topic %s", kc.topic)
+	}
+	assigned := make(map[int32]struct{}, len(assignment))
+	for _, tp := range assignment {
+		if tp.Topic != nil && *tp.Topic == kc.topic {
+			assigned[tp.Partition] = struct{}{}
+		}
+	}
+	return assigned, nil
+}
+
+// newUnassignedPartitionsError returns an UnassignedPartitionsError if any of the partitions is not assigned, otherwise nil.
+func (kc *Consumer) newUnassignedPartitionsError(assigned map[int32]struct{}, partitions []int32) error {
+	var unassigned []int32
+	for _, partition := range partitions {
+		if _, ok := assigned[partition]; !ok {
+			unassigned = append(unassigned, partition)
+		}
+	}
+	if len(unassigned) == 0 {
+		return nil
+	}
+	sort.Slice(unassigned, func(i, j int) bool { return unassigned[i] < unassigned[j] })
+	return &UnassignedPartitionsError{Topic: kc.topic, Partitions: lo.Uniq(unassigned)}
+}
+
+// CommitOffsets commits the offsets of the assigned partitions of the topic in a single call,
+// the offset of a partition is the offset of the next message to consume, like the offset committed by Ack.
+// An UnassignedPartitionsError is returned and nothing is committed if any of the partitions is not assigned,
+// ErrBrokerCommitDisabled is returned if the offsets are stored by the caller.
+func (kc *Consumer) CommitOffsets(offsets map[int32]int64) error {
+	if kc.manualOffsetStorage {
+		return ErrBrokerCommitDisabled
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	assigned, err := kc.assignedPartitions()
+	if err != nil {
+		return err
+	}
+	if err := kc.newUnassignedPartitionsError(assigned, lo.Keys(offsets)); err != nil {
+		return err
+	}
+
+	partitions := make([]kafka.TopicPartition, 0, len(offsets))
+	for partition, offset := range offsets {
+		partitions = append(partitions, kafka.TopicPartition{Topic: &kc.topic, Partition: partition, Offset: kafka.Offset(offset)})
+	}
+	committed, err := kc.c.CommitOffsets(partitions)
+	if err != nil {
+		log.Warn("kafka consumer commit offsets failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Any("offsets", offsets), zap.Error(err))
+		return err
+	}
+	// the commit may fail on some partitions.
+	for _, tp := range committed {
+		if tp.Error != nil {
+			return errors.Wrapf(tp.Error, "commit offset %d of partition %d of topic %s", tp.Offset, tp.Partition, kc.topic)
+		}
+	}
+	log.Info("kafka consumer commit offsets", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Any("offsets", offsets))
+	return nil
+}
+
+// Pause pauses the consumption of the assigned partitions of the topic for flow control, the other partitions are consumed as usual.
+// The messages that are already fetched from the paused partitions may still be delivered.
+// An UnassignedPartitionsError is returned and nothing is paused if any of the partitions is not assigned.
+func (kc *Consumer) Pause(partitions []int32) error {
+	return kc.pauseOrResume(partitions, true)
+}
+
+// Resume resumes the consumption of the assigned partitions of the topic paused by Pause,
+// it's a no-op for a partition that is not paused.
+// An UnassignedPartitionsError is returned and nothing is resumed if any of the partitions is not assigned.
+func (kc *Consumer) Resume(partitions []int32) error {
+	return kc.pauseOrResume(partitions, false)
+}
+
+// pauseOrResume pauses or resumes the consumption of the assigned partitions.
+func (kc *Consumer) pauseOrResume(partitions []int32, pause bool) error {
+	if len(partitions) == 0 {
+		return nil
+	}
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	assigned, err := kc.assignedPartitions()
+	if err != nil {
+		return err
+	}
+	if err := kc.newUnassignedPartitionsError(assigned, partitions); err != nil {
+		return err
+	}
+
+	tps := lo.Map(partitions, func(partition int32, _ int) kafka.TopicPartition {
+		return kafka.TopicPartition{Topic: &kc.topic, Partition: partition}
+	})
+	operation := "resume"
+	if pause {
+		operation = "pause"
+		err = kc.c.Pause(tps)
+	} else {
+		err = kc.c.Resume(tps)
+	}
+	if err != nil {
+		log.Warn("kafka consumer "+operation+" partitions failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Int32s("partitions", partitions), zap.Error(err))
+		return errors.Wrapf(err, "%s partitions %v of topic %s", operation, partitions, kc.topic)
+	}
+	log.Info("kafka consumer "+operation+" partitions", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Int32s("partitions", partitions))
+	return nil
+}
+
 func (kc *Consumer) createKafkaConsumer() error {
 	var err error
 	kc.c, err = kafka.NewConsumer(kc.config)
@@ -107,6 +320,61 @@ func (kc *Consumer) createKafkaConsumer() error {
 	return nil
 }
 
+// SetMessageTransform registers the payload transform of the consumer, the payload is returned as is by default.
+// It should be set before Chan is called.
+// The transform error doesn't stop the consumption, the failed message keeps its raw payload
+// and the error can be got by MessageTransformError.
+func (kc *Consumer) SetMessageTransform(transform MessageTransform) {
+	kc.transform = transform
+}
+
+// SetSkipOnTransformError makes the consumer skip the message that fails to transform instead of returning it,
+// so an unparseable message never blocks the consumption. It should be set before Chan is called.
+// The skipped message is acked and counted as Skip does.
+func (kc *Consumer) SetSkipOnTransformError(skip bool) {
+	kc.skipOnTransformError = skip
+}
+
+// SetSkipExpired makes the consumer skip the message whose expiry stamped by the producer with TTL has passed,
+// the message without expiry is always returned. It should be set before Chan is called.
+// The skipped message is acked and counted as Skip does.
+func (kc *Consumer) SetSkipExpired(skip bool) {
+	kc.skipExpired = skip
+}
+
+// SetVerifyChecksum makes the consumer verify the payload against the checksum stamped by the producer with Checksum set,
+// the corrupt message is skipped and counted as a checksum mismatch, the message without checksum is always returned.
+// The checksum is verified over the raw payload before the transform. It should be set before Chan is called.
+// The skipped message is acked and counted as Skip does.
+func (kc *Consumer) SetVerifyChecksum(verify bool) {
+	kc.verifyChecksum = verify
+}
+
+// SetDedup makes the consumer skip the message whose dedup key stamped by the producer is among the last capacity distinct keys
+// delivered by Chan, so the duplicates of the retried writes across the restart of producer are filtered.
+// The message without dedup key is always returned. A non-positive capacity disables the dedup, it's disabled by default.
+// It should be set before Chan is called. The skipped message is acked and counted as Skip does.
+func (kc *Consumer) SetDedup(capacity int) {
+	kc.dedup = newDedupFilter(capacity)
+}
+
+// newMessage wraps the kafka message and applies the payload transform.
+func (kc *Consumer) newMessage(msg *kafka.Message) *kafkaMessage {
+	km := &kafkaMessage{msg: msg, payload: msg.Value, fetchTime: time.Now()}
+	if kc.transform == nil {
+		return km
+	}
+	payload, err := kc.transform(msg.Value)
+	if err != nil {
+		log.Warn("transform the payload of kafka message failed",
+			zap.String("topic", kc.topic), zap.Any("offset", msg.TopicPartition.Offset), zap.Error(err))
+		km.transformErr = errors.Wrapf(err, "transform the payload of message at offset %v of topic %s", msg.TopicPartition.Offset, kc.topic)
+		return km
+	}
+	km.payload = payload
+	return km
+}
+
 func (kc *Consumer) Subscription() string {
 	return kc.groupID
 }
@@ -121,9 +389,19 @@ func (kc *Consumer) Chan() <-chan common.Message {
 		panic("failed to chan a kafka consumer without assign")
 	}
 	kc.chanOnce.Do(func() {
+		kc.mu.RLock()
+		defer kc.mu.RUnlock()
+		if kc.closed {
+			// the consumer is closed before Chan is invoked, the reader should never block on the channel.
+			close(kc.msgChannel)
+			return
+		}
+		kc.started = true
 		kc.wg.Add(1)
 		go func() {
 			defer kc.wg.Done()
+			// the count of messages polled in a row without yielding.
+			polled := 0
 			for {
 				select {
 				case <-kc.closeCh:
@@ -133,18 +411,52 @@ func (kc *Consumer) Chan() <-chan common.Message {
 					return
 				default:
 					readTimeout := paramtable.Get().KafkaCfg.ReadTimeout.GetAsDuration(time.Second)
-					e, err := kc.c.ReadMessage(readTimeout)
+					e, err := kc.readMessage(readTimeout)
 					if err != nil {
 						// if we failed to read message in 30 Seconds, print out a warn message since there should always be a tt
 						log.Warn("consume msg failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Error(err))
+						polled = 0
 					} else {
+						// the skipped messages are counted too, they cost the poll as the returned ones.
+						polled++
+						if maxPollRecords := paramtable.Get().KafkaCfg.ConsumerMaxPollRecords.GetAsInt(); maxPollRecords > 0 && polled >= maxPollRecords {
+							kc.yield()
+							polled = 0
+						}
+						kc.detectOffsetGap(e)
 						if kc.skipMsg {
 							kc.skipMsg = false
 							continue
 						}
+						if kc.consumeEndOfStream(e) {
+							if kc.endOfStream.Load() {
+								close(kc.msgChannel)
+								return
+							}
+							continue
+						}
 
+						msg := kc.newMessage(e)
+						if kc.verifyChecksum && !msg.verifyChecksum() {
+							metrics.MsgStreamConsumeChecksumMismatchTotal.WithLabelValues(kc.topic).Inc()
+							kc.skip(msg, skipReasonChecksum)
+							continue
+						}
+						if msg.transformErr != nil && kc.skipOnTransformError {
+							kc.skip(msg, skipReasonTransformError)
+							continue
+						}
+						if kc.skipExpired && msg.isExpired(time.Now()) {
+							kc.skip(msg, skipReasonExpired)
+							continue
+						}
+						if key, ok := msg.dedupKey(); ok && kc.dedup.Seen(key) {
+							kc.skip(msg, skipReasonDuplicate)
+							continue
+						}
+						msg.markReturned()
 						select {
-						case kc.msgChannel <- &kafkaMessage{msg: e}:
+						case kc.msgChannel <- msg:
 						case <-kc.closeCh:
 						}
 					}
@@ -156,6 +468,146 @@ func (kc *Consumer) Chan() <-chan common.Message {
 	return kc.msgChannel
 }
 
+// ReceiveBatch receives the messages from Chan in order until maxBatch messages are received or maxWait elapses,
+// so the caller can amortize the processing over a batch.
+// An empty batch is returned if no message arrives within maxWait.
+// If the context is done or the consumer is closed, the received messages are returned with the error.
+func (kc *Consumer) ReceiveBatch(ctx context.Context, maxBatch int, maxWait time.Duration) ([]common.Message, error) {
+	if maxBatch <= 0 {
+		return nil, errors.Newf("invalid max batch size %d", maxBatch)
+	}
+	msgChan := kc.Chan()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	batch := make([]common.Message, 0, maxBatch)
+	for len(batch) < maxBatch {
+		select {
+		case <-ctx.Done():
+			return batch, ctx.Err()
+		case <-timer.C:
+			return batch, nil
+		case msg, ok := <-msgChan:
+			if !ok {
+				return batch, kc.closedErr()
+			}
+			batch = append(batch, msg)
+		}
+	}
+	return batch, nil
+}
+
+// Skip discards the next n messages that are not received from Chan yet, e.g. the unparseable messages,
+// so the consumer advances past them rather than looping on them.
+// The skipped messages are acked, so the offset is committed forward if the async commit is enabled.
+// It blocks until n messages are skipped, an error is returned if no message arrives within the read timeout.
+// It should be called by the goroutine that receives from Chan.
+func (kc *Consumer) Skip(n int) error {
+	if n < 0 {
+		return errors.Newf("invalid count %d of messages to skip", n)
+	}
+	if !kc.hasAssign {
+		return errors.New("can not skip messages of a kafka consumer without assign")
+	}
+	readTimeout := paramtable.Get().KafkaCfg.ReadTimeout.GetAsDuration(time.Second)
+	for i := 0; i < n; i++ {
+		msg, err := kc.next(readTimeout)
+		if err != nil {
+			return errors.Wrapf(err, "skip messages of topic %s, %d of %d skipped", kc.topic, i, n)
+		}
+		kc.skip(msg, skipReasonManual)
+	}
+	return nil
+}
+
+// Rewind moves the consumer back by n messages from the current position, so the n consumed messages are consumed again,
+// the consumer is rewound to the earliest offset if n exceeds the available history of the topic.
+// The current position is the offset of the next message to consume, so at least one message should be consumed before.
+// It should be called before Chan is called, because the messages already fetched by Chan can not be taken back.
+func (kc *Consumer) Rewind(n int64) error {
+	if n < 0 {
+		return errors.Newf("invalid count %d of messages to rewind", n)
+	}
+	if !kc.hasAssign {
+		return errors.New("can not rewind a kafka consumer without assign")
+	}
+	if kc.started {
+		return errors.New("can not rewind a kafka consumer after chan is started")
+	}
+
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	positions, err := kc.c.Position([]kafka.TopicPartition{{Topic: &kc.topic, Partition: mqwrapper.DefaultPartitionIdx}})
+	if err != nil {
+		return errors.Wrapf(err, "get position of kafka consumer of topic %s", kc.topic)
+	}
+	current := positions[0].Offset
+	if current < 0 {
+		return errors.Newf("no message of topic %s is consumed yet, can not rewind", kc.topic)
+	}
+	low, _, err := kc.c.QueryWatermarkOffsets(kc.topic, mqwrapper.DefaultPartitionIdx, timeout)
+	if err != nil {
+		return errors.Wrapf(err, "query watermark offsets of topic %s", kc.topic)
+	}
+	target := max(int64(current)-n, low)
+
+	if err := kc.c.Assign([]kafka.TopicPartition{{Topic: &kc.topic, Partition: mqwrapper.DefaultPartitionIdx, Offset: kafka.Offset(target)}}); err != nil {
+		return errors.Wrapf(err, "rewind kafka consumer of topic %s to offset %d", kc.topic, target)
+	}
+	kc.assigned = kafka.Offset(target)
+	// the rewound position is always inclusive.
+	kc.skipMsg = false
+	log.Info("kafka consumer is rewound", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID),
+		zap.Any("from", current), zap.Int64("to", target), zap.Int64("n", n), zap.Int64("earliest", low))
+	return nil
+}
+
+// next returns the next message that is not received from Chan yet.
+func (kc *Consumer) next(readTimeout time.Duration) (*kafkaMessage, error) {
+	if err := kc.Err(); err != nil {
+		return nil, err
+	}
+	if kc.started {
+		timer := time.NewTimer(readTimeout)
+		defer timer.Stop()
+		select {
+		case msg, ok := <-kc.msgChannel:
+			if !ok {
+				return nil, kc.closedErr()
+			}
+			return msg.(*kafkaMessage), nil
+		case <-timer.C:
+			return nil, errors.Newf("no message arrives in %s", readTimeout)
+		}
+	}
+	for {
+		e, err := kc.readMessage(readTimeout)
+		if err != nil {
+			return nil, err
+		}
+		kc.detectOffsetGap(e)
+		if kc.skipMsg {
+			kc.skipMsg = false
+			continue
+		}
+		if kc.consumeEndOfStream(e) {
+			if kc.endOfStream.Load() {
+				return nil, ErrEndOfStream
+			}
+			continue
+		}
+		return kc.newMessage(e), nil
+	}
+}
+
+// skip acks the message without delivery and records it.
+func (kc *Consumer) skip(msg *kafkaMessage, reason string) {
+	log.Warn("skip kafka message", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID),
+		zap.Any("offset", msg.msg.TopicPartition.Offset), zap.String("reason", reason), zap.Error(msg.transformErr))
+	metrics.MsgStreamConsumeSkippedMessageTotal.WithLabelValues(kc.topic, reason).Inc()
+	kc.Ack(msg)
+}
+
 func (kc *Consumer) Seek(id common.MessageID, inclusive bool) error {
 	if kc.hasAssign {
 		return errors.New("kafka consumer is already assigned, can not seek again")
@@ -175,6 +627,7 @@ func (kc *Consumer) internalSeek(offset kafka.Offset, inclusive bool) error {
 		log.Warn("kafka consumer assign failed ", zap.String("topic name", kc.topic), zap.Any("Msg offset", offset), zap.Error(err))
 		return err
 	}
+	kc.assigned = offset
 
 	cost := time.Since(start).Milliseconds()
 	if cost > 200 {
@@ -201,12 +654,77 @@ func (kc *Consumer) internalSeek(offset kafka.Offset, inclusive bool) error {
 }
 
 func (kc *Consumer) Ack(message common.Message) {
-	// Do nothing
 	// Kafka retention mechanism only depends on retention configuration,
 	// it does not relate to the commit with consumer's offsets.
+	// So the offset is only committed in async commit mode to resume the consumption of the group,
+	// and never committed if the offsets are stored by the caller.
+	if kc.committer == nil {
+		return
+	}
+	if km, ok := message.(*kafkaMessage); ok {
+		kc.committer.Mark(km.msg.TopicPartition.Offset)
+	}
+}
+
+// PartitionPosition is the current position of the consumer on an assigned partition.
+type PartitionPosition struct {
+	Topic     string
+	Partition int32
+	// Offset is the offset of the next message to consume,
+	// it's kafka.OffsetInvalid if no message has been consumed from the partition yet.
+	Offset int64
+}
+
+// Assignment returns the assigned partitions and the current positions of the consumer, for diagnostics.
+// An empty slice is returned if the consumer is not assigned yet.
+func (kc *Consumer) Assignment() ([]PartitionPosition, error) {
+	if !kc.hasAssign {
+		return []PartitionPosition{}, nil
+	}
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	assignment, err := kc.c.Assignment()
+	if err != nil {
+		log.Warn("get kafka consumer assignment failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Error(err))
+		return nil, err
+	}
+	positions, err := kc.c.Position(assignment)
+	if err != nil {
+		log.Warn("get kafka consumer position failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Error(err))
+		return nil, err
+	}
+	result := make([]PartitionPosition, 0, len(positions))
+	for _, p := range positions {
+		result = append(result, PartitionPosition{
+			Topic:     *p.Topic,
+			Partition: p.Partition,
+			Offset:    int64(p.Offset),
+		})
+	}
+	return result, nil
+}
+
+// Watermarks returns the low and high watermarks of the partition of the consumer, for lag computation and replay bounds.
+// The low watermark is the offset of the earliest retained message, and the high watermark is the offset
+// of the next message to be produced, so high-low is the count of retained messages.
+// An error is returned if the consumer is not assigned yet.
+func (kc *Consumer) Watermarks() (low int64, high int64, err error) {
+	if !kc.hasAssign {
+		return 0, 0, errors.Newf("can not query watermarks of a kafka consumer of topic %s without assign", kc.topic)
+	}
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	low, high, err = kc.c.QueryWatermarkOffsets(kc.topic, mqwrapper.DefaultPartitionIdx, timeout)
+	if err != nil {
+		log.Warn("query kafka watermark offsets failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Error(err))
+		return 0, 0, errors.Wrapf(err, "query watermark offsets of topic %s", kc.topic)
+	}
+	return low, high, nil
 }
 
 func (kc *Consumer) GetLatestMsgID() (common.MessageID, error) {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
 	low, high, err := kc.c.QueryWatermarkOffsets(kc.topic, mqwrapper.DefaultPartitionIdx, timeout)
 	if err != nil {
 		return nil, err
@@ -254,11 +772,21 @@ func (kc *Consumer) closeInternal() {
 	}
 }
 
+// Close closes the consumer, it's safe to be called multiple times and concurrently,
+// the underlying consumer is closed exactly once and the later calls are no-op.
+// The call returns after the background goroutine of Chan exits and the underlying consumer is closed.
 func (kc *Consumer) Close() {
 	kc.closeOnce.Do(func() {
+		kc.mu.Lock()
+		kc.closed = true
+		kc.mu.Unlock()
 		close(kc.closeCh)
 		// wait work goroutine exit
 		kc.wg.Wait()
+		// flush the acked offsets before the client is closed.
+		if kc.committer != nil {
+			kc.committer.Close()
+		}
 		// close the client
 		kc.closeInternal()
 	})
@@ -2,15 +2,20 @@ package kafka
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/milvus-io/milvus/pkg/v2/common"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
 )
 
@@ -295,4 +300,757 @@ func TestKafkaConsumer_Close(t *testing.T) {
 		<-consumer.Chan()
 		consumer.Close()
 	})
+
+	t.Run("close concurrently", func(t *testing.T) {
+		groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+		config := createConfig(groupID)
+		consumer, err := newKafkaConsumer(config, 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+		assert.NoError(t, err)
+		ch := consumer.Chan()
+		<-ch
+
+		// the underlying consumer panics if it's closed twice.
+		wg := sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NotPanics(t, consumer.Close)
+			}()
+		}
+		wg.Wait()
+		assert.NotPanics(t, consumer.Close)
+
+		// the background goroutine exits and closes the channel, the buffered messages may be left.
+		for range ch {
+		}
+	})
+
+	t.Run("chan after close", func(t *testing.T) {
+		groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+		config := createConfig(groupID)
+		consumer, err := newKafkaConsumer(config, 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+		assert.NoError(t, err)
+		consumer.Close()
+		consumer.Close()
+
+		_, ok := <-consumer.Chan()
+		assert.False(t, ok)
+		assert.False(t, consumer.started)
+	})
+}
+
+func TestKafkaConsumer_AsyncCommit(t *testing.T) {
+	Params.Save(Params.KafkaCfg.ConsumerAsyncCommitEnabled.Key, "true")
+	Params.Save(Params.KafkaCfg.ConsumerAsyncCommitIntervalMs.Key, "100")
+	defer Params.Reset(Params.KafkaCfg.ConsumerAsyncCommitEnabled.Key)
+	defer Params.Reset(Params.KafkaCfg.ConsumerAsyncCommitIntervalMs.Key)
+
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	data1 := []int{111, 222, 333}
+	data2 := []string{"111", "222", "333"}
+	testKafkaConsumerProduceData(t, topic, data1, data2)
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	assert.NotNil(t, consumer.committer)
+	var lastOffset kafka.Offset
+	for i := 0; i < len(data1); i++ {
+		msg := <-consumer.Chan()
+		consumer.Ack(msg)
+		lastOffset = kafka.Offset(msg.ID().(*KafkaID).MessageID)
+	}
+
+	// the latest acked offset is committed after the flush interval.
+	partitions := []kafka.TopicPartition{{Topic: &topic, Partition: 0}}
+	assert.Eventually(t, func() bool {
+		committed, err := consumer.c.Committed(partitions, timeout)
+		return err == nil && committed[0].Offset == lastOffset+1
+	}, 5*time.Second, 50*time.Millisecond)
+	consumer.Close()
+
+	// the consumer of the same group can resume from the committed offset.
+	c, err := kafka.NewConsumer(createConfig(groupID))
+	assert.NoError(t, err)
+	defer c.Close()
+	committed, err := c.Committed(partitions, timeout)
+	assert.NoError(t, err)
+	assert.Equal(t, lastOffset+1, committed[0].Offset)
+}
+
+func TestKafkaConsumer_ManualOffsetStorage(t *testing.T) {
+	// the async commit is ignored if the offsets are stored by the caller.
+	Params.Save(Params.KafkaCfg.ConsumerManualOffsetStorage.Key, "true")
+	Params.Save(Params.KafkaCfg.ConsumerAsyncCommitEnabled.Key, "true")
+	Params.Save(Params.KafkaCfg.ConsumerAsyncCommitIntervalMs.Key, "100")
+	defer Params.Reset(Params.KafkaCfg.ConsumerManualOffsetStorage.Key)
+	defer Params.Reset(Params.KafkaCfg.ConsumerAsyncCommitEnabled.Key)
+	defer Params.Reset(Params.KafkaCfg.ConsumerAsyncCommitIntervalMs.Key)
+
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	data1 := []int{111, 222, 333}
+	data2 := []string{"111", "222", "333"}
+	testKafkaConsumerProduceData(t, topic, data1, data2)
+
+	// the auto commit of the extra config is also overridden.
+	config := createConfig(groupID)
+	config.SetKey("enable.auto.commit", true)
+	config.SetKey("auto.commit.interval.ms", 10)
+	consumer, err := newKafkaConsumer(config, 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	assert.Nil(t, consumer.committer)
+	for i := 0; i < len(data1); i++ {
+		msg := <-consumer.Chan()
+		assert.Equal(t, data1[i], BytesToInt(msg.Payload()))
+		consumer.Ack(msg)
+	}
+	assert.ErrorIs(t, consumer.CommitOffsets(map[int32]int64{0: 1}), ErrBrokerCommitDisabled)
+	// wait longer than the intervals of the auto commit and the async commit.
+	time.Sleep(500 * time.Millisecond)
+	consumer.Close()
+
+	// no offset of the group is committed to the broker.
+	partitions := []kafka.TopicPartition{{Topic: &topic, Partition: 0}}
+	c, err := kafka.NewConsumer(createConfig(groupID))
+	assert.NoError(t, err)
+	defer c.Close()
+	committed, err := c.Committed(partitions, timeout)
+	assert.NoError(t, err)
+	assert.Equal(t, kafka.OffsetInvalid, committed[0].Offset)
+}
+
+func TestKafkaConsumer_MessageTransform(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	payloads := [][]byte{
+		[]byte(base64.StdEncoding.EncodeToString([]byte("hello"))),
+		[]byte("not-base64!"),
+		[]byte(base64.StdEncoding.EncodeToString([]byte("world"))),
+	}
+	for _, payload := range payloads {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: payload})
+		assert.NoError(t, err)
+	}
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	consumer.SetMessageTransform(func(payload []byte) ([]byte, error) {
+		return base64.StdEncoding.DecodeString(string(payload))
+	})
+
+	msg := <-consumer.Chan()
+	assert.NoError(t, MessageTransformError(msg))
+	assert.Equal(t, []byte("hello"), msg.Payload())
+
+	// the failed message is still returned with its raw payload.
+	msg = <-consumer.Chan()
+	assert.Error(t, MessageTransformError(msg))
+	assert.Equal(t, []byte("not-base64!"), msg.Payload())
+
+	// the transform error doesn't stop the consumption.
+	msg = <-consumer.Chan()
+	assert.NoError(t, MessageTransformError(msg))
+	assert.Equal(t, []byte("world"), msg.Payload())
+}
+
+func TestKafkaConsumer_Skip(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	payloads := [][]byte{
+		[]byte("not-base64!"),
+		[]byte(base64.StdEncoding.EncodeToString([]byte("hello"))),
+		[]byte("not-base64!"),
+		[]byte(base64.StdEncoding.EncodeToString([]byte("world"))),
+		[]byte("not-base64!"),
+		[]byte(base64.StdEncoding.EncodeToString([]byte("milvus"))),
+	}
+	for _, payload := range payloads {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: payload})
+		assert.NoError(t, err)
+	}
+	decode := func(payload []byte) ([]byte, error) {
+		return base64.StdEncoding.DecodeString(string(payload))
+	}
+	skipped := func(reason string) float64 {
+		m := &dto.Metric{}
+		err := metrics.MsgStreamConsumeSkippedMessageTotal.WithLabelValues(topic, reason).(prometheus.Metric).Write(m)
+		assert.NoError(t, err)
+		return m.GetCounter().GetValue()
+	}
+
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	consumer.SetMessageTransform(decode)
+	assert.Error(t, consumer.Skip(-1))
+	assert.NoError(t, consumer.Skip(0))
+
+	// skip the bad message before the consumption.
+	assert.NoError(t, consumer.Skip(1))
+	msg := <-consumer.Chan()
+	assert.NoError(t, MessageTransformError(msg))
+	assert.Equal(t, []byte("hello"), msg.Payload())
+
+	// skip the bad message during the consumption.
+	assert.NoError(t, consumer.Skip(1))
+	msg = <-consumer.Chan()
+	assert.NoError(t, MessageTransformError(msg))
+	assert.Equal(t, []byte("world"), msg.Payload())
+	assert.Equal(t, float64(2), skipped(skipReasonManual))
+
+	// the bad messages are skipped automatically.
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	autoSkipConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer autoSkipConsumer.Close()
+	autoSkipConsumer.SetMessageTransform(decode)
+	autoSkipConsumer.SetSkipOnTransformError(true)
+	for _, expected := range []string{"hello", "world", "milvus"} {
+		msg := <-autoSkipConsumer.Chan()
+		assert.NoError(t, MessageTransformError(msg))
+		assert.Equal(t, []byte(expected), msg.Payload())
+	}
+	assert.Equal(t, float64(3), skipped(skipReasonTransformError))
+}
+
+func TestKafkaConsumer_SkipExpired(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	messages := []*mqcommon.ProducerMessage{
+		// already expired when it's produced.
+		{Payload: []byte("expired"), Timestamp: time.Now().Add(-time.Hour), TTL: time.Minute},
+		{Payload: []byte("alive"), TTL: time.Hour},
+		{Payload: []byte("forever")},
+	}
+	for _, msg := range messages {
+		_, err := producer.Send(context.TODO(), msg)
+		assert.NoError(t, err)
+	}
+	skipped := func() float64 {
+		m := &dto.Metric{}
+		err := metrics.MsgStreamConsumeSkippedMessageTotal.WithLabelValues(topic, skipReasonExpired).(prometheus.Metric).Write(m)
+		assert.NoError(t, err)
+		return m.GetCounter().GetValue()
+	}
+
+	// the expired message is returned if not skipped.
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	msg := <-consumer.Chan()
+	assert.Equal(t, []byte("expired"), msg.Payload())
+	expireAt, ok := ExpireTime(msg)
+	assert.True(t, ok)
+	assert.True(t, expireAt.Before(time.Now()))
+
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	skipConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer skipConsumer.Close()
+	skipConsumer.SetSkipExpired(true)
+	msg = <-skipConsumer.Chan()
+	assert.Equal(t, []byte("alive"), msg.Payload())
+	expireAt, ok = ExpireTime(msg)
+	assert.True(t, ok)
+	assert.True(t, expireAt.After(time.Now()))
+	msg = <-skipConsumer.Chan()
+	assert.Equal(t, []byte("forever"), msg.Payload())
+	_, ok = ExpireTime(msg)
+	assert.False(t, ok)
+	assert.Equal(t, float64(1), skipped())
+}
+
+func TestKafkaConsumer_VerifyChecksum(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer, err := kc.CreateProducer(context.TODO(), mqcommon.ProducerOptions{Topic: topic, Checksum: true})
+	assert.NoError(t, err)
+	defer producer.Close()
+	_, err = producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte("intact")})
+	assert.NoError(t, err)
+
+	// the payload is corrupted after the checksum is computed.
+	rawProducer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": getKafkaBrokerList()})
+	assert.NoError(t, err)
+	defer rawProducer.Close()
+	delivery := make(chan kafka.Event, 1)
+	err = rawProducer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 0},
+		Value:          []byte("corrupted"),
+		Headers:        []kafka.Header{{Key: ChecksumHeaderKey, Value: []byte(payloadChecksum([]byte("original")))}},
+	}, delivery)
+	assert.NoError(t, err)
+	assert.NoError(t, (<-delivery).(*kafka.Message).TopicPartition.Error)
+
+	_, err = producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte("intact again")})
+	assert.NoError(t, err)
+	mismatches := func() float64 {
+		m := &dto.Metric{}
+		err := metrics.MsgStreamConsumeChecksumMismatchTotal.WithLabelValues(topic).(prometheus.Metric).Write(m)
+		assert.NoError(t, err)
+		return m.GetCounter().GetValue()
+	}
+
+	// the corrupt message is returned if the checksum is not verified.
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	for _, expected := range []string{"intact", "corrupted", "intact again"} {
+		msg := <-consumer.Chan()
+		assert.Equal(t, []byte(expected), msg.Payload())
+	}
+	assert.Zero(t, mismatches())
+
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	verifyingConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer verifyingConsumer.Close()
+	verifyingConsumer.SetVerifyChecksum(true)
+	for _, expected := range []string{"intact", "intact again"} {
+		msg := <-verifyingConsumer.Chan()
+		assert.Equal(t, []byte(expected), msg.Payload())
+	}
+	assert.Equal(t, float64(1), mismatches())
+}
+
+func TestKafkaConsumer_MaxPollRecords(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	// all messages are available before the consumption.
+	for i := 0; i < 10; i++ {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte(fmt.Sprint(i))})
+		assert.NoError(t, err)
+	}
+
+	Params.Save(Params.KafkaCfg.ConsumerMaxPollRecords.Key, "3")
+	defer Params.Reset(Params.KafkaCfg.ConsumerMaxPollRecords.Key)
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	// the yield happens before the message that reaches the limit is handed over.
+	yields := 0
+	consumer.yield = func() {
+		yields++
+	}
+	for i := 0; i < 10; i++ {
+		msg := <-consumer.Chan()
+		assert.Equal(t, []byte(fmt.Sprint(i)), msg.Payload())
+	}
+	// the consumer yields after every 3 messages polled, even though the following ones are available.
+	assert.Equal(t, 3, yields)
+}
+
+func TestKafkaConsumer_ReceiveBatch(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	send := func(i int) {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: []byte(fmt.Sprint(i))})
+		assert.NoError(t, err)
+	}
+	for i := 0; i < 10; i++ {
+		send(i)
+	}
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	_, err = consumer.ReceiveBatch(context.TODO(), 0, time.Second)
+	assert.Error(t, err)
+
+	// the backlog fills the batch up to the max batch size in order.
+	next := 0
+	assertBatch := func(batch []mqcommon.Message) {
+		for _, msg := range batch {
+			assert.Equal(t, []byte(fmt.Sprint(next)), msg.Payload())
+			next++
+		}
+	}
+	for next < 8 {
+		batch, err := consumer.ReceiveBatch(context.TODO(), 4, 10*time.Second)
+		assert.NoError(t, err)
+		assert.Len(t, batch, 4)
+		assertBatch(batch)
+	}
+
+	// the batch is returned when the max wait elapses.
+	start := time.Now()
+	batch, err := consumer.ReceiveBatch(context.TODO(), 4, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+	assert.Len(t, batch, 2)
+	assertBatch(batch)
+
+	// the slowly arriving messages are accumulated until the max wait elapses.
+	go func() {
+		for i := 10; i < 13; i++ {
+			time.Sleep(200 * time.Millisecond)
+			send(i)
+		}
+	}()
+	start = time.Now()
+	batch, err = consumer.ReceiveBatch(context.TODO(), 100, 2*time.Second)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 2*time.Second)
+	assert.Len(t, batch, 3)
+	assertBatch(batch)
+
+	// no message arrives.
+	batch, err = consumer.ReceiveBatch(context.TODO(), 4, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Empty(t, batch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = consumer.ReceiveBatch(ctx, 4, time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestKafkaConsumer_Assignment(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	data1 := []int{111, 222, 333}
+	data2 := []string{"111", "222", "333"}
+	testKafkaConsumerProduceData(t, topic, data1, data2)
+
+	// the consumer is not assigned until seek.
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	positions, err := consumer.Assignment()
+	assert.NoError(t, err)
+	assert.Empty(t, positions)
+	assert.NotNil(t, positions)
+
+	err = consumer.Seek(&KafkaID{MessageID: 0}, true)
+	assert.NoError(t, err)
+	msg := <-consumer.Chan()
+	assert.Equal(t, 111, BytesToInt(msg.Payload()))
+
+	assert.Eventually(t, func() bool {
+		positions, err = consumer.Assignment()
+		return err == nil && len(positions) == 1 && positions[0].Offset != int64(kafka.OffsetInvalid)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, topic, positions[0].Topic)
+	assert.Equal(t, int32(0), positions[0].Partition)
+	// the position is after the consumed messages, which may be prefetched into the buffer.
+	assert.GreaterOrEqual(t, positions[0].Offset, msg.ID().(*KafkaID).MessageID+1)
+	assert.LessOrEqual(t, positions[0].Offset, int64(len(data1)))
+}
+
+func TestKafkaConsumer_OffsetGap(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+	testKafkaConsumerProduceData(t, topic, []int{1, 2, 3}, []string{"", "", ""})
+
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	gaps := make(chan OffsetGap, 16)
+	consumer.EnableOffsetGapDetection(func(gap OffsetGap) {
+		gaps <- gap
+	})
+	getGapCount := func() float64 {
+		m := &dto.Metric{}
+		assert.NoError(t, metrics.MsgStreamConsumeOffsetGapTotal.WithLabelValues(topic).(prometheus.Metric).Write(m))
+		return m.GetCounter().GetValue()
+	}
+
+	// no gap is reported for the contiguous offsets.
+	var lastOffset kafka.Offset
+	for i := 1; i <= 3; i++ {
+		msg := <-consumer.Chan()
+		assert.Equal(t, i, BytesToInt(msg.Payload()))
+		lastOffset = kafka.Offset(msg.ID().(*KafkaID).MessageID)
+	}
+	assert.Empty(t, gaps)
+	assert.Zero(t, getGapCount())
+
+	// the mock cluster never leaves holes in the offsets, so feed the non-contiguous offsets to the receive path directly.
+	for _, offset := range []kafka.Offset{lastOffset + 1, lastOffset + 4, lastOffset + 5, lastOffset + 7} {
+		consumer.detectOffsetGap(&kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: offset}})
+	}
+	gap := <-gaps
+	assert.Equal(t, OffsetGap{Topic: topic, From: lastOffset + 1, To: lastOffset + 4}, gap)
+	assert.Equal(t, int64(2), gap.Missing())
+	gap = <-gaps
+	assert.Equal(t, OffsetGap{Topic: topic, From: lastOffset + 5, To: lastOffset + 7}, gap)
+	assert.Equal(t, int64(1), gap.Missing())
+	assert.Empty(t, gaps)
+	assert.Equal(t, float64(2), getGapCount())
+
+	// the detection is disabled by default.
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	disabled, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer disabled.Close()
+	for _, offset := range []kafka.Offset{0, 10} {
+		disabled.detectOffsetGap(&kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Offset: offset}})
+	}
+	assert.Equal(t, float64(2), getGapCount())
+}
+
+func TestKafkaConsumer_Rewind(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	for i := 0; i < 2100; i++ {
+		_, err := producer.Send(context.TODO(), &mqcommon.ProducerMessage{Payload: IntToBytes(i)})
+		assert.NoError(t, err)
+	}
+
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	assert.Error(t, consumer.Rewind(1))
+	assert.NoError(t, consumer.Seek(&KafkaID{MessageID: 0}, true))
+	assert.Error(t, consumer.Rewind(-1))
+	// no message is consumed yet.
+	assert.Error(t, consumer.Rewind(1))
+
+	// consume to offset 2000 and rewind 500.
+	assert.NoError(t, consumer.Skip(2000))
+	assert.NoError(t, consumer.Rewind(500))
+	msg := <-consumer.Chan()
+	assert.Equal(t, int64(1500), msg.ID().(*KafkaID).MessageID)
+	assert.Equal(t, 1500, BytesToInt(msg.Payload()))
+	// can not rewind after chan is started.
+	assert.Error(t, consumer.Rewind(1))
+
+	// rewind to the earliest if n exceeds the history.
+	groupID = fmt.Sprintf("test-groupid-%d", rand.Int())
+	earliestConsumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer earliestConsumer.Close()
+	assert.NoError(t, earliestConsumer.Skip(100))
+	assert.NoError(t, earliestConsumer.Rewind(1000))
+	msg = <-earliestConsumer.Chan()
+	assert.Equal(t, int64(0), msg.ID().(*KafkaID).MessageID)
+	assert.Equal(t, 0, BytesToInt(msg.Payload()))
+}
+
+func TestKafkaConsumer_Watermarks(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	data := []int{111, 222, 333, 444, 555}
+	testKafkaConsumerProduceData(t, topic, data, []string{"111", "222", "333", "444", "555"})
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	_, _, err = consumer.Watermarks()
+	assert.Error(t, err)
+
+	assert.NoError(t, consumer.Seek(&KafkaID{MessageID: 0}, true))
+	low, high, err := consumer.Watermarks()
+	assert.NoError(t, err)
+	// all the produced messages are retained.
+	assert.Equal(t, int64(0), low)
+	assert.Equal(t, int64(len(data)), high)
+
+	// the watermarks don't move with the consumption.
+	msg := <-consumer.Chan()
+	assert.Equal(t, 111, BytesToInt(msg.Payload()))
+	low, high, err = consumer.Watermarks()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), low)
+	assert.Equal(t, int64(len(data)), high)
+}
+
+func TestKafkaConsumer_CommitOffsets(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	// the topic is auto created with multiple partitions by the mock cluster.
+	testKafkaConsumerProduceData(t, topic, []int{111}, []string{"111"})
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	assert.NoError(t, consumer.CommitOffsets(nil))
+	metadata, err := consumer.c.GetMetadata(&topic, false, timeout)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(metadata.Topics[topic].Partitions), 3)
+
+	// assign the first two partitions explicitly.
+	err = consumer.c.Assign([]kafka.TopicPartition{
+		{Topic: &topic, Partition: 0, Offset: kafka.OffsetBeginning},
+		{Topic: &topic, Partition: 1, Offset: kafka.OffsetBeginning},
+	})
+	assert.NoError(t, err)
+	committed := func() map[int32]int64 {
+		partitions, err := consumer.c.Committed([]kafka.TopicPartition{
+			{Topic: &topic, Partition: 0},
+			{Topic: &topic, Partition: 1},
+			{Topic: &topic, Partition: 2},
+		}, timeout)
+		assert.NoError(t, err)
+		offsets := make(map[int32]int64)
+		for _, p := range partitions {
+			offsets[p.Partition] = int64(p.Offset)
+		}
+		return offsets
+	}
+
+	assert.NoError(t, consumer.CommitOffsets(map[int32]int64{0: 5, 1: 7}))
+	assert.Equal(t, map[int32]int64{0: 5, 1: 7, 2: int64(kafka.OffsetInvalid)}, committed())
+
+	// nothing is committed if any partition is not assigned.
+	err = consumer.CommitOffsets(map[int32]int64{0: 10, 5: 1, 2: 3})
+	unassignedErr := &UnassignedPartitionsError{}
+	assert.ErrorAs(t, err, &unassignedErr)
+	assert.Equal(t, topic, unassignedErr.Topic)
+	assert.Equal(t, []int32{2, 5}, unassignedErr.Partitions)
+	assert.Equal(t, map[int32]int64{0: 5, 1: 7, 2: int64(kafka.OffsetInvalid)}, committed())
+}
+
+func TestKafkaConsumer_PauseResume(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	// the topic is auto created with multiple partitions by the mock cluster.
+	testKafkaConsumerProduceData(t, topic, []int{111}, []string{"111"})
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	assert.NoError(t, consumer.Pause(nil))
+	assert.NoError(t, consumer.Resume(nil))
+
+	// consume the first two partitions from the latest offset.
+	assignment := make([]kafka.TopicPartition, 0, 2)
+	for _, partition := range []int32{0, 1} {
+		_, high, err := consumer.c.QueryWatermarkOffsets(topic, partition, timeout)
+		assert.NoError(t, err)
+		assignment = append(assignment, kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: kafka.Offset(high)})
+	}
+	assert.NoError(t, consumer.c.Assign(assignment))
+	err = consumer.Pause([]int32{1, 3})
+	unassignedErr := &UnassignedPartitionsError{}
+	assert.ErrorAs(t, err, &unassignedErr)
+	assert.Equal(t, []int32{3}, unassignedErr.Partitions)
+	assert.NoError(t, consumer.Pause([]int32{1}))
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": getKafkaBrokerList()})
+	assert.NoError(t, err)
+	defer producer.Close()
+	delivery := make(chan kafka.Event, 6)
+	for _, partition := range []int32{0, 1} {
+		for i := 0; i < 3; i++ {
+			err := producer.Produce(&kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: partition},
+				Value:          IntToBytes(int(partition)*10 + i),
+			}, delivery)
+			assert.NoError(t, err)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, (<-delivery).(*kafka.Message).TopicPartition.Error)
+	}
+
+	// receive until no message arrives, returns the partitions of the received messages.
+	receive := func() []int32 {
+		partitions := make([]int32, 0)
+		for {
+			msg, err := consumer.c.ReadMessage(time.Second)
+			if err != nil {
+				return partitions
+			}
+			partitions = append(partitions, msg.TopicPartition.Partition)
+		}
+	}
+	// no message arrives from the paused partition.
+	assert.Equal(t, []int32{0, 0, 0}, receive())
+
+	err = consumer.Resume([]int32{5})
+	assert.ErrorAs(t, err, &unassignedErr)
+	assert.Equal(t, []int32{5}, unassignedErr.Partitions)
+	assert.NoError(t, consumer.Resume([]int32{1}))
+	assert.Equal(t, []int32{1, 1, 1}, receive())
+}
+
+func TestKafkaConsumer_LatencyMetrics(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+	data := []int{111, 222, 333}
+	testKafkaConsumerProduceData(t, topic, data, []string{"111", "222", "333"})
+
+	sampleCount := func(histogram *prometheus.HistogramVec) uint64 {
+		m := &dto.Metric{}
+		err := histogram.WithLabelValues(topic).(prometheus.Metric).Write(m)
+		assert.NoError(t, err)
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+
+	msgs := make([]mqcommon.Message, 0, len(data))
+	for range data {
+		msgs = append(msgs, <-consumer.Chan())
+	}
+	// every returned message is observed from its fetch.
+	assert.Equal(t, uint64(len(data)), sampleCount(metrics.MsgStreamConsumeReturnLatency))
+	assert.Zero(t, sampleCount(metrics.MsgStreamConsumeProcessLatency))
+
+	// the processing latency is observed once for each message reported done.
+	MessageDone(msgs[0])
+	MessageDone(msgs[0])
+	MessageDone(msgs[1])
+	assert.Equal(t, uint64(2), sampleCount(metrics.MsgStreamConsumeProcessLatency))
 }
@@ -1,13 +1,146 @@
 package kafka
 
 import (
+	"hash/crc32"
+	"strconv"
+	"time"
+
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/atomic"
 
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	"github.com/milvus-io/milvus/pkg/v2/mq/common"
 )
 
 type kafkaMessage struct {
-	msg *kafka.Message
+	msg          *kafka.Message
+	payload      []byte // the transformed payload.
+	transformErr error  // the error of payload transform, the raw payload is kept if it's not nil.
+
+	fetchTime  time.Time   // the time when the message is fetched from kafka.
+	returnTime atomic.Time // the time when the message is handed to the caller, zero if not returned yet.
+	done       atomic.Bool // the message is reported done by MessageDone.
+}
+
+// markReturned records the latency from the fetch to the return of the message to the caller,
+// it's called once the message is ready to be handed to the caller.
+func (km *kafkaMessage) markReturned() {
+	now := time.Now()
+	km.returnTime.Store(now)
+	metrics.MsgStreamConsumeReturnLatency.WithLabelValues(km.Topic()).Observe(float64(now.Sub(km.fetchTime).Milliseconds()))
+}
+
+// MessageDone reports that the caller finishes processing the consumed message,
+// the latency from the return of the message to the done is recorded as the downstream processing latency.
+// It's optional and only the first call of each message is recorded, the non-kafka message is ignored.
+func MessageDone(msg common.Message) {
+	km, ok := msg.(*kafkaMessage)
+	if !ok || km.done.Swap(true) {
+		return
+	}
+	returnTime := km.returnTime.Load()
+	if returnTime.IsZero() {
+		return
+	}
+	metrics.MsgStreamConsumeProcessLatency.WithLabelValues(km.Topic()).Observe(float64(time.Since(returnTime).Milliseconds()))
+}
+
+// MessageTransformError returns the error of the payload transform of the consumed message,
+// nil if the message is transformed successfully or it's not a kafka message.
+func MessageTransformError(msg common.Message) error {
+	if km, ok := msg.(*kafkaMessage); ok {
+		return km.transformErr
+	}
+	return nil
+}
+
+// SchemaVersionHeaderKey is the header key of the schema version stamped by the producer with SchemaVersion set.
+const SchemaVersionHeaderKey = "milvus-schema-version"
+
+// SchemaVersion returns the schema version stamped into the header of the consumed message,
+// false if the message is not stamped or it's not a kafka message.
+func SchemaVersion(msg common.Message) (string, bool) {
+	km, ok := msg.(*kafkaMessage)
+	if !ok {
+		return "", false
+	}
+	for _, header := range km.msg.Headers {
+		if header.Key == SchemaVersionHeaderKey {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+// ExpireAtHeaderKey is the header key of the expiry stamped by the producer for the message with TTL set,
+// the value is the unix milliseconds of the expiry.
+const ExpireAtHeaderKey = "milvus-expire-at"
+
+// ExpireTime returns the expiry stamped into the header of the consumed message,
+// false if the message never expires or it's not a kafka message.
+func ExpireTime(msg common.Message) (time.Time, bool) {
+	km, ok := msg.(*kafkaMessage)
+	if !ok {
+		return time.Time{}, false
+	}
+	return km.expireTime()
+}
+
+// expireTime returns the expiry of the message, false if it's not stamped or malformed.
+func (km *kafkaMessage) expireTime() (time.Time, bool) {
+	for _, header := range km.msg.Headers {
+		if header.Key != ExpireAtHeaderKey {
+			continue
+		}
+		millis, err := strconv.ParseInt(string(header.Value), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(millis), true
+	}
+	return time.Time{}, false
+}
+
+// isExpired returns whether the message is expired at now.
+func (km *kafkaMessage) isExpired(now time.Time) bool {
+	expireAt, ok := km.expireTime()
+	return ok && !now.Before(expireAt)
+}
+
+// DedupKeyHeaderKey is the header key of the dedup key stamped by the producer for the message with DedupKey set.
+const DedupKeyHeaderKey = "milvus-dedup-key"
+
+// dedupKey returns the dedup key of the message, false if it's not stamped.
+func (km *kafkaMessage) dedupKey() (string, bool) {
+	for _, header := range km.msg.Headers {
+		if header.Key == DedupKeyHeaderKey {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+// ChecksumHeaderKey is the header key of the CRC-32C checksum of payload stamped by the producer with Checksum set,
+// the value is the checksum in hex.
+const ChecksumHeaderKey = "milvus-checksum"
+
+// crc32cTable is the table of CRC-32C (Castagnoli), which is hardware accelerated on most platforms.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// payloadChecksum returns the checksum of the payload in hex.
+func payloadChecksum(payload []byte) string {
+	return strconv.FormatUint(uint64(crc32.Checksum(payload, crc32cTable)), 16)
+}
+
+// verifyChecksum returns false if the raw payload of the message doesn't match the stamped checksum,
+// the message without checksum is always valid.
+func (km *kafkaMessage) verifyChecksum() bool {
+	for _, header := range km.msg.Headers {
+		if header.Key == ChecksumHeaderKey {
+			return string(header.Value) == payloadChecksum(km.msg.Value)
+		}
+	}
+	return true
 }
 
 func (km *kafkaMessage) Topic() string {
@@ -23,7 +156,7 @@ func (km *kafkaMessage) Properties() map[string]string {
 }
 
 func (km *kafkaMessage) Payload() []byte {
-	return km.msg.Value
+	return km.payload
 }
 
 func (km *kafkaMessage) ID() common.MessageID {
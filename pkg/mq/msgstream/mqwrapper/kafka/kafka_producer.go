@@ -2,11 +2,13 @@ package kafka
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
 
 	"github.com/milvus-io/milvus/pkg/v2/common"
@@ -17,44 +19,312 @@ import (
 	"github.com/milvus-io/milvus/pkg/v2/util/timerecord"
 )
 
+// KeyExtractor derives the partition key of the message at produce time.
+// The messages with the same key are routed into the same partition by the partitioner of kafka.
+type KeyExtractor func(*mqcommon.ProducerMessage) []byte
+
+// kafkaProducer is the producer of a topic, the callers of CreateProducer with the same topic and options share the same producer,
+// so the key extractor, the retry policy and the rotated underlying producer apply to all of them.
+// It's reference counted, each CreateProducer call should be paired with exactly one Close.
 type kafkaProducer struct {
+	mu        sync.RWMutex // protect the underlying producer from being rotated while producing, and the key extractor and retry policy.
 	p         *kafka.Producer
+	pKey      string // the key of the shared underlying producer, released once it's rotated or closed, empty if it's not shared.
 	topic     string
 	closeOnce sync.Once
-	isClosed  bool
-	stopCh    chan struct{}
+	isClosed  bool          // protected by mu.
+	stopCh    chan struct{} // closed once the last reference of the producer is released.
+
+	client   *kafkaClient             // nil if the producer is not created by client.
+	cacheKey string                   // the key of the producer in the cache of client.
+	refCnt   int                      // protected by the mutex of client.
+	options  mqcommon.ProducerOptions // the options of CreateProducer, the overrides of them are kept on rotation.
+
+	keyExtractor KeyExtractor
+	durability   mqcommon.DurabilityMode
+	retryPolicy  *RetryPolicy // route the failed-delivery messages to the retry topics, nil if disabled.
+
+	queueFullPolicy       mqcommon.QueueFullPolicy
+	queueFullBlockTimeout time.Duration
+
+	schemaVersion string // stamped into the header of every message, empty if unset.
+	checksum      bool   // stamp the checksum of payload into the header of every message.
+
+	inflight *inflightLimiter // bound the messages whose delivery report is not arrived yet, nil if unlimited.
+
+	partitionCount atomic.Int32 // the cached partition count of the topic for SendToPartition, 0 if unknown.
+
+	pending pendingDeliveries // the messages of SendAsync waiting for the delivery reports, failed once the producer is closed.
+
+	syncMu sync.Mutex // serialize the SendSync calls.
+}
+
+// ErrMessageDropped is returned if the message is dropped by the QueueFullDrop policy because the queue of producer is full.
+var ErrMessageDropped = errors.New("kafka message is dropped because the queue of producer is full")
+
+const (
+	queueFullInitialBackoff = time.Millisecond
+	queueFullMaxBackoff     = 50 * time.Millisecond
+)
+
+// messageHeaders converts the properties of the message into kafka headers,
+// with the schema version header if it's set, the expiry header if the message has a TTL,
+// the dedup key header if the message has a dedup key and the checksum header if the checksum is enabled.
+func (kp *kafkaProducer) messageHeaders(message *mqcommon.ProducerMessage) []kafka.Header {
+	headers := propertiesToHeaders(message.Properties)
+	if kp.schemaVersion != "" {
+		headers = append(headers, kafka.Header{Key: SchemaVersionHeaderKey, Value: []byte(kp.schemaVersion)})
+	}
+	if message.TTL > 0 {
+		createTime := message.Timestamp
+		if createTime.IsZero() {
+			createTime = time.Now()
+		}
+		expireAt := strconv.FormatInt(createTime.Add(message.TTL).UnixMilli(), 10)
+		headers = append(headers, kafka.Header{Key: ExpireAtHeaderKey, Value: []byte(expireAt)})
+	}
+	if message.DedupKey != "" {
+		headers = append(headers, kafka.Header{Key: DedupKeyHeaderKey, Value: []byte(message.DedupKey)})
+	}
+	if kp.checksum {
+		headers = append(headers, kafka.Header{Key: ChecksumHeaderKey, Value: []byte(payloadChecksum(message.Payload))})
+	}
+	return headers
 }
 
 func (kp *kafkaProducer) Topic() string {
 	return kp.topic
 }
 
+// getProducer returns the current underlying producer.
+func (kp *kafkaProducer) getProducer() *kafka.Producer {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.p
+}
+
+// withProducer calls fn with the current underlying producer, which is not rotated or closed until fn returns.
+func (kp *kafkaProducer) withProducer(fn func(p *kafka.Producer) error) error {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return fn(kp.p)
+}
+
+// retainProducer returns the current underlying producer with one more reference,
+// so the producer is not closed by the rotation or close until the returned release is called.
+func (kp *kafkaProducer) retainProducer() (*kafka.Producer, func()) {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	p, key := kp.p, kp.pKey
+	if kp.client == nil || !retainKafkaProducer(key, p) {
+		return p, func() {}
+	}
+	return p, func() { releaseKafkaProducer(key, p) }
+}
+
+// closed returns whether the producer is closed.
+func (kp *kafkaProducer) closed() bool {
+	kp.mu.RLock()
+	defer kp.mu.RUnlock()
+	return kp.isClosed
+}
+
+// RotateProducer swaps the underlying producer with the one built with the new config overrides,
+// so the config can be changed without restart.
+// The new produces go to the new producer once swapped, and the old producer is flushed to deliver the in-flight messages.
+// The overrides of the producer options, such as linger and durability, and the per-topic config overrides of the client
+// are still applied, the new overrides take precedence.
+// All the callers sharing the producer are rotated, the producers of the other options keep their underlying producers.
+// The underlying producers are shared by the overrides, the old one is closed once no other producer uses it.
+func (kp *kafkaProducer) RotateProducer(newConfigOverrides kafka.ConfigMap) error {
+	if kp.client == nil {
+		return errors.New("kafka producer is not created by client, cannot rotate")
+	}
+	overrides := producerOverrides(kp.options)
+	kp.client.specialExtraConfig(&overrides, newConfigOverrides)
+	newProducer, newKey, err := kp.client.acquireKafkaProducer(kp.client.topicProducerOverrides(kp.topic, overrides))
+	if err != nil {
+		return err
+	}
+
+	kp.mu.Lock()
+	if kp.isClosed {
+		kp.mu.Unlock()
+		releaseKafkaProducer(newKey, newProducer)
+		return common.NewIgnorableError(errors.New("kafka producer is closed"))
+	}
+	oldProducer, oldKey := kp.p, kp.pKey
+	kp.p, kp.pKey = newProducer, newKey
+	kp.mu.Unlock()
+	// release the reference of the old producer after the in-flight messages are flushed.
+	defer releaseKafkaProducer(oldKey, oldProducer)
+	if oldProducer == newProducer {
+		return nil
+	}
+
+	// no more message is produced into the old producer after swapped.
+	if remain := oldProducer.Flush(10000); remain > 0 {
+		log.Warn("There are still un-flushed outstanding events after producer rotated", zap.Int("event_num", remain), zap.String("topic", kp.topic))
+		return errors.Newf("%d messages of topic %s are not flushed after producer rotated", remain, kp.topic)
+	}
+	log.Info("kafka producer rotated", zap.String("topic", kp.topic), zap.String("overrides", ConfigtoString(newConfigOverrides)))
+	return nil
+}
+
+// SetKeyExtractor registers the key extractor of the producer, no key is attached to the message by default.
+// It applies to all the callers sharing the producer, the producers of the same topic with other options are not affected.
+// The message id only keeps the offset, so the key extractor should only be used for a multi-partition topic
+// that is not consumed by the default partition consumer.
+func (kp *kafkaProducer) SetKeyExtractor(extractor KeyExtractor) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	kp.keyExtractor = extractor
+}
+
 func (kp *kafkaProducer) Send(ctx context.Context, message *mqcommon.ProducerMessage) (mqcommon.MessageID, error) {
+	partition := int32(mqwrapper.DefaultPartitionIdx)
+	key := kp.extractKey(message)
+	if key != nil {
+		// route the message by the key with the partitioner of kafka.
+		partition = kafka.PartitionAny
+	}
+	return kp.sendOrRoute(ctx, partition, key, message)
+}
+
+// SendSync sends the message and blocks until the delivery report of the message arrives, the offset of the message is returned.
+// Unlike Send, the SendSync calls of the producer are serialized, so a message is never enqueued before the previous one is confirmed,
+// which keeps the strict order on the failures and retries.
+// It costs the throughput that at most one message of SendSync is in flight and the batching of producer is defeated,
+// so it should only be used on the low throughput paths, Send and SendAsync pipeline the messages.
+// An error is returned in at-most-once mode, because the delivery report is disabled.
+func (kp *kafkaProducer) SendSync(ctx context.Context, message *mqcommon.ProducerMessage) (mqcommon.MessageID, error) {
+	if kp.durability == mqcommon.DurabilityAtMostOnce {
+		return nil, errors.Newf("kafka producer of topic %s can not send synchronously in at-most-once mode", kp.topic)
+	}
+	kp.syncMu.Lock()
+	defer kp.syncMu.Unlock()
+	return kp.Send(ctx, message)
+}
+
+// SendToPartition sends the message to the given partition of the topic, the partitioner is bypassed.
+// An error is returned if the partition doesn't exist in the metadata of the topic.
+// The partition count of the topic is cached, the metadata is only refreshed if the partition is unknown.
+// The key of key extractor is still attached to the message.
+func (kp *kafkaProducer) SendToPartition(ctx context.Context, partition int32, message *mqcommon.ProducerMessage) (mqcommon.MessageID, error) {
+	if err := kp.checkPartition(partition); err != nil {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.TotalLabel).Inc()
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+		return nil, err
+	}
+	msgID, err := kp.sendOrRoute(ctx, partition, kp.extractKey(message), message)
+	if isUnknownPartition(err) {
+		// the topic is changed, the partition count is refreshed by the next send.
+		kp.partitionCount.Store(0)
+	}
+	return msgID, err
+}
+
+// extractKey returns the key of the message, nil if there's no key extractor.
+func (kp *kafkaProducer) extractKey(message *mqcommon.ProducerMessage) []byte {
+	kp.mu.RLock()
+	extractor := kp.keyExtractor
+	kp.mu.RUnlock()
+	if extractor == nil {
+		return nil
+	}
+	return extractor(message)
+}
+
+// checkPartition checks whether the partition exists in the topic,
+// the metadata of the topic is fetched only if the partition is beyond the cached partition count.
+func (kp *kafkaProducer) checkPartition(partition int32) error {
+	if partition < 0 {
+		return errors.Newf("invalid partition %d of topic %s", partition, kp.topic)
+	}
+	if partition < kp.partitionCount.Load() {
+		return nil
+	}
+	count, err := kp.refreshPartitionCount()
+	if err != nil {
+		return err
+	}
+	if partition >= count {
+		return errors.Newf("partition %d of topic %s does not exist, the topic has %d partitions", partition, kp.topic, count)
+	}
+	return nil
+}
+
+// refreshPartitionCount fetches the partition count of the topic from the metadata and caches it.
+func (kp *kafkaProducer) refreshPartitionCount() (int32, error) {
+	var metadata *kafka.Metadata
+	err := kp.withProducer(func(p *kafka.Producer) (err error) {
+		metadata, err = p.GetMetadata(&kp.topic, false, timeout)
+		return err
+	})
+	if err != nil {
+		log.Warn("get kafka topic metadata failed", zap.String("topic", kp.topic), zap.Error(err))
+		return 0, err
+	}
+	topicMetadata, ok := metadata.Topics[kp.topic]
+	if !ok {
+		return 0, errors.Newf("topic %s not found in metadata", kp.topic)
+	}
+	if topicMetadata.Error.Code() != kafka.ErrNoError {
+		return 0, topicMetadata.Error
+	}
+	count := int32(len(topicMetadata.Partitions))
+	kp.partitionCount.Store(count)
+	return count, nil
+}
+
+// isUnknownPartition returns true if the error is the unknown partition error of kafka.
+func isUnknownPartition(err error) bool {
+	var kafkaErr kafka.Error
+	return errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrUnknownPartition
+}
+
+func (kp *kafkaProducer) send(ctx context.Context, partition int32, key []byte, message *mqcommon.ProducerMessage) (mqcommon.MessageID, error) {
 	start := timerecord.NewTimeRecorder("send msg to stream")
 	metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.TotalLabel).Inc()
 
-	if kp.isClosed {
+	if kp.closed() {
 		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
 		log.Error("kafka produce message fail because the producer has been closed", zap.String("topic", kp.topic))
 		return nil, common.NewIgnorableError(errors.New("kafka producer is closed"))
 	}
 
-	headers := make([]kafka.Header, 0, len(message.Properties))
-	for key, value := range message.Properties {
-		header := kafka.Header{Key: key, Value: []byte(value)}
-		headers = append(headers, header)
+	if err := kp.inflight.Acquire(ctx, kp.stopCh); err != nil {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+		return nil, err
 	}
+	// the delivery report is waited before return, so the slot is released on every path.
+	defer kp.inflight.Release()
+
+	headers := kp.messageHeaders(message)
 
-	resultCh := make(chan kafka.Event, 1)
-	err := kp.p.Produce(&kafka.Message{
-		TopicPartition: kafka.TopicPartition{Topic: &kp.topic, Partition: mqwrapper.DefaultPartitionIdx},
+	topicPartition := kafka.TopicPartition{Topic: &kp.topic, Partition: partition}
+	var resultCh chan kafka.Event
+	if kp.durability != mqcommon.DurabilityAtMostOnce {
+		resultCh = make(chan kafka.Event, 1)
+	}
+	err := kp.produce(ctx, &kafka.Message{
+		TopicPartition: topicPartition,
+		Key:            key,
 		Value:          message.Payload,
 		Headers:        headers,
+		Timestamp:      message.Timestamp,
 	}, resultCh)
 	if err != nil {
 		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
 		return nil, err
 	}
+	metrics.MsgStreamProduceMessageBytes.WithLabelValues(kp.topic).Observe(float64(len(message.Payload)))
+
+	if resultCh == nil {
+		// the delivery confirmation is not waited in at-most-once mode, so the offset is unknown.
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.SuccessLabel).Inc()
+		return &KafkaID{MessageID: int64(kafka.OffsetInvalid)}, nil
+	}
 
 	var m *kafka.Message
 	select {
@@ -78,18 +348,79 @@ func (kp *kafkaProducer) Send(ctx context.Context, message *mqcommon.ProducerMes
 	return &KafkaID{MessageID: int64(m.TopicPartition.Offset)}, nil
 }
 
+// produce puts the message into the local queue of the underlying producer, the full queue is handled by the queue full policy:
+// QueueFullError fails fast, QueueFullDrop returns ErrMessageDropped, and QueueFullBlock retries the enqueue
+// until the block timeout or the context is done.
+func (kp *kafkaProducer) produce(ctx context.Context, msg *kafka.Message, resultCh chan kafka.Event) error {
+	var deadline <-chan time.Time
+	if kp.queueFullPolicy == mqcommon.QueueFullBlock && kp.queueFullBlockTimeout > 0 {
+		timer := time.NewTimer(kp.queueFullBlockTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	backoff := queueFullInitialBackoff
+	for {
+		kp.mu.RLock()
+		err := kp.p.Produce(msg, resultCh)
+		kp.mu.RUnlock()
+		if err == nil || !isQueueFull(err) {
+			return err
+		}
+		switch kp.queueFullPolicy {
+		case mqcommon.QueueFullDrop:
+			metrics.MsgStreamProduceDroppedMessageTotal.WithLabelValues(kp.topic).Inc()
+			log.RatedWarn(10, "kafka message is dropped because the queue of producer is full", zap.String("topic", kp.topic))
+			return errors.Wrapf(ErrMessageDropped, "topic %s", kp.topic)
+		case mqcommon.QueueFullBlock:
+		default:
+			return err
+		}
+		// wait for the queued messages to be delivered.
+		select {
+		case <-ctx.Done():
+			// mark it with the context error, so the caller can tell the timeout of message.
+			return errors.Mark(errors.Wrapf(err, "context done while waiting for the queue of producer of topic %s: %s", kp.topic, ctx.Err()), ctx.Err())
+		case <-kp.stopCh:
+			return common.NewIgnorableError(errors.New("kafka producer is closed"))
+		case <-deadline:
+			return errors.Wrapf(err, "the queue of producer of topic %s is still full after %s", kp.topic, kp.queueFullBlockTimeout)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, queueFullMaxBackoff)
+	}
+}
+
+// isQueueFull returns true if the error is the queue full error of kafka producer.
+func isQueueFull(err error) bool {
+	var kafkaErr kafka.Error
+	return errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrQueueFull
+}
+
+// Close releases one reference of the producer, the producer is closed once the last reference is released.
 func (kp *kafkaProducer) Close() {
 	log := log.Ctx(context.TODO())
+	if kp.client != nil && !kp.client.releaseProducer(kp) {
+		// still used by the other callers.
+		return
+	}
 	kp.closeOnce.Do(func() {
+		kp.mu.Lock()
 		kp.isClosed = true
+		p, pKey := kp.p, kp.pKey
+		kp.mu.Unlock()
 
 		start := time.Now()
 		// flush in-flight msg within queue.
-		i := kp.p.Flush(10000)
+		i := p.Flush(10000)
 		if i > 0 {
 			log.Warn("There are still un-flushed outstanding events", zap.Int("event_num", i), zap.String("topic", kp.topic))
 		}
 
+		if kp.client != nil {
+			releaseKafkaProducer(pKey, p)
+		}
+		// the delivery reports of the messages that are not flushed never arrive.
+		kp.pending.drain(common.NewIgnorableError(errors.New("kafka producer is closed")))
 		close(kp.stopCh)
 		cost := time.Since(start).Milliseconds()
 		if cost > 500 {
@@ -4,13 +4,19 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
 	"github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
 )
 
 func TestKafkaProducer_SendSuccess(t *testing.T) {
@@ -87,3 +93,839 @@ func TestKafkaProducer_SendFailAfterClose(t *testing.T) {
 	time.Sleep(10 * time.Second)
 	assert.NotNil(t, err)
 }
+
+func TestKafkaProducer_CreateProducerIdempotent(t *testing.T) {
+	kafkaAddress := getKafkaBrokerList()
+	kc := NewKafkaClientInstance(kafkaAddress)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer1, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic})
+	assert.NoError(t, err)
+	producer2, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic})
+	assert.NoError(t, err)
+
+	// the callers of the same topic and options share the same producer.
+	assert.Same(t, producer1, producer2)
+	assert.Len(t, kc.topicProducers, 1)
+	assert.Equal(t, 2, producer1.(*kafkaProducer).refCnt)
+
+	// release the first reference, the producer should still work.
+	producer1.Close()
+	assert.False(t, producer2.(*kafkaProducer).closed())
+	assert.Equal(t, 1, producer2.(*kafkaProducer).refCnt)
+	msgID, err := producer2.Send(context.TODO(), &common.ProducerMessage{Payload: []byte{1}, Properties: map[string]string{}})
+	assert.NoError(t, err)
+	assert.NotNil(t, msgID)
+
+	// the producer of other options is not shared.
+	other, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, SchemaVersion: "v2"})
+	assert.NoError(t, err)
+	assert.NotSame(t, producer2, other)
+	assert.Len(t, kc.topicProducers, 2)
+	other.Close()
+
+	// release the last reference, the producer should be closed.
+	producer2.Close()
+	assert.True(t, producer2.(*kafkaProducer).closed())
+	assert.Len(t, kc.topicProducers, 0)
+	select {
+	case <-producer2.(*kafkaProducer).stopCh:
+	default:
+		assert.Fail(t, "the delivery channel should be closed")
+	}
+	_, err = producer2.Send(context.TODO(), &common.ProducerMessage{Payload: []byte{1}, Properties: map[string]string{}})
+	assert.Error(t, err)
+	// closing the closed producer again is a no-op.
+	producer2.Close()
+
+	// a new producer is created after the last reference is released.
+	producer3, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic})
+	assert.NoError(t, err)
+	assert.NotSame(t, producer1, producer3)
+	producer3.Close()
+}
+
+func TestKafkaProducer_KeyExtractor(t *testing.T) {
+	kafkaAddress := getKafkaBrokerList()
+	kc := NewKafkaClientInstance(kafkaAddress)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic})
+	assert.NoError(t, err)
+	defer producer.Close()
+	producer.(*kafkaProducer).SetKeyExtractor(func(msg *common.ProducerMessage) []byte {
+		return []byte(msg.Properties["pk"])
+	})
+
+	// the key extractor is owned by the producer, the producer of the same topic with other options doesn't attach the key.
+	other, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, SchemaVersion: "v2"})
+	assert.NoError(t, err)
+	defer other.Close()
+	unkeyed := 3
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < unkeyed; i++ {
+			_, err := other.Send(context.TODO(), &common.ProducerMessage{
+				Payload:    []byte("unkeyed"),
+				Properties: map[string]string{"pk": "a"},
+			})
+			assert.NoError(t, err)
+		}
+	}()
+
+	keys := []string{"a", "b", "c", "a", "b", "c", "a"}
+	for _, key := range keys {
+		_, err := producer.Send(context.TODO(), &common.ProducerMessage{
+			Payload:    []byte(key),
+			Properties: map[string]string{"pk": key},
+		})
+		assert.NoError(t, err)
+	}
+	wg.Wait()
+
+	// consume all partitions of the topic and check the partition of each key.
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": kafkaAddress,
+		"group.id":          fmt.Sprintf("test-group-%d", rand.Int()),
+	})
+	assert.NoError(t, err)
+	defer consumer.Close()
+	metadata, err := consumer.GetMetadata(&topic, false, timeout)
+	assert.NoError(t, err)
+	partitions := make([]kafka.TopicPartition, 0)
+	for _, partition := range metadata.Topics[topic].Partitions {
+		partitions = append(partitions, kafka.TopicPartition{Topic: &topic, Partition: partition.ID, Offset: kafka.OffsetBeginning})
+	}
+	assert.NoError(t, consumer.Assign(partitions))
+
+	keyPartitions := make(map[string]int32)
+	for i := 0; i < len(keys)+unkeyed; i++ {
+		msg, err := consumer.ReadMessage(10 * time.Second)
+		assert.NoError(t, err)
+		if string(msg.Value) == "unkeyed" {
+			assert.Nil(t, msg.Key)
+			assert.Equal(t, int32(mqwrapper.DefaultPartitionIdx), msg.TopicPartition.Partition)
+			continue
+		}
+		assert.Equal(t, msg.Value, msg.Key)
+		if partition, ok := keyPartitions[string(msg.Key)]; ok {
+			assert.Equal(t, partition, msg.TopicPartition.Partition)
+		}
+		keyPartitions[string(msg.Key)] = msg.TopicPartition.Partition
+	}
+	assert.Len(t, keyPartitions, 3)
+}
+
+func TestKafkaProducer_SendToPartition(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	subName := fmt.Sprintf("test-subname-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+
+	msgID, err := kafkaProd.SendToPartition(context.TODO(), 0, &common.ProducerMessage{
+		Payload:    []byte("partition-0"),
+		Properties: map[string]string{},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, msgID)
+	// the partition count is cached by the first send.
+	partitionCount := kafkaProd.partitionCount.Load()
+	assert.Greater(t, partitionCount, int32(0))
+
+	// the partition doesn't exist.
+	_, err = kafkaProd.SendToPartition(context.TODO(), 1024, &common.ProducerMessage{Payload: []byte("invalid")})
+	assert.ErrorContains(t, err, "does not exist")
+	_, err = kafkaProd.SendToPartition(context.TODO(), -1, &common.ProducerMessage{Payload: []byte("invalid")})
+	assert.Error(t, err)
+	assert.Equal(t, partitionCount, kafkaProd.partitionCount.Load())
+
+	// the stale cache is trusted without fetching the metadata, and it's dropped once the partition is unknown to kafka.
+	kafkaProd.partitionCount.Store(1024)
+	_, err = kafkaProd.SendToPartition(context.TODO(), 1000, &common.ProducerMessage{Payload: []byte("invalid")})
+	assert.True(t, isUnknownPartition(err))
+	assert.Zero(t, kafkaProd.partitionCount.Load())
+	_, err = kafkaProd.SendToPartition(context.TODO(), 1000, &common.ProducerMessage{Payload: []byte("invalid")})
+	assert.ErrorContains(t, err, "does not exist")
+	assert.Equal(t, partitionCount, kafkaProd.partitionCount.Load())
+
+	// the consumer is assigned to partition 0.
+	consumer := createConsumer(t, kc, topic, subName, common.SubscriptionPositionEarliest)
+	defer consumer.Close()
+	select {
+	case msg := <-consumer.Chan():
+		consumer.Ack(msg)
+		assert.Equal(t, []byte("partition-0"), msg.Payload())
+		assert.Equal(t, msgID.(*KafkaID).MessageID, msg.ID().(*KafkaID).MessageID)
+	case <-time.After(10 * time.Second):
+		assert.FailNow(t, "should not wait")
+	}
+}
+
+func TestKafkaProducer_SendAsync(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	subName := fmt.Sprintf("test-subname-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+	assert.Error(t, kafkaProd.SendAsync(context.TODO(), &common.ProducerMessage{Payload: []byte("no-callback")}, nil))
+
+	// the first callback blocks, which doesn't block the delivery reports of others.
+	type delivery struct {
+		payload string
+		id      common.MessageID
+		err     error
+	}
+	deliveries := make(chan delivery, 3)
+	blocked := make(chan struct{})
+	for k := 0; k < 3; k++ {
+		payload := fmt.Sprintf("async-%d", k)
+		err := kafkaProd.SendAsync(context.TODO(), &common.ProducerMessage{Payload: []byte(payload)}, func(id common.MessageID, err error) {
+			if payload == "async-0" {
+				<-blocked
+			}
+			deliveries <- delivery{payload: payload, id: id, err: err}
+		})
+		assert.NoError(t, err)
+	}
+	offsets := make(map[string]int64)
+	for k := 0; k < 2; k++ {
+		d := <-deliveries
+		assert.NoError(t, d.err)
+		offsets[d.payload] = d.id.(*KafkaID).MessageID
+	}
+	close(blocked)
+	d := <-deliveries
+	assert.Equal(t, "async-0", d.payload)
+	assert.NoError(t, d.err)
+	offsets[d.payload] = d.id.(*KafkaID).MessageID
+	assert.Len(t, offsets, 3)
+
+	// the offsets of callbacks match the consumed messages.
+	consumer := createConsumer(t, kc, topic, subName, common.SubscriptionPositionEarliest)
+	defer consumer.Close()
+	for k := 0; k < 3; k++ {
+		select {
+		case msg := <-consumer.Chan():
+			assert.Equal(t, offsets[string(msg.Payload())], msg.ID().(*KafkaID).MessageID)
+		case <-time.After(10 * time.Second):
+			assert.FailNow(t, "should not wait")
+		}
+	}
+
+	// the message is confirmed with an invalid offset in at-most-once mode.
+	atMostOnce, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, Durability: common.DurabilityAtMostOnce})
+	assert.NoError(t, err)
+	defer atMostOnce.Close()
+	err = atMostOnce.(*kafkaProducer).SendAsync(context.TODO(), &common.ProducerMessage{Payload: []byte("at-most-once")}, func(id common.MessageID, err error) {
+		deliveries <- delivery{payload: "at-most-once", id: id, err: err}
+	})
+	assert.NoError(t, err)
+	d = <-deliveries
+	assert.NoError(t, d.err)
+	assert.Equal(t, int64(kafka.OffsetInvalid), d.id.(*KafkaID).MessageID)
+}
+
+func TestKafkaProducer_SendFuture(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+
+	futures := make([]*DeliveryFuture, 0, 5)
+	for k := 0; k < 5; k++ {
+		futures = append(futures, kafkaProd.SendFuture(context.TODO(), &common.ProducerMessage{Payload: []byte(fmt.Sprintf("future-%d", k))}))
+	}
+	// the messages are sent to the same partition in order, so the offsets are consecutive.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for k, future := range futures {
+		id, err := future.Wait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(k), id.(*KafkaID).MessageID)
+		// the result is kept after it's ready.
+		<-future.Done()
+		again, err := future.Wait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, id, again)
+	}
+
+	// the offsets of futures match the consumed messages.
+	consumer := createConsumer(t, kc, topic, fmt.Sprintf("test-subname-%d", rand.Int()), common.SubscriptionPositionEarliest)
+	defer consumer.Close()
+	for k := 0; k < 5; k++ {
+		msg := <-consumer.Chan()
+		assert.Equal(t, fmt.Sprintf("future-%d", k), string(msg.Payload()))
+		assert.Equal(t, int64(k), msg.ID().(*KafkaID).MessageID)
+	}
+
+	// the future is resolved with the error if the message can't be sent.
+	producer.Close()
+	_, err := kafkaProd.SendFuture(context.TODO(), &common.ProducerMessage{Payload: []byte("closed")}).Wait(ctx)
+	assert.Error(t, err)
+
+	// the wait respects the context.
+	pending := newDeliveryFuture()
+	canceled, cancelWait := context.WithCancel(context.Background())
+	cancelWait()
+	_, err = pending.Wait(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestKafkaProducer_SendFutureClose(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	pp, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": getKafkaBrokerList()})
+	assert.NoError(t, err)
+	defer pp.Close()
+	// the delivery reports are held back, as if they arrive after the producer is closed.
+	reports := make(chan *kafka.Message, 3)
+	go func() {
+		for e := range pp.Events() {
+			if m, ok := e.(*kafka.Message); ok {
+				reports <- m
+			}
+		}
+	}()
+	kp := &kafkaProducer{p: pp, topic: topic, stopCh: make(chan struct{}), inflight: newInflightLimiter(topic, 3)}
+
+	futures := make([]*DeliveryFuture, 0, 3)
+	for k := 0; k < 3; k++ {
+		futures = append(futures, kp.SendFuture(context.TODO(), &common.ProducerMessage{Payload: []byte(fmt.Sprintf("pending-%d", k))}))
+	}
+	for _, future := range futures {
+		select {
+		case <-future.Done():
+			assert.FailNow(t, "the future should be pending")
+		default:
+		}
+	}
+
+	// the pending futures are resolved with the closed error once the producer is closed.
+	kp.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, future := range futures {
+		id, err := future.Wait(ctx)
+		assert.Nil(t, id)
+		assert.ErrorContains(t, err, "kafka producer is closed")
+	}
+	assert.Zero(t, kp.inflight.Len())
+
+	// the late delivery reports are ignored.
+	for k := 0; k < 3; k++ {
+		select {
+		case m := <-reports:
+			assert.True(t, dispatchDelivery(m))
+		case <-time.After(10 * time.Second):
+			assert.FailNow(t, "the delivery report should arrive")
+		}
+	}
+	_, err = kp.SendFuture(context.TODO(), &common.ProducerMessage{Payload: []byte("closed")}).Wait(ctx)
+	assert.ErrorContains(t, err, "kafka producer is closed")
+}
+
+func TestKafkaProducer_SendSync(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+
+	for k := 0; k < 5; k++ {
+		id, err := kafkaProd.SendSync(context.TODO(), &common.ProducerMessage{Payload: []byte(fmt.Sprintf("sync-%d", k))})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(k), id.(*KafkaID).MessageID)
+		// the message is confirmed, nothing is left in the underlying producer.
+		assert.Zero(t, kafkaProd.getProducer().Len())
+	}
+
+	// the concurrent calls are serialized.
+	ids := make(chan int64, 5)
+	for k := 0; k < 5; k++ {
+		go func() {
+			id, err := kafkaProd.SendSync(context.TODO(), &common.ProducerMessage{Payload: []byte("concurrent")})
+			assert.NoError(t, err)
+			ids <- id.(*KafkaID).MessageID
+		}()
+	}
+	offsets := make(map[int64]struct{})
+	for k := 0; k < 5; k++ {
+		offsets[<-ids] = struct{}{}
+	}
+	assert.Len(t, offsets, 5)
+
+	// the delivery report is disabled in at-most-once mode.
+	atMostOnce, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, Durability: common.DurabilityAtMostOnce})
+	assert.NoError(t, err)
+	defer atMostOnce.Close()
+	_, err = atMostOnce.(*kafkaProducer).SendSync(context.TODO(), &common.ProducerMessage{Payload: []byte("at-most-once")})
+	assert.Error(t, err)
+}
+
+func TestKafkaProducer_MaxInflightMessages(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	inflightGauge := func() float64 {
+		m := &dto.Metric{}
+		metrics.MsgStreamProduceInflightMessages.WithLabelValues(topic).(prometheus.Metric).Write(m)
+		return m.GetGauge().GetValue()
+	}
+
+	producer, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, MaxInflightMessages: 2})
+	assert.NoError(t, err)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+
+	// saturate the limit with the messages whose delivery reports are not arrived yet.
+	ctx := context.TODO()
+	assert.NoError(t, kafkaProd.inflight.Acquire(ctx, kafkaProd.stopCh))
+	assert.NoError(t, kafkaProd.inflight.Acquire(ctx, kafkaProd.stopCh))
+	assert.Equal(t, 2, kafkaProd.inflight.Len())
+	assert.Equal(t, float64(2), inflightGauge())
+
+	// the send is bounded by the context while the limit is reached.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = kafkaProd.Send(timeoutCtx, &common.ProducerMessage{Payload: []byte("timeout")})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	err = kafkaProd.SendAsync(timeoutCtx, &common.ProducerMessage{Payload: []byte("timeout")}, func(common.MessageID, error) {})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the blocked sends are unblocked once the delivery reports drain.
+	sent := make(chan error, 2)
+	go func() {
+		_, err := kafkaProd.Send(ctx, &common.ProducerMessage{Payload: []byte("sync")})
+		sent <- err
+	}()
+	go func() {
+		_, err := kafkaProd.SendFuture(ctx, &common.ProducerMessage{Payload: []byte("async")}).Wait(ctx)
+		sent <- err
+	}()
+	select {
+	case <-sent:
+		t.Fatal("send should be blocked by the in-flight limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+	kafkaProd.inflight.Release()
+	kafkaProd.inflight.Release()
+	assert.NoError(t, <-sent)
+	assert.NoError(t, <-sent)
+	assert.Eventually(t, func() bool {
+		return kafkaProd.inflight.Len() == 0 && inflightGauge() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the in-flight messages are never more than the limit.
+	futures := make([]*DeliveryFuture, 0, 10)
+	for k := 0; k < 10; k++ {
+		futures = append(futures, kafkaProd.SendFuture(ctx, &common.ProducerMessage{Payload: []byte(fmt.Sprintf("pipelined-%d", k))}))
+		assert.LessOrEqual(t, kafkaProd.inflight.Len(), 2)
+	}
+	for _, future := range futures {
+		_, err := future.Wait(ctx)
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return kafkaProd.inflight.Len() == 0 && inflightGauge() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the limit is released by the delivery reports, which are disabled in at-most-once mode.
+	_, err = kc.CreateProducer(ctx, common.ProducerOptions{Topic: topic, Durability: common.DurabilityAtMostOnce, MaxInflightMessages: 2})
+	assert.Error(t, err)
+}
+
+func TestKafkaProducer_SendWithTimestamp(t *testing.T) {
+	kafkaAddress := getKafkaBrokerList()
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+
+	// the topic of mock cluster uses CreateTime, the timestamp set by producer is kept.
+	createTime := time.UnixMilli(time.Now().Add(-time.Hour).UnixMilli())
+	_, err := producer.Send(context.TODO(), &common.ProducerMessage{
+		Payload:   []byte("with-timestamp"),
+		Timestamp: createTime,
+	})
+	assert.NoError(t, err)
+	_, err = producer.Send(context.TODO(), &common.ProducerMessage{
+		Payload: []byte("without-timestamp"),
+	})
+	assert.NoError(t, err)
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": kafkaAddress,
+		"group.id":          fmt.Sprintf("test-group-%d", rand.Int()),
+	})
+	assert.NoError(t, err)
+	defer consumer.Close()
+	assert.NoError(t, consumer.Assign([]kafka.TopicPartition{{Topic: &topic, Partition: 0, Offset: kafka.OffsetBeginning}}))
+
+	msg, err := consumer.ReadMessage(10 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("with-timestamp"), msg.Value)
+	assert.Equal(t, kafka.TimestampCreateTime, msg.TimestampType)
+	assert.True(t, createTime.Equal(msg.Timestamp))
+
+	msg, err = consumer.ReadMessage(10 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("without-timestamp"), msg.Value)
+	assert.True(t, msg.Timestamp.After(createTime))
+}
+
+func TestKafkaProducer_SchemaVersion(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	versioned, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, SchemaVersion: "v2"})
+	assert.NoError(t, err)
+	defer versioned.Close()
+	// the producers of different schema versions are not shared.
+	unversioned := createProducer(t, kc, topic)
+	defer unversioned.Close()
+	assert.NotSame(t, versioned, unversioned)
+
+	_, err = versioned.Send(context.TODO(), &common.ProducerMessage{
+		Payload:    []byte("versioned"),
+		Properties: map[string]string{"key": "value"},
+	})
+	assert.NoError(t, err)
+	_, err = unversioned.Send(context.TODO(), &common.ProducerMessage{Payload: []byte("unversioned")})
+	assert.NoError(t, err)
+
+	consumer := createConsumer(t, kc, topic, fmt.Sprintf("test-group-%d", rand.Int()), common.SubscriptionPositionEarliest)
+	defer consumer.Close()
+
+	msg := <-consumer.Chan()
+	assert.Equal(t, []byte("versioned"), msg.Payload())
+	version, ok := SchemaVersion(msg)
+	assert.True(t, ok)
+	assert.Equal(t, "v2", version)
+	assert.Equal(t, "value", msg.Properties()["key"])
+
+	msg = <-consumer.Chan()
+	assert.Equal(t, []byte("unversioned"), msg.Payload())
+	_, ok = SchemaVersion(msg)
+	assert.False(t, ok)
+}
+
+func TestKafkaProducer_RotateProducer(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	subName := fmt.Sprintf("test-subname-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+	oldProducer := kafkaProd.getProducer()
+	// the producer of the same topic with other options is not rotated.
+	other, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, SchemaVersion: "v2"})
+	assert.NoError(t, err)
+	defer other.Close()
+
+	// rotate the producer while producing.
+	total := 100
+	rotated := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < total; i++ {
+			if i == total/2 {
+				<-rotated
+			}
+			_, err := producer.Send(context.TODO(), &common.ProducerMessage{
+				Payload:    IntToBytes(i),
+				Properties: map[string]string{},
+			})
+			assert.NoError(t, err)
+		}
+	}()
+	// the client id makes the underlying producer dedicated to the test.
+	overrides := kafka.ConfigMap{"compression.codec": "lz4", "client.id": topic}
+	assert.NoError(t, kafkaProd.RotateProducer(overrides))
+	close(rotated)
+	<-done
+
+	// the new produces use the new config.
+	sharedProducer := func(overrides kafka.ConfigMap) (*sharedKafkaProducer, bool) {
+		producersMu.Lock()
+		defer producersMu.Unlock()
+		shared, ok := producers[producerKey(overrides)]
+		if !ok {
+			return nil, false
+		}
+		return &sharedKafkaProducer{p: shared.p, refCnt: shared.refCnt}, true
+	}
+	newProducer := kafkaProd.getProducer()
+	assert.NotSame(t, oldProducer, newProducer)
+	assert.Same(t, oldProducer, other.(*kafkaProducer).getProducer())
+	shared, ok := sharedProducer(overrides)
+	assert.True(t, ok)
+	assert.Same(t, shared.p, newProducer)
+	assert.Equal(t, 1, shared.refCnt)
+
+	// rotate with the same config is a no-op.
+	assert.NoError(t, kafkaProd.RotateProducer(overrides))
+	assert.Same(t, newProducer, kafkaProd.getProducer())
+	shared, _ = sharedProducer(overrides)
+	assert.Equal(t, 1, shared.refCnt)
+
+	// the rotated producer is closed once it's rotated away, since nothing else uses it.
+	assert.NoError(t, kafkaProd.RotateProducer(nil))
+	assert.Same(t, oldProducer, kafkaProd.getProducer())
+	_, ok = sharedProducer(overrides)
+	assert.False(t, ok)
+	// the old producer is still used by both producers.
+	shared, ok = sharedProducer(nil)
+	assert.True(t, ok)
+	assert.Same(t, oldProducer, shared.p)
+	assert.GreaterOrEqual(t, shared.refCnt, 2)
+
+	// the producer can not be rotated after closed.
+	closed, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, SchemaVersion: "v3"})
+	assert.NoError(t, err)
+	closed.Close()
+	assert.Error(t, closed.(*kafkaProducer).RotateProducer(overrides))
+	_, ok = sharedProducer(overrides)
+	assert.False(t, ok)
+
+	// all messages are delivered in order.
+	consumer := createConsumer(t, kc, topic, subName, common.SubscriptionPositionEarliest)
+	defer consumer.Close()
+	for i := 0; i < total; i++ {
+		select {
+		case msg := <-consumer.Chan():
+			consumer.Ack(msg)
+			assert.Equal(t, i, BytesToInt(msg.Payload()))
+		case <-time.After(10 * time.Second):
+			assert.FailNow(t, "should not wait")
+		}
+	}
+}
+
+func TestKafkaProducer_RotateProducerKeepOptions(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer, err := kc.CreateProducer(context.TODO(), common.ProducerOptions{Topic: topic, Durability: common.DurabilityExactlyOnce, LingerMs: 5})
+	assert.NoError(t, err)
+	defer producer.Close()
+	kafkaProd := producer.(*kafkaProducer)
+
+	// the overrides of durability and linger survive the rotation.
+	assert.NoError(t, kafkaProd.RotateProducer(kafka.ConfigMap{"compression.codec": "lz4", "client.id": topic}))
+	expected := kafka.ConfigMap{
+		"compression.codec":  "lz4",
+		"client.id":          topic,
+		"acks":               "all",
+		"enable.idempotence": true,
+		"linger.ms":          5,
+	}
+	assert.Equal(t, producerKey(expected), kafkaProd.pKey)
+
+	// the new overrides take precedence.
+	assert.NoError(t, kafkaProd.RotateProducer(kafka.ConfigMap{"linger.ms": 10, "client.id": topic}))
+	assert.Contains(t, kafkaProd.pKey, "acks=all")
+	assert.Contains(t, kafkaProd.pKey, "enable.idempotence=true")
+	assert.Contains(t, kafkaProd.pKey, "linger.ms=10")
+
+	msgID, err := producer.Send(context.TODO(), &common.ProducerMessage{Payload: []byte("rotated")})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, msgID.(*KafkaID).MessageID, int64(0))
+}
+
+func TestKafkaProducer_MessageSizeMetrics(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+
+	sizes := []int{0, 100, 4096, 1 << 20}
+	total := 0
+	for _, size := range sizes {
+		total += size
+		_, err := producer.Send(context.TODO(), &common.ProducerMessage{Payload: make([]byte, size)})
+		assert.NoError(t, err)
+	}
+
+	m := &dto.Metric{}
+	err := metrics.MsgStreamProduceMessageBytes.WithLabelValues(topic).(prometheus.Metric).Write(m)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(sizes)), m.GetHistogram().GetSampleCount())
+	assert.Equal(t, float64(total), m.GetHistogram().GetSampleSum())
+	// the 1MB message must fall into a larger bucket than the small ones.
+	var smallBucketCount uint64
+	for _, bucket := range m.GetHistogram().GetBucket() {
+		if bucket.GetUpperBound() >= 4096 {
+			smallBucketCount = bucket.GetCumulativeCount()
+			break
+		}
+	}
+	assert.Equal(t, uint64(3), smallBucketCount)
+}
+
+func TestKafkaProducer_QueueFullPolicy(t *testing.T) {
+	kafkaAddress := getKafkaBrokerList()
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+
+	// the queue only holds one message, which is lingered in the queue to saturate it.
+	newProducer := func(policy common.QueueFullPolicy, blockTimeout time.Duration) *kafkaProducer {
+		pp, err := kafka.NewProducer(&kafka.ConfigMap{
+			"bootstrap.servers":            kafkaAddress,
+			"queue.buffering.max.messages": 1,
+			"linger.ms":                    300,
+			"go.delivery.reports":          false,
+		})
+		assert.NoError(t, err)
+		return &kafkaProducer{
+			p:                     pp,
+			stopCh:                make(chan struct{}),
+			topic:                 topic,
+			durability:            common.DurabilityAtMostOnce,
+			queueFullPolicy:       policy,
+			queueFullBlockTimeout: blockTimeout,
+		}
+	}
+	saturate := func(producer *kafkaProducer) {
+		_, err := producer.Send(context.TODO(), &common.ProducerMessage{Payload: []byte{1}})
+		assert.NoError(t, err)
+	}
+	msg := &common.ProducerMessage{Payload: []byte{2}}
+
+	t.Run("error", func(t *testing.T) {
+		producer := newProducer(common.QueueFullError, 0)
+		defer producer.Close()
+		saturate(producer)
+		_, err := producer.Send(context.TODO(), msg)
+		assert.True(t, isQueueFull(err))
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		producer := newProducer(common.QueueFullDrop, 0)
+		defer producer.Close()
+		saturate(producer)
+		id, err := producer.Send(context.TODO(), msg)
+		assert.ErrorIs(t, err, ErrMessageDropped)
+		assert.Nil(t, id)
+		err = producer.SendAsync(context.TODO(), msg, func(id common.MessageID, err error) {
+			assert.Fail(t, "the callback of dropped message should not be called")
+		})
+		assert.ErrorIs(t, err, ErrMessageDropped)
+
+		m := &dto.Metric{}
+		err = metrics.MsgStreamProduceDroppedMessageTotal.WithLabelValues(topic).(prometheus.Metric).Write(m)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(2), m.GetCounter().GetValue())
+	})
+
+	t.Run("block", func(t *testing.T) {
+		// the message is queued once the lingered message is sent.
+		producer := newProducer(common.QueueFullBlock, 10*time.Second)
+		defer producer.Close()
+		saturate(producer)
+		_, err := producer.Send(context.TODO(), msg)
+		assert.NoError(t, err)
+
+		// the queue is saturated by the blocked message, and it's still full after the block timeout.
+		producer.queueFullBlockTimeout = 10 * time.Millisecond
+		start := time.Now()
+		_, err = producer.Send(context.TODO(), msg)
+		assert.True(t, isQueueFull(err))
+		assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+		// the block is interrupted by the context.
+		producer.queueFullBlockTimeout = 0
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = producer.Send(ctx, msg)
+		assert.True(t, isQueueFull(err))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		producer := newProducer(common.QueueFullError, 0)
+		defer producer.Close()
+		saturate(producer)
+
+		// the error policy fails fast even if the context has a deadline.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err := producer.Send(ctx, msg)
+		assert.True(t, isQueueFull(err))
+		assert.False(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, time.Since(start), time.Second)
+
+		// the block policy without timeout retries the enqueue until the deadline of message.
+		producer.queueFullPolicy = common.QueueFullBlock
+		ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start = time.Now()
+		_, err = producer.Send(ctx, msg)
+		assert.True(t, isQueueFull(err))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+		// the message is queued before the deadline once the lingered message is sent.
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err = producer.Send(ctx, msg)
+		assert.NoError(t, err)
+
+		// the canceled context fails once the queue is full.
+		ctx, cancel = context.WithCancel(context.Background())
+		cancel()
+		_, err = producer.Send(ctx, msg)
+		assert.True(t, isQueueFull(err))
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
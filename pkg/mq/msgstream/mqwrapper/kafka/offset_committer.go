@@ -0,0 +1,112 @@
+package kafka
+
+import (
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+)
+
+// newOffsetCommitter creates a new offset committer and starts the background flush.
+func newOffsetCommitter(topic string, commit func(offset kafka.Offset) error, interval time.Duration, batchSize int) *offsetCommitter {
+	c := &offsetCommitter{
+		topic:     topic,
+		commit:    commit,
+		interval:  interval,
+		batchSize: batchSize,
+		marked:    kafka.OffsetInvalid,
+		committed: kafka.OffsetInvalid,
+		notifyCh:  make(chan struct{}, 1),
+		closeCh:   make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.background()
+	return c
+}
+
+// offsetCommitter marks the acked offsets in memory, and flushes the latest one to the broker
+// on the interval or when the count of marked messages reaches the batch size.
+// So the offset is not committed for every message, but the messages after the last flush may be reprocessed after restart.
+type offsetCommitter struct {
+	topic     string
+	commit    func(offset kafka.Offset) error
+	interval  time.Duration
+	batchSize int // 0 means only flush by interval.
+
+	mu        sync.Mutex
+	marked    kafka.Offset // the next offset to consume, which is the offset to commit.
+	committed kafka.Offset
+	pending   int // the count of marked messages since the last flush.
+
+	notifyCh chan struct{}
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Mark marks the message at the offset is consumed.
+func (c *offsetCommitter) Mark(offset kafka.Offset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if offset+1 > c.marked {
+		c.marked = offset + 1
+	}
+	c.pending++
+	if c.batchSize > 0 && c.pending >= c.batchSize {
+		select {
+		case c.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close stops the background flush and flushes the marked offset for the last time.
+func (c *offsetCommitter) Close() {
+	close(c.closeCh)
+	c.wg.Wait()
+	c.flush()
+}
+
+func (c *offsetCommitter) background() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closeCh:
+			return
+		case <-ticker.C:
+			c.flush()
+		case <-c.notifyCh:
+			c.flush()
+		}
+	}
+}
+
+// flush commits the latest marked offset if it's not committed.
+func (c *offsetCommitter) flush() {
+	c.mu.Lock()
+	marked := c.marked
+	if marked <= c.committed {
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	if err := c.commit(marked); err != nil {
+		// the offset will be committed at next flush.
+		log.Warn("kafka consumer commit offset failed", zap.String("topic", c.topic), zap.Int64("offset", int64(marked)), zap.Error(err))
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if marked > c.committed {
+		c.committed = marked
+	}
+	c.pending = 0
+}
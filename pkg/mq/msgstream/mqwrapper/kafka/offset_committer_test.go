@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedCommits struct {
+	mu      sync.Mutex
+	offsets []kafka.Offset
+	err     error
+}
+
+func (r *recordedCommits) commit(offset kafka.Offset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.offsets = append(r.offsets, offset)
+	return nil
+}
+
+func (r *recordedCommits) get() []kafka.Offset {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]kafka.Offset{}, r.offsets...)
+}
+
+func TestOffsetCommitter_FlushByInterval(t *testing.T) {
+	commits := &recordedCommits{}
+	c := newOffsetCommitter("test", commits.commit, 100*time.Millisecond, 0)
+
+	for i := 0; i < 1000; i++ {
+		c.Mark(kafka.Offset(i))
+	}
+	// a single commit covers the latest offset.
+	assert.Eventually(t, func() bool {
+		return len(commits.get()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, []kafka.Offset{1000}, commits.get())
+
+	// nothing new to flush on close.
+	c.Close()
+	assert.Equal(t, []kafka.Offset{1000}, commits.get())
+}
+
+func TestOffsetCommitter_FlushByBatchSize(t *testing.T) {
+	commits := &recordedCommits{}
+	c := newOffsetCommitter("test", commits.commit, time.Hour, 10)
+	defer c.Close()
+
+	for i := 0; i < 9; i++ {
+		c.Mark(kafka.Offset(i))
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, commits.get())
+
+	c.Mark(9)
+	assert.Eventually(t, func() bool {
+		offsets := commits.get()
+		return len(offsets) == 1 && offsets[0] == 10
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestOffsetCommitter_FlushOnClose(t *testing.T) {
+	commits := &recordedCommits{err: errors.New("commit failed")}
+	c := newOffsetCommitter("test", commits.commit, 10*time.Millisecond, 0)
+
+	c.Mark(5)
+	// the offset is kept and retried after the commit failure.
+	time.Sleep(50 * time.Millisecond)
+	commits.mu.Lock()
+	commits.err = nil
+	commits.mu.Unlock()
+	c.Mark(3)
+	c.Close()
+	offsets := commits.get()
+	assert.NotEmpty(t, offsets)
+	assert.Equal(t, kafka.Offset(6), offsets[len(offsets)-1])
+}
@@ -0,0 +1,55 @@
+package kafka
+
+import (
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+)
+
+// OffsetGap is a jump between the offsets of two consecutive consumed messages,
+// the messages in between are missing, e.g. removed by the compaction or the retention of topic,
+// or occupied by the transaction markers.
+type OffsetGap struct {
+	Topic string
+	From  kafka.Offset // the offset of the previous consumed message.
+	To    kafka.Offset // the offset of the current consumed message.
+}
+
+// Missing returns the count of offsets that are skipped by the gap.
+func (g OffsetGap) Missing() int64 {
+	return int64(g.To - g.From - 1)
+}
+
+// OffsetGapHandler is called in the receive path when an offset gap is detected, so it should not block.
+type OffsetGapHandler func(gap OffsetGap)
+
+// EnableOffsetGapDetection makes the consumer compare the offsets of consecutive consumed messages,
+// the gap is counted by metric and reported to the handler if it's not nil.
+// The detection is disabled by default, it should be enabled before Chan is called.
+func (kc *Consumer) EnableOffsetGapDetection(handler OffsetGapHandler) {
+	kc.gapDetection = true
+	kc.gapHandler = handler
+	kc.lastOffset = kafka.OffsetInvalid
+}
+
+// detectOffsetGap checks the offset of the consumed message against the previous one.
+func (kc *Consumer) detectOffsetGap(msg *kafka.Message) {
+	if !kc.gapDetection {
+		return
+	}
+	offset := msg.TopicPartition.Offset
+	lastOffset := kc.lastOffset
+	kc.lastOffset = offset
+	if lastOffset == kafka.OffsetInvalid || offset <= lastOffset+1 {
+		return
+	}
+	gap := OffsetGap{Topic: kc.topic, From: lastOffset, To: offset}
+	log.Warn("offset gap is detected in kafka consumer", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID),
+		zap.Any("from", gap.From), zap.Any("to", gap.To), zap.Int64("missing", gap.Missing()))
+	metrics.MsgStreamConsumeOffsetGapTotal.WithLabelValues(kc.topic).Inc()
+	if kc.gapHandler != nil {
+		kc.gapHandler(gap)
+	}
+}
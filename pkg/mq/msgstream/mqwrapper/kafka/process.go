@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+// Process receives the messages from Chan in order and calls fn on each of them,
+// the offset of the message is committed synchronously only after fn returns nil, for the at-least-once processing.
+// It stops at the first message that fn fails on, and returns the error of fn without committing the message,
+// so the failed message and the ones after it are redelivered to the consumer of the same group that resumes from the committed offset.
+// A message may still be processed more than once if the consumer crashes or the commit fails after fn returns,
+// so fn should be idempotent.
+// Nothing is committed if the offsets are stored by the caller, then fn should store the offset of the message by itself.
+// It blocks until fn fails, the commit fails, the context is done or the consumer is closed.
+// It should not be used along with Chan or ReceiveBatch, which receive from the same channel.
+func (kc *Consumer) Process(ctx context.Context, fn func(common.Message) error) error {
+	if !kc.hasAssign {
+		return errors.New("can not process messages of a kafka consumer without assign")
+	}
+	msgChan := kc.Chan()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgChan:
+			if !ok {
+				return errors.New("kafka consumer is closed")
+			}
+			offset := msg.(*kafkaMessage).msg.TopicPartition.Offset
+			err := fn(msg)
+			MessageDone(msg)
+			if err != nil {
+				log.Warn("process kafka message failed, the offset is not committed",
+					zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Int64("offset", int64(offset)), zap.Error(err))
+				return errors.Wrapf(err, "process message at offset %d of topic %s", offset, kc.topic)
+			}
+			// the committed offset is the next message to consume.
+			if err := kc.commitOffset(offset + 1); err != nil {
+				return errors.Wrapf(err, "commit offset %d of topic %s", offset+1, kc.topic)
+			}
+		}
+	}
+}
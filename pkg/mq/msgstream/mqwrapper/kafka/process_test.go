@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestKafkaConsumer_Process(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+	data := []int{0, 1, 2, 3, 4}
+	testKafkaConsumerProduceData(t, topic, data, []string{"0", "1", "2", "3", "4"})
+
+	// the consumer of the group restarts from the committed offset.
+	// the auto commit is disabled like the consumer of client, so only the processed messages are committed.
+	config := createConfig(groupID)
+	config.SetKey("enable.auto.commit", false)
+	resume := func() *Consumer {
+		consumer, err := newKafkaConsumer(config, 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+		assert.NoError(t, err)
+		committed, err := consumer.c.Committed([]kafka.TopicPartition{{Topic: &topic, Partition: 0}}, timeout)
+		assert.NoError(t, err)
+		offset := int64(committed[0].Offset)
+		if offset < 0 {
+			offset = 0
+		}
+		assert.NoError(t, consumer.Seek(&KafkaID{MessageID: offset}, true))
+		return consumer
+	}
+
+	// fn fails on the message 2, the messages before it are committed.
+	consumer := resume()
+	processed := make([]int, 0)
+	errProcess := errors.New("process failed")
+	err := consumer.Process(context.TODO(), func(msg mqcommon.Message) error {
+		v := BytesToInt(msg.Payload())
+		if v == 2 {
+			return errProcess
+		}
+		processed = append(processed, v)
+		return nil
+	})
+	assert.ErrorIs(t, err, errProcess)
+	assert.Equal(t, []int{0, 1}, processed)
+	consumer.Close()
+
+	// the failed message is redelivered rather than skipped after the consumer is recreated.
+	consumer = resume()
+	processed = processed[:0]
+	ctx, cancel := context.WithCancel(context.Background())
+	err = consumer.Process(ctx, func(msg mqcommon.Message) error {
+		processed = append(processed, BytesToInt(msg.Payload()))
+		if len(processed) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []int{2, 3, 4}, processed)
+	consumer.Close()
+
+	// nothing is redelivered once all messages are committed.
+	consumer = resume()
+	defer consumer.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	err = consumer.Process(ctx, func(msg mqcommon.Message) error {
+		t.Errorf("unexpected redelivered message %d", BytesToInt(msg.Payload()))
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the consumer should be assigned.
+	unassigned, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer unassigned.Close()
+	assert.Error(t, unassigned.Process(context.TODO(), func(mqcommon.Message) error { return nil }))
+}
@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+)
+
+// reconfigurePollInterval is the max duration of one poll of the consumer,
+// which bounds how long the reconfiguration waits for the in-progress poll.
+const reconfigurePollInterval = 100 * time.Millisecond
+
+// ReconfigureConsumer replaces the underlying consumer with a new one created by the current config merged with newConfig,
+// e.g. to change the prefetch at runtime, the new one resumes from the position of the old one,
+// so no message is lost or consumed twice, the messages already fetched by Chan are still delivered.
+// The old consumer is kept if the new one can't be created or assigned.
+// The group id can't be changed, and the consumer in the catch up phase can't be reconfigured.
+func (kc *Consumer) ReconfigureConsumer(newConfig map[string]string) error {
+	if _, ok := newConfig["group.id"]; ok {
+		return errors.New("can not change the group id of a kafka consumer")
+	}
+	if !kc.hasAssign {
+		return errors.Newf("can not reconfigure a kafka consumer of topic %s without assign", kc.topic)
+	}
+	if kc.isCatchingUp() {
+		return errors.Newf("can not reconfigure a kafka consumer of topic %s that is catching up", kc.topic)
+	}
+	config := cloneKafkaConfig(*kc.config)
+	for k, v := range newConfig {
+		config.SetKey(k, v)
+	}
+	if kc.manualOffsetStorage {
+		config.SetKey("enable.auto.commit", false)
+		config.SetKey("enable.auto.offset.store", false)
+	}
+
+	logger := log.With(zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Any("config", newConfig))
+	// the lock is held until the swap, so no message is polled from the old consumer after the position is captured.
+	kc.mu.Lock()
+	if kc.closed {
+		kc.mu.Unlock()
+		return errors.Newf("can not reconfigure a closed kafka consumer of topic %s", kc.topic)
+	}
+	positions, err := kc.c.Position([]kafka.TopicPartition{{Topic: &kc.topic, Partition: mqwrapper.DefaultPartitionIdx}})
+	if err != nil {
+		kc.mu.Unlock()
+		return errors.Wrapf(err, "get position of kafka consumer of topic %s", kc.topic)
+	}
+	offset := positions[0].Offset
+	if offset < 0 {
+		// nothing is consumed since the last assignment.
+		offset = kc.assigned
+	}
+	c, err := kafka.NewConsumer(config)
+	if err != nil {
+		kc.mu.Unlock()
+		logger.Warn("create reconfigured kafka consumer failed, keep the old one", zap.Error(err))
+		return errors.Wrapf(err, "create reconfigured kafka consumer of topic %s", kc.topic)
+	}
+	if err := c.Assign([]kafka.TopicPartition{{Topic: &kc.topic, Partition: mqwrapper.DefaultPartitionIdx, Offset: offset}}); err != nil {
+		kc.mu.Unlock()
+		c.Close()
+		logger.Warn("assign reconfigured kafka consumer failed, keep the old one", zap.Any("offset", offset), zap.Error(err))
+		return errors.Wrapf(err, "assign reconfigured kafka consumer of topic %s to offset %d", kc.topic, offset)
+	}
+	old := kc.c
+	kc.c, kc.config, kc.assigned = c, config, offset
+	kc.mu.Unlock()
+
+	if err := old.Close(); err != nil {
+		logger.Warn("close the old kafka consumer failed", zap.Error(err))
+	}
+	logger.Info("kafka consumer is reconfigured", zap.Any("offset", offset))
+	return nil
+}
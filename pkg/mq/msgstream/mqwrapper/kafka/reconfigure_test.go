@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestKafkaConsumer_Reconfigure(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+
+	data := make([]int, 0, 10)
+	traces := make([]string, 0, 10)
+	for i := 0; i < 10; i++ {
+		data = append(data, i)
+		traces = append(traces, fmt.Sprint(i))
+	}
+	testKafkaConsumerProduceData(t, topic, data, traces)
+
+	consumer, err := newKafkaConsumer(createConfig(groupID), 1, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	// can not reconfigure without assign.
+	assert.Error(t, consumer.ReconfigureConsumer(map[string]string{"fetch.wait.max.ms": "10"}))
+
+	// nothing is consumed after the seek, the seek position is resumed.
+	assert.NoError(t, consumer.Seek(&KafkaID{MessageID: 2}, true))
+	assert.NoError(t, consumer.ReconfigureConsumer(map[string]string{"fetch.wait.max.ms": "10"}))
+	waitMax, err := consumer.config.Get("fetch.wait.max.ms", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", waitMax)
+
+	next := 2
+	consume := func(n int) {
+		for i := 0; i < n; i++ {
+			select {
+			case msg := <-consumer.Chan():
+				assert.Equal(t, next, BytesToInt(msg.Payload()))
+				assert.Equal(t, int64(next), msg.ID().(*KafkaID).MessageID)
+				next++
+			case <-time.After(10 * time.Second):
+				t.Fatalf("message %d is not consumed", next)
+			}
+		}
+	}
+	consume(3)
+
+	// reconfigure mid-stream, the consumption resumes at the exact position without loss or duplication.
+	assert.NoError(t, consumer.ReconfigureConsumer(map[string]string{"fetch.wait.max.ms": "20", "fetch.min.bytes": "1"}))
+	waitMax, err = consumer.config.Get("fetch.wait.max.ms", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "20", waitMax)
+	consume(2)
+
+	// the old consumer is kept if the new config is invalid or changes the group.
+	assert.Error(t, consumer.ReconfigureConsumer(map[string]string{"no.such.property": "1"}))
+	assert.Error(t, consumer.ReconfigureConsumer(map[string]string{"group.id": "another-group"}))
+	consume(3)
+	select {
+	case msg := <-consumer.Chan():
+		t.Fatalf("unexpected message %d consumed", BytesToInt(msg.Payload()))
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// can not reconfigure a closed consumer.
+	consumer.Close()
+	assert.Error(t, consumer.ReconfigureConsumer(map[string]string{"fetch.wait.max.ms": "10"}))
+}
@@ -0,0 +1,246 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/common"
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+)
+
+const (
+	retryOriginTopicHeader = "kafka-retry-origin-topic" // the topic that the message is re-produced to.
+	retryAttemptHeader     = "kafka-retry-attempt"      // the count of retries, which is also the tier of the retry topic plus one.
+	retryNotBeforeHeader   = "kafka-retry-not-before"   // the unix milliseconds before which the message should not be re-produced.
+)
+
+// ErrRoutedToRetryTopic is returned if the message is failed to deliver and routed to the retry topic.
+var ErrRoutedToRetryTopic = errors.New("kafka message is routed to retry topic")
+
+// RetryTier is a tier of retry topic, the message is re-produced after the delay.
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// RetryPolicy is the tiered retry topics for the failed messages.
+// The failed message is routed to the first tier, and routed to the next tier if the re-produce of the tier fails,
+// so the later tier should have a longer delay.
+// The message is dropped if the re-produce of the last tier fails.
+// The message is re-produced to the default partition of its origin topic with its key and properties.
+type RetryPolicy struct {
+	Tiers []RetryTier
+}
+
+// SetRetryPolicy enables routing the failed-delivery messages of the producer to the retry topics,
+// the failed messages are returned with the error by default.
+// It only applies to the messages of this producer, and ReplayRetryTopic should be run on every tier to re-produce the messages.
+func (kp *kafkaProducer) SetRetryPolicy(policy *RetryPolicy) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	kp.retryPolicy = policy
+}
+
+// sendOrRoute sends the message, the failed message is routed to the retry topic if the retry policy is set.
+// Only the retriable delivery failures are routed, the errors before the message is enqueued,
+// such as the done context, the in-flight limit, the full queue and the closed producer, are returned to the caller.
+func (kp *kafkaProducer) sendOrRoute(ctx context.Context, partition int32, key []byte, message *mqcommon.ProducerMessage) (mqcommon.MessageID, error) {
+	id, err := kp.send(ctx, partition, key, message)
+	kp.mu.RLock()
+	policy := kp.retryPolicy
+	kp.mu.RUnlock()
+	if err == nil || policy == nil || len(policy.Tiers) == 0 || ctx.Err() != nil || !isRetriableDeliveryError(err) {
+		return id, err
+	}
+	// no retry if the producer is closed.
+	if kp.closed() {
+		return id, err
+	}
+	// the producer is not locked while routing, so the rotation and close are not blocked by the slow retry topic.
+	p, release := kp.retainProducer()
+	defer release()
+	headers := kp.messageHeaders(message)
+	if routeErr := routeToRetryTier(ctx, p, policy, 0, kp.topic, key, message.Payload, headers, kp.stopCh); routeErr != nil {
+		log.Warn("route the failed kafka message to retry topic failed", zap.String("topic", kp.topic), zap.Error(routeErr))
+		return nil, err
+	}
+	return nil, errors.Wrapf(ErrRoutedToRetryTopic, "topic %s, retry topic %s, delivery error: %s", kp.topic, policy.Tiers[0].Topic, err.Error())
+}
+
+// isRetriableDeliveryError returns true if the delivery of message to kafka fails transiently,
+// which may succeed if the message is re-produced later.
+func isRetriableDeliveryError(err error) bool {
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) {
+		return false
+	}
+	if kafkaErr.IsFatal() {
+		return false
+	}
+	if kafkaErr.IsRetriable() {
+		return true
+	}
+	switch kafkaErr.Code() {
+	case kafka.ErrMsgTimedOut,
+		kafka.ErrTimedOut,
+		kafka.ErrRequestTimedOut,
+		kafka.ErrTransport,
+		kafka.ErrAllBrokersDown,
+		kafka.ErrBrokerNotAvailable,
+		kafka.ErrNetworkException,
+		kafka.ErrLeaderNotAvailable,
+		kafka.ErrNotLeaderForPartition,
+		kafka.ErrNotEnoughReplicas,
+		kafka.ErrNotEnoughReplicasAfterAppend,
+		kafka.ErrUnknownPartition,
+		kafka.ErrUnknownTopicOrPart:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReplayRetryTopic consumes the retry topic of the tier and re-produces the messages to their origin topics after the delay,
+// the message failed to re-produce is routed to the next tier.
+// It blocks until the context is canceled or the consumer fails.
+func (kc *kafkaClient) ReplayRetryTopic(ctx context.Context, policy *RetryPolicy, tier int, subscription string) error {
+	if tier < 0 || tier >= len(policy.Tiers) {
+		return errors.Newf("invalid retry tier %d, the policy has %d tiers", tier, len(policy.Tiers))
+	}
+	producer, key, err := kc.acquireKafkaProducer(nil)
+	if err != nil {
+		return err
+	}
+	defer releaseKafkaProducer(key, producer)
+	consumer, err := kc.Subscribe(ctx, mqwrapper.ConsumerOptions{
+		Topic:                       policy.Tiers[tier].Topic,
+		SubscriptionName:            subscription,
+		SubscriptionInitialPosition: mqcommon.SubscriptionPositionEarliest,
+		BufSize:                     1024,
+	})
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	logger := log.With(zap.String("retryTopic", policy.Tiers[tier].Topic), zap.Int("tier", tier))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-consumer.Chan():
+			if !ok {
+				return errors.Newf("consumer of retry topic %s is closed", policy.Tiers[tier].Topic)
+			}
+			if err := replayRetryMessage(ctx, producer, policy, tier, msg.(*kafkaMessage).msg); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logger.Warn("replay the retry message failed", zap.Any("offset", msg.ID()), zap.Error(err))
+			}
+			consumer.Ack(msg)
+		}
+	}
+}
+
+// replayRetryMessage waits until the delay of message is passed and re-produces the message to its origin topic.
+func replayRetryMessage(ctx context.Context, producer *kafka.Producer, policy *RetryPolicy, tier int, msg *kafka.Message) error {
+	var originTopic string
+	var notBefore int64
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for _, header := range msg.Headers {
+		switch header.Key {
+		case retryOriginTopicHeader:
+			originTopic = string(header.Value)
+		case retryNotBeforeHeader:
+			notBefore, _ = strconv.ParseInt(string(header.Value), 10, 64)
+		case retryAttemptHeader:
+		default:
+			headers = append(headers, header)
+		}
+	}
+	if originTopic == "" {
+		return errors.New("the origin topic of retry message is not found, drop it")
+	}
+
+	if delay := time.Until(time.UnixMilli(notBefore)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	_, err := produceAndWait(ctx, producer, &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &originTopic, Partition: mqwrapper.DefaultPartitionIdx},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        headers,
+	}, nil)
+	if err == nil {
+		return nil
+	}
+	if tier+1 >= len(policy.Tiers) {
+		return errors.Wrapf(err, "re-produce to topic %s failed at the last retry tier, drop it", originTopic)
+	}
+	if routeErr := routeToRetryTier(ctx, producer, policy, tier+1, originTopic, msg.Key, msg.Value, headers, nil); routeErr != nil {
+		return errors.Wrapf(routeErr, "re-produce to topic %s failed with %s, and route to next tier failed", originTopic, err.Error())
+	}
+	return nil
+}
+
+// routeToRetryTier produces the message into the retry topic of the tier with the retry headers,
+// the wait of delivery is stopped once the stop channel is closed.
+func routeToRetryTier(ctx context.Context, producer *kafka.Producer, policy *RetryPolicy, tier int, originTopic string, key []byte, payload []byte, headers []kafka.Header, stopCh <-chan struct{}) error {
+	retryTier := policy.Tiers[tier]
+	headers = append(headers,
+		kafka.Header{Key: retryOriginTopicHeader, Value: []byte(originTopic)},
+		kafka.Header{Key: retryAttemptHeader, Value: []byte(strconv.Itoa(tier + 1))},
+		kafka.Header{Key: retryNotBeforeHeader, Value: []byte(strconv.FormatInt(time.Now().Add(retryTier.Delay).UnixMilli(), 10))},
+	)
+	_, err := produceAndWait(ctx, producer, &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &retryTier.Topic, Partition: mqwrapper.DefaultPartitionIdx},
+		Key:            key,
+		Value:          payload,
+		Headers:        headers,
+	}, stopCh)
+	return err
+}
+
+// produceAndWait produces the message and waits for the delivery report, the nil stop channel never stops the wait.
+func produceAndWait(ctx context.Context, producer *kafka.Producer, msg *kafka.Message, stopCh <-chan struct{}) (kafka.Offset, error) {
+	resultCh := make(chan kafka.Event, 1)
+	if err := producer.Produce(msg, resultCh); err != nil {
+		return kafka.OffsetInvalid, err
+	}
+	select {
+	case <-ctx.Done():
+		return kafka.OffsetInvalid, ctx.Err()
+	case <-stopCh:
+		return kafka.OffsetInvalid, common.NewIgnorableError(errors.New("kafka producer is closed"))
+	case e := <-resultCh:
+		m := e.(*kafka.Message)
+		if m.TopicPartition.Error != nil {
+			return kafka.OffsetInvalid, m.TopicPartition.Error
+		}
+		return m.TopicPartition.Offset, nil
+	}
+}
+
+// propertiesToHeaders converts the properties of message into kafka headers.
+func propertiesToHeaders(properties map[string]string) []kafka.Header {
+	headers := make([]kafka.Header, 0, len(properties))
+	for key, value := range properties {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	return headers
+}
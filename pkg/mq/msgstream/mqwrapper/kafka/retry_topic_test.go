@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/v2/common"
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestKafkaProducer_RetryTopic(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	retryTopic := fmt.Sprintf("test-retry-topic-%d", rand.Int())
+	delay := 500 * time.Millisecond
+	policy := &RetryPolicy{Tiers: []RetryTier{{Topic: retryTopic, Delay: delay}}}
+
+	producer := createProducer(t, kc, topic)
+	defer producer.Close()
+	kp := producer.(*kafkaProducer)
+	msg := &mqcommon.ProducerMessage{
+		Payload:    []byte("retry-me"),
+		Properties: map[string]string{"k": "v"},
+	}
+
+	// the message is returned with the error if the retry policy is not set.
+	_, err := kp.sendOrRoute(context.TODO(), 10, nil, msg)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrRoutedToRetryTopic)
+
+	// the delivery of the message fails because the partition doesn't exist.
+	kp.SetRetryPolicy(policy)
+	failedAt := time.Now()
+	_, err = kp.sendOrRoute(context.TODO(), 10, nil, msg)
+	assert.ErrorIs(t, err, ErrRoutedToRetryTopic)
+
+	consumer, err := kafka.NewConsumer(createConfig(fmt.Sprintf("test-group-%d", rand.Int())))
+	assert.NoError(t, err)
+	defer consumer.Close()
+
+	// the retry message carries the retry headers.
+	assert.NoError(t, consumer.Assign([]kafka.TopicPartition{{Topic: &retryTopic, Partition: 0, Offset: kafka.OffsetBeginning}}))
+	retryMsg, err := consumer.ReadMessage(10 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("retry-me"), retryMsg.Value)
+	headers := make(map[string]string)
+	for _, header := range retryMsg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	assert.Equal(t, topic, headers[retryOriginTopicHeader])
+	assert.Equal(t, "1", headers[retryAttemptHeader])
+	notBefore, err := strconv.ParseInt(headers[retryNotBeforeHeader], 10, 64)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, notBefore, failedAt.Add(delay).UnixMilli())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	replayDone := make(chan error, 1)
+	go func() {
+		replayDone <- kc.ReplayRetryTopic(ctx, policy, 0, fmt.Sprintf("test-retry-group-%d", rand.Int()))
+	}()
+
+	// the message is re-produced to the origin topic after the delay without the retry headers.
+	assert.NoError(t, consumer.Assign([]kafka.TopicPartition{{Topic: &topic, Partition: 0, Offset: kafka.OffsetBeginning}}))
+	replayed, err := consumer.ReadMessage(10 * time.Second)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(failedAt), delay)
+	assert.Equal(t, []byte("retry-me"), replayed.Value)
+	assert.Len(t, replayed.Headers, 1)
+	assert.Equal(t, "k", replayed.Headers[0].Key)
+	assert.Equal(t, "v", string(replayed.Headers[0].Value))
+
+	cancel()
+	assert.NoError(t, <-replayDone)
+
+	// invalid tier.
+	assert.Error(t, kc.ReplayRetryTopic(context.TODO(), policy, 1, "test"))
+}
+
+func TestKafkaProducer_RetryTopicNotRouted(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	policy := &RetryPolicy{Tiers: []RetryTier{{Topic: fmt.Sprintf("test-retry-topic-%d", rand.Int())}}}
+
+	producer, err := kc.CreateProducer(context.TODO(), mqcommon.ProducerOptions{Topic: topic, MaxInflightMessages: 1})
+	assert.NoError(t, err)
+	defer producer.Close()
+	kp := producer.(*kafkaProducer)
+	kp.SetRetryPolicy(policy)
+	msg := &mqcommon.ProducerMessage{Payload: []byte("not-routed")}
+
+	// the message is not routed if the context is done before it's enqueued.
+	assert.NoError(t, kp.inflight.Acquire(context.TODO(), kp.stopCh))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = kp.sendOrRoute(ctx, 10, nil, msg)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrRoutedToRetryTopic)
+	kp.inflight.Release()
+
+	// only the retriable delivery failures are routed.
+	assert.True(t, isRetriableDeliveryError(kafka.NewError(kafka.ErrUnknownPartition, "unknown partition", false)))
+	assert.True(t, isRetriableDeliveryError(errors.Wrap(kafka.NewError(kafka.ErrMsgTimedOut, "timed out", false), "send")))
+	assert.False(t, isRetriableDeliveryError(kafka.NewError(kafka.ErrUnknownPartition, "fatal", true)))
+	assert.False(t, isRetriableDeliveryError(kafka.NewError(kafka.ErrMsgSizeTooLarge, "too large", false)))
+	assert.False(t, isRetriableDeliveryError(errors.Mark(errors.Wrap(kafka.NewError(kafka.ErrQueueFull, "queue full", false), "timeout"), context.DeadlineExceeded)))
+	assert.False(t, isRetriableDeliveryError(context.Canceled))
+	assert.False(t, isRetriableDeliveryError(common.NewIgnorableError(errors.New("kafka producer is closed"))))
+}
+
+func TestKafkaProducer_CloseWhileRouting(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	policy := &RetryPolicy{Tiers: []RetryTier{{Topic: fmt.Sprintf("test-retry-topic-%d", rand.Int())}}}
+
+	// the broker is unreachable, so the delivery fails after the message is timed out.
+	p, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": "127.0.0.1:1", "message.timeout.ms": 1000})
+	assert.NoError(t, err)
+	defer p.Close()
+	go func() {
+		for range p.Events() {
+		}
+	}()
+
+	// the wait of the routed message is stopped once the producer is closed.
+	stopCh := make(chan struct{})
+	routed := make(chan error, 1)
+	go func() {
+		routed <- routeToRetryTier(context.TODO(), p, policy, 0, topic, nil, []byte("stopped"), nil, stopCh)
+	}()
+	select {
+	case <-routed:
+		assert.FailNow(t, "the delivery should not be confirmed")
+	case <-time.After(200 * time.Millisecond):
+	}
+	close(stopCh)
+	select {
+	case err := <-routed:
+		assert.True(t, common.IsIgnorableError(err))
+	case <-time.After(time.Second):
+		assert.FailNow(t, "the route should be stopped")
+	}
+
+	// close the producer while the failed message may be routing, it's never reported as routed.
+	kp := &kafkaProducer{p: p, topic: topic, stopCh: make(chan struct{}), retryPolicy: policy}
+	sent := make(chan error, 1)
+	go func() {
+		_, err := kp.sendOrRoute(context.TODO(), 0, nil, &mqcommon.ProducerMessage{Payload: []byte("closed")})
+		sent <- err
+	}()
+	time.Sleep(1500 * time.Millisecond)
+	kp.Close()
+	select {
+	case err := <-sent:
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrRoutedToRetryTopic)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "the send should not be blocked after closed")
+	}
+}
@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/util/paramtable"
+)
+
+// ScanRange delivers the messages in the offset range [start, end) to fn in order and returns once the range is scanned,
+// for the bounded historical scan, e.g. analytics and export.
+// The scan is bounded by the high watermark when it starts, so it returns at the end of partition if end is beyond the latest message.
+// The consumer is seeked to start, so it should not be assigned yet.
+// The scan stops with the error if fn returns an error or the context is done.
+func (kc *Consumer) ScanRange(ctx context.Context, start, end common.MessageID, fn func(common.Message) error) error {
+	startID, ok := start.(*KafkaID)
+	if !ok {
+		return errors.Newf("invalid start message id %v of kafka scan", start)
+	}
+	endID, ok := end.(*KafkaID)
+	if !ok {
+		return errors.Newf("invalid end message id %v of kafka scan", end)
+	}
+	if startID.MessageID < 0 || startID.MessageID > endID.MessageID {
+		return errors.Newf("invalid scan range [%d, %d) of topic %s", startID.MessageID, endID.MessageID, kc.topic)
+	}
+	if kc.started {
+		return errors.New("can not scan a kafka consumer after chan is started")
+	}
+	if err := kc.Seek(startID, true); err != nil {
+		return errors.Wrapf(err, "seek kafka consumer of topic %s to offset %d", kc.topic, startID.MessageID)
+	}
+	_, high, err := kc.Watermarks()
+	if err != nil {
+		return err
+	}
+	stop := min(endID.MessageID, high)
+
+	logger := log.With(zap.String("topic", kc.topic), zap.String("groupID", kc.groupID),
+		zap.Int64("start", startID.MessageID), zap.Int64("end", endID.MessageID), zap.Int64("high", high))
+	readTimeout := paramtable.Get().KafkaCfg.ReadTimeout.GetAsDuration(time.Second)
+	delivered := 0
+	for offset := startID.MessageID; offset < stop; {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrapf(err, "scan range of topic %s stopped at offset %d", kc.topic, offset)
+		}
+		msg, err := kc.next(readTimeout)
+		if err != nil {
+			// keep waiting for the messages that are not fetched yet until the context is done.
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrTimedOut {
+				continue
+			}
+			return errors.Wrapf(err, "scan range of topic %s at offset %d", kc.topic, offset)
+		}
+		offset = int64(msg.msg.TopicPartition.Offset)
+		if offset >= endID.MessageID {
+			break
+		}
+		msg.markReturned()
+		if err := fn(msg); err != nil {
+			return err
+		}
+		delivered++
+		offset++
+	}
+	logger.Info("kafka consumer scan range finished", zap.Int("delivered", delivered))
+	return nil
+}
@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestKafkaConsumer_ScanRange(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+	data := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	testKafkaConsumerProduceData(t, topic, data, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"})
+
+	scan := func(start, end int64) ([]int, error) {
+		groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+		consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+		assert.NoError(t, err)
+		defer consumer.Close()
+
+		delivered := make([]int, 0)
+		err = consumer.ScanRange(context.TODO(), &KafkaID{MessageID: start}, &KafkaID{MessageID: end}, func(msg mqcommon.Message) error {
+			assert.Equal(t, int64(len(delivered))+start, msg.ID().(*KafkaID).MessageID)
+			delivered = append(delivered, BytesToInt(msg.Payload()))
+			return nil
+		})
+		return delivered, err
+	}
+
+	// exactly the messages in [start, end) are delivered.
+	delivered, err := scan(3, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 4, 5, 6}, delivered)
+
+	// the scan returns at the end of partition if end is beyond the latest message.
+	delivered, err = scan(8, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{8, 9}, delivered)
+
+	// the empty range delivers nothing.
+	delivered, err = scan(5, 5)
+	assert.NoError(t, err)
+	assert.Empty(t, delivered)
+
+	// start is larger than end.
+	_, err = scan(7, 3)
+	assert.Error(t, err)
+
+	// the scan stops with the error of fn.
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionUnknown)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	fnErr := errors.New("export failed")
+	calls := 0
+	err = consumer.ScanRange(context.TODO(), &KafkaID{MessageID: 0}, &KafkaID{MessageID: 10}, func(msg mqcommon.Message) error {
+		calls++
+		return fnErr
+	})
+	assert.ErrorIs(t, err, fnErr)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,228 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/common"
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+	"github.com/milvus-io/milvus/pkg/v2/util/timerecord"
+)
+
+// DeliveryCallback is called with the id of the message once the delivery of the message sent by SendAsync is confirmed,
+// or with the error if the delivery fails.
+type DeliveryCallback func(id mqcommon.MessageID, err error)
+
+// asyncDelivery is the opaque token of the message sent by SendAsync,
+// which correlates the delivery report on the shared event channel of the underlying producer with the callback.
+type asyncDelivery struct {
+	topic    string
+	start    *timerecord.TimeRecorder
+	callback DeliveryCallback
+	inflight *inflightLimiter
+	pending  *pendingDeliveries
+}
+
+// deliver reports the delivery result of the message to the callback, it should be called after the delivery is taken from the pending table.
+func (d *asyncDelivery) deliver(m *kafka.Message) {
+	d.inflight.Release()
+	if m.TopicPartition.Error != nil {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+		log.Warn("kafka async produce message failed", zap.String("topic", d.topic), zap.Error(m.TopicPartition.Error))
+		d.callback(nil, m.TopicPartition.Error)
+		return
+	}
+	metrics.MsgStreamRequestLatency.WithLabelValues(metrics.SendMsgLabel).Observe(float64(d.start.ElapseSpan().Milliseconds()))
+	metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.SuccessLabel).Inc()
+	d.callback(&KafkaID{MessageID: int64(m.TopicPartition.Offset)}, nil)
+}
+
+// fail reports the error to the callback without the delivery report.
+func (d *asyncDelivery) fail(err error) {
+	d.inflight.Release()
+	metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+	d.callback(nil, err)
+}
+
+// pendingDeliveries is the table of the messages sent by SendAsync whose delivery reports are not arrived yet,
+// the delivery is taken from the table by either its delivery report or the close of producer, so its callback is called exactly once.
+type pendingDeliveries struct {
+	mu         sync.Mutex
+	drained    bool
+	deliveries map[*asyncDelivery]struct{}
+}
+
+// add adds the delivery into the table, false is returned if the table is drained by the close of producer.
+func (t *pendingDeliveries) add(d *asyncDelivery) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.drained {
+		return false
+	}
+	if t.deliveries == nil {
+		t.deliveries = make(map[*asyncDelivery]struct{})
+	}
+	t.deliveries[d] = struct{}{}
+	return true
+}
+
+// take removes the delivery from the table, false is returned if it's already taken.
+func (t *pendingDeliveries) take(d *asyncDelivery) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.deliveries[d]; !ok {
+		return false
+	}
+	delete(t.deliveries, d)
+	return true
+}
+
+// drain takes all the pending deliveries and fails them with the error, no delivery can be added afterwards.
+func (t *pendingDeliveries) drain(err error) {
+	t.mu.Lock()
+	t.drained = true
+	deliveries := t.deliveries
+	t.deliveries = nil
+	t.mu.Unlock()
+	for d := range deliveries {
+		d.fail(err)
+	}
+}
+
+// dispatchDelivery dispatches the delivery report of the message sent by SendAsync to its callback,
+// the callback is called in a new goroutine so a slow callback never blocks the event loop of the underlying producer.
+// The report arrived after the producer is closed is ignored, because the callback is already called with the closed error.
+// false is returned if the message is not sent by SendAsync.
+func dispatchDelivery(m *kafka.Message) bool {
+	d, ok := m.Opaque.(*asyncDelivery)
+	if !ok {
+		return false
+	}
+	if d.pending.take(d) {
+		go d.deliver(m)
+	}
+	return true
+}
+
+// SendAsync sends the message without waiting for the delivery, the callback is called once the delivery report
+// of the message arrives, so the caller can confirm each message without blocking on it.
+// An error is returned and the callback is never called if the message can't be enqueued into the producer,
+// including ErrMessageDropped of the queue full policy,
+// otherwise the callback is called exactly once in another goroutine, the order of the callbacks is not guaranteed.
+// The callbacks whose delivery reports are not arrived when the producer is closed are called with the closed error,
+// the message may still be delivered.
+// The message sent in at-most-once mode is confirmed with an invalid offset immediately.
+// The enqueue blocks while the in-flight limit of the producer is reached, the slot is released before the callback is called.
+// The failed delivery is not routed to the retry topics.
+func (kp *kafkaProducer) SendAsync(ctx context.Context, message *mqcommon.ProducerMessage, deliveryCb DeliveryCallback) error {
+	if deliveryCb == nil {
+		return errors.New("delivery callback of kafka async produce should not be nil")
+	}
+	start := timerecord.NewTimeRecorder("send msg to stream async")
+	metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.TotalLabel).Inc()
+
+	if kp.closed() {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+		log.Error("kafka async produce message fail because the producer has been closed", zap.String("topic", kp.topic))
+		return common.NewIgnorableError(errors.New("kafka producer is closed"))
+	}
+
+	if err := kp.inflight.Acquire(ctx, kp.stopCh); err != nil {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+		return err
+	}
+
+	partition := int32(mqwrapper.DefaultPartitionIdx)
+	key := kp.extractKey(message)
+	if key != nil {
+		partition = kafka.PartitionAny
+	}
+	// the delivery report is disabled in at-most-once mode.
+	var delivery *asyncDelivery
+	if kp.durability != mqcommon.DurabilityAtMostOnce {
+		delivery = &asyncDelivery{topic: kp.topic, start: start, callback: deliveryCb, inflight: kp.inflight, pending: &kp.pending}
+		if !kp.pending.add(delivery) {
+			kp.inflight.Release()
+			metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+			return common.NewIgnorableError(errors.New("kafka producer is closed"))
+		}
+	}
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &kp.topic, Partition: partition},
+		Key:            key,
+		Value:          message.Payload,
+		Headers:        kp.messageHeaders(message),
+		Timestamp:      message.Timestamp,
+	}
+	if delivery != nil {
+		msg.Opaque = delivery
+	}
+	if err := kp.produce(ctx, msg, nil); err != nil {
+		if delivery != nil && !kp.pending.take(delivery) {
+			// the callback is already called by the close of producer.
+			return nil
+		}
+		kp.inflight.Release()
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+		return err
+	}
+	metrics.MsgStreamProduceMessageBytes.WithLabelValues(kp.topic).Observe(float64(len(message.Payload)))
+	if delivery == nil {
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.SuccessLabel).Inc()
+		go deliveryCb(&KafkaID{MessageID: int64(kafka.OffsetInvalid)}, nil)
+	}
+	return nil
+}
+
+// DeliveryFuture is the pending delivery result of the message sent by SendFuture.
+type DeliveryFuture struct {
+	done chan struct{}
+	id   mqcommon.MessageID
+	err  error
+}
+
+// newDeliveryFuture creates a new pending future.
+func newDeliveryFuture() *DeliveryFuture {
+	return &DeliveryFuture{done: make(chan struct{})}
+}
+
+// resolve sets the delivery result of the future, it should be called exactly once.
+func (f *DeliveryFuture) resolve(id mqcommon.MessageID, err error) {
+	f.id, f.err = id, err
+	close(f.done)
+}
+
+// Done returns a channel that is closed once the delivery result is ready.
+func (f *DeliveryFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the delivery result of the message is ready or the context is done,
+// the id of the message is returned once the delivery is confirmed.
+// The message may still be delivered if the context is done first.
+func (f *DeliveryFuture) Wait(ctx context.Context) (mqcommon.MessageID, error) {
+	select {
+	case <-f.done:
+		return f.id, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SendFuture sends the message like SendAsync, but returns a future of the delivery result rather than calling back,
+// so the caller can send many messages and wait for all of them.
+// The future is resolved with the error immediately if the message can't be enqueued into the producer.
+func (kp *kafkaProducer) SendFuture(ctx context.Context, message *mqcommon.ProducerMessage) *DeliveryFuture {
+	future := newDeliveryFuture()
+	if err := kp.SendAsync(ctx, message, future.resolve); err != nil {
+		future.resolve(nil, err)
+	}
+	return future
+}
@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+)
+
+// consumerStats is the part of the statistics of librdkafka about the broker throttling, see STATISTICS.md of librdkafka.
+type consumerStats struct {
+	Brokers map[string]brokerStats `json:"brokers"`
+}
+
+// brokerStats is the statistics of one broker.
+type brokerStats struct {
+	Name     string      `json:"name"`
+	NodeID   int32       `json:"nodeid"` // -1 for the internal and bootstrap brokers.
+	Throttle windowStats `json:"throttle"`
+}
+
+// windowStats is the rolling window of the statistics interval, the throttle time is in milliseconds.
+type windowStats struct {
+	Max int64 `json:"max"`
+	Cnt int64 `json:"cnt"`
+}
+
+// throttleSignal is a level-triggered signal of the broker throttling, the channel is closed while the consumer is throttled,
+// and replaced with a new one once the throttling ends.
+type throttleSignal struct {
+	mu       sync.Mutex
+	ch       chan struct{}
+	throttle time.Duration // the max throttle time in the latest statistics window, 0 if not throttled.
+}
+
+// newThrottleSignal creates a throttle signal that is not throttled.
+func newThrottleSignal() *throttleSignal {
+	return &throttleSignal{ch: make(chan struct{})}
+}
+
+// Set updates the throttle time, the signal is active if it's positive.
+func (s *throttleSignal) Set(throttle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if throttle > 0 && s.throttle <= 0 {
+		close(s.ch)
+	} else if throttle <= 0 && s.throttle > 0 {
+		s.ch = make(chan struct{})
+	}
+	s.throttle = throttle
+}
+
+// Chan returns the channel that is closed while the signal is active.
+func (s *throttleSignal) Chan() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ch
+}
+
+// Throttle returns the max throttle time in the latest statistics window.
+func (s *throttleSignal) Throttle() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.throttle
+}
+
+// Throttled returns a channel that is closed while the consumer is throttled by any broker in the latest statistics window,
+// so the caller can slow down proactively, e.g. the quota of the consumer is exceeded.
+// The channel is replaced once the throttling ends, so it should be fetched again after it's closed.
+// The statistics are only collected if kafka.consumerStatsIntervalMs is set, otherwise the channel is never closed.
+func (kc *Consumer) Throttled() <-chan struct{} {
+	return kc.throttle.Chan()
+}
+
+// ThrottleTime returns the max throttle time of the consumer by the brokers in the latest statistics window, 0 if not throttled.
+func (kc *Consumer) ThrottleTime() time.Duration {
+	return kc.throttle.Throttle()
+}
+
+// observeStats observes the throttle time by each broker from the statistics, which are emitted at every statistics interval.
+func (kc *Consumer) observeStats(stats string) {
+	var s consumerStats
+	if err := json.Unmarshal([]byte(stats), &s); err != nil {
+		log.RatedWarn(60, "decode kafka consumer statistics failed", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Error(err))
+		return
+	}
+	var maxThrottle time.Duration
+	for _, broker := range s.Brokers {
+		if broker.NodeID < 0 {
+			continue
+		}
+		var throttle time.Duration
+		if broker.Throttle.Cnt > 0 {
+			throttle = time.Duration(broker.Throttle.Max) * time.Millisecond
+		}
+		metrics.MsgStreamConsumeBrokerThrottleTime.WithLabelValues(kc.topic, broker.Name).Set(float64(throttle.Milliseconds()))
+		maxThrottle = max(maxThrottle, throttle)
+	}
+	if maxThrottle > 0 {
+		log.RatedWarn(10, "kafka consumer is throttled by broker", zap.String("topic", kc.topic), zap.String("groupID", kc.groupID), zap.Duration("throttle", maxThrottle))
+	}
+	kc.throttle.Set(maxThrottle)
+}
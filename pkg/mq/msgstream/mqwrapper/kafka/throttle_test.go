@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestKafkaConsumer_Throttle(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topicName-%d", rand.Int())
+	testKafkaConsumerProduceData(t, topic, []int{111, 222, 333}, []string{"111", "222", "333"})
+
+	isThrottled := func(consumer *Consumer) bool {
+		select {
+		case <-consumer.Throttled():
+			return true
+		default:
+			return false
+		}
+	}
+
+	t.Run("statistics", func(t *testing.T) {
+		// the statistics events are polled with the messages.
+		groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+		config := createConfig(groupID)
+		config.SetKey("statistics.interval.ms", 100)
+		consumer, err := newKafkaConsumer(config, 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+		assert.NoError(t, err)
+		defer consumer.Close()
+		for _, v := range []int{111, 222, 333} {
+			msg := <-consumer.Chan()
+			assert.Equal(t, v, BytesToInt(msg.Payload()))
+		}
+		time.Sleep(300 * time.Millisecond)
+		assert.Zero(t, consumer.ThrottleTime())
+		assert.False(t, isThrottled(consumer))
+	})
+
+	t.Run("synthetic", func(t *testing.T) {
+		groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+		consumer, err := newKafkaConsumer(createConfig(groupID), 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+		assert.NoError(t, err)
+		defer consumer.Close()
+		assert.False(t, isThrottled(consumer))
+
+		statsOf := func(max int64, cnt int64) string {
+			return fmt.Sprintf(`{"brokers": {
+				"b1:9092/1": {"name": "b1:9092/1", "nodeid": 1, "throttle": {"max": %d, "cnt": %d}},
+				"b2:9092/2": {"name": "b2:9092/2", "nodeid": 2, "throttle": {"max": 0, "cnt": 0}},
+				":0/internal": {"name": ":0/internal", "nodeid": -1, "throttle": {"max": 0, "cnt": 0}}
+			}}`, max, cnt)
+		}
+		throttleTime := func(broker string) float64 {
+			return testutil.ToFloat64(metrics.MsgStreamConsumeBrokerThrottleTime.WithLabelValues(topic, broker))
+		}
+
+		consumer.observeStats(statsOf(250, 2))
+		assert.Equal(t, float64(250), throttleTime("b1:9092/1"))
+		assert.Equal(t, float64(0), throttleTime("b2:9092/2"))
+		assert.Equal(t, 250*time.Millisecond, consumer.ThrottleTime())
+		assert.True(t, isThrottled(consumer))
+
+		// the malformed statistics are ignored.
+		consumer.observeStats("{")
+		assert.Equal(t, 250*time.Millisecond, consumer.ThrottleTime())
+
+		// the signal is released once the throttling ends.
+		consumer.observeStats(statsOf(0, 0))
+		assert.Equal(t, float64(0), throttleTime("b1:9092/1"))
+		assert.Zero(t, consumer.ThrottleTime())
+		assert.False(t, isThrottled(consumer))
+	})
+}
@@ -0,0 +1,202 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus/pkg/v2/common"
+	"github.com/milvus-io/milvus/pkg/v2/log"
+	"github.com/milvus-io/milvus/pkg/v2/metrics"
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+	"github.com/milvus-io/milvus/pkg/v2/mq/msgstream/mqwrapper"
+)
+
+var (
+	// ErrBatchInProgress is returned by BeginBatch if the previous batch is not committed or aborted yet.
+	ErrBatchInProgress = errors.New("kafka transactional batch is in progress")
+	// ErrNoBatch is returned by AddToBatch, CommitBatch and AbortBatch if there's no open batch.
+	ErrNoBatch = errors.New("kafka transactional batch is not begun")
+)
+
+// TransactionalProducer produces a logical append group of messages as a batch in one kafka transaction,
+// so the messages of the batch are committed atomically, the consumers with isolation.level read_committed,
+// which is the default of kafka, see either all or none of them.
+// The underlying producer is dedicated to the transactional id and never shared, at most one batch is open at a time.
+// The messages are sent to the default partition of the topic.
+type TransactionalProducer struct {
+	kp              *kafkaProducer // the wrapper of the dedicated transactional producer.
+	transactionalID string
+
+	mu      sync.Mutex         // serialize the batch operations.
+	open    bool               // a batch is begun and not committed or aborted yet.
+	reports []chan kafka.Event // the delivery reports of the messages added to the open batch, in order of addition.
+}
+
+// CreateTransactionalProducer creates a transactional producer of the topic with the transactional id,
+// the transactions that are left open by the previous producer of the same transactional id are aborted.
+// The transactional id should be unique for each producer instance, the previous producer of the same id is fenced.
+func (kc *kafkaClient) CreateTransactionalProducer(ctx context.Context, topic string, transactionalID string) (*TransactionalProducer, error) {
+	if transactionalID == "" {
+		return nil, errors.New("transactional id of kafka producer is empty")
+	}
+	config := kc.newProducerConfig(kc.topicProducerOverrides(topic, nil))
+	config.SetKey("transactional.id", transactionalID)
+	p, err := kafka.NewProducer(config)
+	if err != nil {
+		log.Error("create transactional kafka producer failed", zap.String("topic", topic), zap.String("transactionalID", transactionalID), zap.Error(err))
+		return nil, err
+	}
+	go func() {
+		// the delivery reports are sent to the channel of each message, only the instance-level events are left,
+		// the fatal error is also returned by the transactional operations.
+		for e := range p.Events() {
+			if ev, ok := e.(kafka.Error); ok {
+				log.Warn("transactional kafka producer error", zap.String("transactionalID", transactionalID), zap.Error(ev))
+			}
+		}
+	}()
+	if err := p.InitTransactions(ctx); err != nil {
+		log.Warn("init transactions of kafka producer failed", zap.String("topic", topic), zap.String("transactionalID", transactionalID), zap.Error(err))
+		p.Close()
+		return nil, err
+	}
+	log.Info("transactional kafka producer is created", zap.String("topic", topic), zap.String("transactionalID", transactionalID))
+	return &TransactionalProducer{
+		kp: &kafkaProducer{
+			p:      p,
+			topic:  topic,
+			stopCh: make(chan struct{}),
+		},
+		transactionalID: transactionalID,
+	}, nil
+}
+
+// Topic returns the topic of the producer.
+func (tp *TransactionalProducer) Topic() string {
+	return tp.kp.topic
+}
+
+// BeginBatch begins a new batch, ErrBatchInProgress is returned if the previous batch is still open.
+func (tp *TransactionalProducer) BeginBatch() error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if tp.kp.closed() {
+		return common.NewIgnorableError(errors.New("kafka producer is closed"))
+	}
+	if tp.open {
+		return ErrBatchInProgress
+	}
+	if err := tp.kp.p.BeginTransaction(); err != nil {
+		return err
+	}
+	tp.open = true
+	tp.reports = nil
+	return nil
+}
+
+// AddToBatch adds the message into the open batch, the message is not visible to the read_committed consumers until the batch is committed.
+// The produce fails at once if the queue of the producer is full.
+func (tp *TransactionalProducer) AddToBatch(message *mqcommon.ProducerMessage) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.open {
+		return ErrNoBatch
+	}
+	report := make(chan kafka.Event, 1)
+	if err := tp.kp.produce(context.Background(), &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &tp.kp.topic, Partition: mqwrapper.DefaultPartitionIdx},
+		Value:          message.Payload,
+		Headers:        tp.kp.messageHeaders(message),
+		Timestamp:      message.Timestamp,
+	}, report); err != nil {
+		return err
+	}
+	metrics.MsgStreamProduceMessageBytes.WithLabelValues(tp.kp.topic).Observe(float64(len(message.Payload)))
+	tp.reports = append(tp.reports, report)
+	return nil
+}
+
+// CommitBatch commits the open batch, returns the ids of the messages in order of addition.
+// The batch is kept open if the commit fails with a retriable error, so the commit can be retried,
+// otherwise the batch is aborted and none of its messages is visible.
+func (tp *TransactionalProducer) CommitBatch(ctx context.Context) ([]mqcommon.MessageID, error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.open {
+		return nil, ErrNoBatch
+	}
+	start := time.Now()
+	if err := tp.kp.p.CommitTransaction(ctx); err != nil {
+		var kafkaErr kafka.Error
+		if errors.As(err, &kafkaErr) && kafkaErr.IsRetriable() {
+			log.Warn("commit kafka transactional batch failed, retriable", zap.String("transactionalID", tp.transactionalID), zap.Error(err))
+			return nil, err
+		}
+		log.Warn("commit kafka transactional batch failed, abort it", zap.String("transactionalID", tp.transactionalID), zap.Int("messages", len(tp.reports)), zap.Error(err))
+		if errors.As(err, &kafkaErr) && kafkaErr.TxnRequiresAbort() {
+			if abortErr := tp.abort(ctx); abortErr != nil {
+				return nil, errors.Wrapf(err, "abort the failed batch, %s", abortErr.Error())
+			}
+		}
+		// the fatal error can not be recovered by abort, the producer should be recreated.
+		tp.open = false
+		return nil, err
+	}
+	// all the messages are delivered once the transaction is committed.
+	ids := make([]mqcommon.MessageID, 0, len(tp.reports))
+	for _, report := range tp.reports {
+		m := (<-report).(*kafka.Message)
+		if m.TopicPartition.Error != nil {
+			tp.open = false
+			return nil, errors.Wrapf(m.TopicPartition.Error, "message of committed batch is not delivered")
+		}
+		ids = append(ids, &KafkaID{MessageID: int64(m.TopicPartition.Offset)})
+	}
+	tp.open, tp.reports = false, nil
+	log.Debug("kafka transactional batch is committed", zap.String("transactionalID", tp.transactionalID), zap.Int("messages", len(ids)), zap.Duration("elapsed", time.Since(start)))
+	return ids, nil
+}
+
+// AbortBatch aborts the open batch, none of its messages is visible to the read_committed consumers.
+func (tp *TransactionalProducer) AbortBatch() error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if !tp.open {
+		return ErrNoBatch
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout*time.Millisecond)
+	defer cancel()
+	return tp.abort(ctx)
+}
+
+// abort aborts the open batch, should be called with the lock held.
+func (tp *TransactionalProducer) abort(ctx context.Context) error {
+	if err := tp.kp.p.AbortTransaction(ctx); err != nil {
+		log.Warn("abort kafka transactional batch failed", zap.String("transactionalID", tp.transactionalID), zap.Error(err))
+		return err
+	}
+	log.Info("kafka transactional batch is aborted", zap.String("transactionalID", tp.transactionalID), zap.Int("messages", len(tp.reports)))
+	tp.open, tp.reports = false, nil
+	return nil
+}
+
+// Close aborts the open batch and closes the dedicated underlying producer.
+func (tp *TransactionalProducer) Close() {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if tp.kp.closed() {
+		return
+	}
+	if tp.open {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout*time.Millisecond)
+		_ = tp.abort(ctx)
+		cancel()
+	}
+	tp.kp.Close()
+	tp.kp.p.Close()
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	mqcommon "github.com/milvus-io/milvus/pkg/v2/mq/common"
+)
+
+func TestTransactionalProducer(t *testing.T) {
+	kc := createKafkaClient(t)
+	defer kc.Close()
+	rand.Seed(time.Now().UnixNano())
+	topic := fmt.Sprintf("test-topic-%d", rand.Int())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := kc.CreateTransactionalProducer(ctx, topic, "")
+	assert.Error(t, err)
+	tp, err := kc.CreateTransactionalProducer(ctx, topic, "txn-"+topic)
+	assert.NoError(t, err)
+	defer tp.Close()
+	assert.Equal(t, topic, tp.Topic())
+
+	// the batch operations require an open batch.
+	assert.ErrorIs(t, tp.AddToBatch(&mqcommon.ProducerMessage{Payload: IntToBytes(0)}), ErrNoBatch)
+	_, err = tp.CommitBatch(ctx)
+	assert.ErrorIs(t, err, ErrNoBatch)
+	assert.ErrorIs(t, tp.AbortBatch(), ErrNoBatch)
+
+	// the mock cluster doesn't apply the isolation level of the consumers to the transactions,
+	// so only the committed batches are consumed here, and the visibility of the uncommitted messages is left to kafka.
+	addBatch := func(values ...int) {
+		assert.NoError(t, tp.BeginBatch())
+		for _, v := range values {
+			assert.NoError(t, tp.AddToBatch(&mqcommon.ProducerMessage{Payload: IntToBytes(v)}))
+		}
+	}
+	addBatch(1, 2, 3)
+	assert.ErrorIs(t, tp.BeginBatch(), ErrBatchInProgress)
+	ids, err := tp.CommitBatch(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ids, 3)
+
+	// the aborted batch is purged before it's written into the log, and the batch can be begun again after the abort.
+	addBatch(4, 5)
+	assert.NoError(t, tp.AbortBatch())
+	assert.ErrorIs(t, tp.AbortBatch(), ErrNoBatch)
+	_, err = tp.CommitBatch(ctx)
+	assert.ErrorIs(t, err, ErrNoBatch)
+
+	addBatch(6)
+	lastIDs, err := tp.CommitBatch(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, lastIDs, 1)
+	ids = append(ids, lastIDs...)
+
+	groupID := fmt.Sprintf("test-groupid-%d", rand.Int())
+	config := createConfig(groupID)
+	config.SetKey("isolation.level", "read_committed")
+	consumer, err := newKafkaConsumer(config, 16, topic, groupID, mqcommon.SubscriptionPositionEarliest)
+	assert.NoError(t, err)
+	defer consumer.Close()
+	for k, v := range []int{1, 2, 3, 6} {
+		select {
+		case msg := <-consumer.Chan():
+			assert.Equal(t, v, BytesToInt(msg.Payload()))
+			assert.Equal(t, ids[k].(*KafkaID).MessageID, msg.ID().(*KafkaID).MessageID)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("only %d messages of the committed batches are consumed", k)
+		}
+	}
+	select {
+	case msg := <-consumer.Chan():
+		t.Fatalf("unexpected message %d consumed", BytesToInt(msg.Payload()))
+	case <-time.After(500 * time.Millisecond):
+	}
+}
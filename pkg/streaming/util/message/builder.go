@@ -3,6 +3,7 @@ package message
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/cockroachdb/errors"
 	"google.golang.org/protobuf/proto"
@@ -124,6 +125,19 @@ func (b *mutableMesasgeBuilder[H, B]) WithNotPersisted() *mutableMesasgeBuilder[
 	return b
 }
 
+// WithTimeTickSource creates a new builder with the source id and the sequence of time tick, 0 means the sequence is not assigned.
+func (b *mutableMesasgeBuilder[H, B]) WithTimeTickSource(sourceID int64, sequence uint64) *mutableMesasgeBuilder[H, B] {
+	messageType := mustGetMessageTypeFromHeader(b.header)
+	if messageType != MessageTypeTimeTick {
+		panic("only time tick message can be stamped with the time tick source")
+	}
+	b.WithProperty(messageTimeTickSourceID, strconv.FormatInt(sourceID, 10))
+	if sequence != 0 {
+		b.WithProperty(messageTimeTickSequence, strconv.FormatUint(sequence, 10))
+	}
+	return b
+}
+
 // WithBody creates a new builder with message body.
 func (b *mutableMesasgeBuilder[H, B]) WithBody(body B) *mutableMesasgeBuilder[H, B] {
 	b.body = body
@@ -36,6 +36,32 @@ func TestMutableBuilder(t *testing.T) {
 	assert.Panics(t, func() {
 		message.NewCreateCollectionMessageBuilderV1().WithNotPersisted()
 	})
+
+	b = message.NewTimeTickMessageBuilderV1().
+		WithHeader(&message.TimeTickMessageHeader{}).
+		WithBody(&msgpb.TimeTickMsg{}).
+		WithTimeTickSource(3, 0).
+		WithAllVChannel().
+		MustBuildMutable()
+	_, ok := message.GetTimeTickSequence(b)
+	assert.False(t, ok)
+	sourceID, ok := message.GetTimeTickSourceID(b)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), sourceID)
+
+	b = message.NewTimeTickMessageBuilderV1().
+		WithHeader(&message.TimeTickMessageHeader{}).
+		WithBody(&msgpb.TimeTickMsg{}).
+		WithTimeTickSource(3, 7).
+		WithAllVChannel().
+		MustBuildMutable()
+	sequence, ok := message.GetTimeTickSequence(b)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), sequence)
+
+	assert.Panics(t, func() {
+		message.NewCreateCollectionMessageBuilderV1().WithTimeTickSource(3, 7)
+	})
 }
 
 func TestImmutableTxnBuilder(t *testing.T) {
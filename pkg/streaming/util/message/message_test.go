@@ -123,3 +123,33 @@ func TestCheckIfMessageFromStreaming(t *testing.T) {
 		messageVersion: "1",
 	}))
 }
+
+func TestTimeTickSource(t *testing.T) {
+	// the time tick source is only read from the time tick message.
+	msg, err := NewInsertMessageBuilderV1().
+		WithVChannel("v1").
+		WithHeader(&InsertMessageHeader{}).
+		WithBody(&msgpb.InsertRequest{}).
+		WithProperty(messageTimeTickSequence, "7").
+		WithProperty(messageTimeTickSourceID, "3").
+		BuildMutable()
+	assert.NoError(t, err)
+	_, ok := GetTimeTickSequence(msg)
+	assert.False(t, ok)
+	_, ok = GetTimeTickSourceID(msg)
+	assert.False(t, ok)
+
+	// the malformed properties are ignored.
+	msg, err = NewTimeTickMessageBuilderV1().
+		WithHeader(&TimeTickMessageHeader{}).
+		WithBody(&msgpb.TimeTickMsg{}).
+		WithProperty(messageTimeTickSequence, "x").
+		WithProperty(messageTimeTickSourceID, "y").
+		WithAllVChannel().
+		BuildMutable()
+	assert.NoError(t, err)
+	_, ok = GetTimeTickSequence(msg)
+	assert.False(t, ok)
+	_, ok = GetTimeTickSourceID(msg)
+	assert.False(t, ok)
+}
@@ -1,5 +1,7 @@
 package message
 
+import "strconv"
+
 const (
 	// preserved properties
 	messageVersion                          = "_v"   // message version for compatibility, see `Version` for more information.
@@ -15,6 +17,8 @@ const (
 	messageTxnContext                       = "_tx"  // transaction context.
 	messageCipherHeader                     = "_ch"  // message cipher header.
 	messageNotPersisteted                   = "_np"  // check if the message is unpersisted.
+	messageTimeTickSequence                 = "_tsq" // sequence of the time tick message emitted by the time tick inspector.
+	messageTimeTickSourceID                 = "_tsi" // source id of the time tick message.
 )
 
 var (
@@ -94,3 +98,37 @@ func CheckIfMessageFromStreaming(props map[string]string) bool {
 	}
 	return false
 }
+
+// GetTimeTickSequence returns the sequence stamped on the time tick message,
+// false is returned if the message is not a time tick or the sequence is not assigned.
+func GetTimeTickSequence(msg BasicMessage) (uint64, bool) {
+	if msg.MessageType() != MessageTypeTimeTick {
+		return 0, false
+	}
+	value, ok := msg.Properties().Get(messageTimeTickSequence)
+	if !ok {
+		return 0, false
+	}
+	sequence, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return sequence, true
+}
+
+// GetTimeTickSourceID returns the source id stamped on the time tick message,
+// false is returned if the message is not a time tick or it's written before the source id is stamped.
+func GetTimeTickSourceID(msg BasicMessage) (int64, bool) {
+	if msg.MessageType() != MessageTypeTimeTick {
+		return 0, false
+	}
+	value, ok := msg.Properties().Get(messageTimeTickSourceID)
+	if !ok {
+		return 0, false
+	}
+	sourceID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return sourceID, true
+}
@@ -5248,6 +5248,25 @@ type streamingConfig struct {
 
 	// logging
 	LoggingAppendSlowThreshold ParamItem `refreshable:"true"`
+
+	// time tick
+	TimeTickSyncInterval                   ParamItem  `refreshable:"true"`
+	TimeTickDefaultTenantPersistedSyncRate ParamItem  `refreshable:"true"`
+	TimeTickTenantPersistedSyncRate        ParamGroup `refreshable:"true"`
+	TimeTickStallThreshold                 ParamItem  `refreshable:"false"`
+	TimeTickCancelStalledSync              ParamItem  `refreshable:"false"`
+	TimeTickBufferPressureHighWatermark    ParamItem  `refreshable:"true"`
+	TimeTickBufferPressureLowWatermark     ParamItem  `refreshable:"true"`
+	TimeTickMaxConcurrentPersistedSyncs    ParamItem  `refreshable:"false"`
+	TimeTickMaxPendingTriggers             ParamItem  `refreshable:"false"`
+	TimeTickSyncOnRegistration             ParamItem  `refreshable:"false"`
+	TimeTickTraceSync                      ParamItem  `refreshable:"false"`
+	TimeTickDryRun                         ParamItem  `refreshable:"false"`
+	TimeTickAuditInterval                  ParamItem  `refreshable:"false"`
+	TimeTickAuditTolerance                 ParamItem  `refreshable:"false"`
+	TimeTickTriggerCoalesceWindow          ParamItem  `refreshable:"false"`
+	TimeTickTriggerCoalesceMaxDelay        ParamItem  `refreshable:"false"`
+	TimeTickMaxClockSkew                   ParamItem  `refreshable:"false"`
 }
 
 func (p *streamingConfig) init(base *BaseTable) {
@@ -5385,6 +5404,178 @@ If the wal implementation is woodpecker, the minimum threshold is 3s`,
 		Export:       true,
 	}
 	p.LoggingAppendSlowThreshold.Init(base.mgr)
+
+	// time tick
+	p.TimeTickSyncInterval = ParamItem{
+		Key:     "streaming.timeTick.syncInterval",
+		Version: "2.6.0",
+		Doc: `The interval of the periodic time tick sync of pchannels, 0 by default means proxy.timeTickInterval is used.
+The change takes effect on the next scheduling cycle of all pchannels without restart.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickSyncInterval.Init(base.mgr)
+
+	p.TimeTickDefaultTenantPersistedSyncRate = ParamItem{
+		Key:     "streaming.timeTick.defaultTenantPersistedSyncRate",
+		Version: "2.6.0",
+		Doc: `The max persisted time tick syncs per second of a tenant, 0 by default means no limit.
+The tenant of a pchannel is the name prefix before the last '_', the budget is evenly distributed across the pchannels of the tenant.
+The budget of a specified tenant can be set by streaming.timeTick.tenantPersistedSyncRate.<tenant>`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickDefaultTenantPersistedSyncRate.Init(base.mgr)
+
+	p.TimeTickTenantPersistedSyncRate = ParamGroup{
+		KeyPrefix: "streaming.timeTick.tenantPersistedSyncRate.",
+		Version:   "2.6.0",
+	}
+	p.TimeTickTenantPersistedSyncRate.Init(base.mgr)
+
+	p.TimeTickStallThreshold = ParamItem{
+		Key:     "streaming.timeTick.stallThreshold",
+		Version: "2.6.0",
+		Doc: `The threshold of the time tick sync stall, 1m by default, 0 means the stall watchdog is disabled.
+The time ticks of all pchannels on the streaming node are halted if a sync lasts longer than the threshold, a stall is logged and counted.`,
+		DefaultValue: "1m",
+		Export:       true,
+	}
+	p.TimeTickStallThreshold.Init(base.mgr)
+
+	p.TimeTickCancelStalledSync = ParamItem{
+		Key:          "streaming.timeTick.cancelStalledSync",
+		Version:      "2.6.0",
+		Doc:          "Whether to cancel the stalled time tick sync, so the time ticks of other pchannels can go on, false by default.",
+		DefaultValue: "false",
+		Export:       true,
+	}
+	p.TimeTickCancelStalledSync.Init(base.mgr)
+
+	p.TimeTickBufferPressureHighWatermark = ParamItem{
+		Key:     "streaming.timeTick.bufferPressureHighWatermark",
+		Version: "2.6.0",
+		Doc: `The high watermark of the total size of write ahead buffers of all pchannels on the streaming node, 0 by default means disabled.
+The buffers are under pressure once the total size exceeds the high watermark, the periodic time tick syncs are persisted
+and the pchannels with larger buffers are synced first, until the total size falls below the low watermark.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickBufferPressureHighWatermark.Init(base.mgr)
+
+	p.TimeTickBufferPressureLowWatermark = ParamItem{
+		Key:          "streaming.timeTick.bufferPressureLowWatermark",
+		Version:      "2.6.0",
+		Doc:          "The low watermark to release the write ahead buffer pressure, the high watermark is used if it's 0 or larger than the high watermark.",
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickBufferPressureLowWatermark.Init(base.mgr)
+
+	p.TimeTickMaxConcurrentPersistedSyncs = ParamItem{
+		Key:     "streaming.timeTick.maxConcurrentPersistedSyncs",
+		Version: "2.6.0",
+		Doc: `The max number of concurrent persisted time tick syncs on the streaming node,
+0 by default means the persisted syncs are performed one by one with the other time tick syncs.
+The persisted syncs of pchannels are performed concurrently under the limit, so a slow wal doesn't halt the time ticks of the others,
+the non-persisted syncs are not limited.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickMaxConcurrentPersistedSyncs.Init(base.mgr)
+
+	p.TimeTickMaxPendingTriggers = ParamItem{
+		Key:     "streaming.timeTick.maxPendingTriggers",
+		Version: "2.6.0",
+		Doc: `The max number of pending triggered time tick syncs on the streaming node, 0 by default means unlimited.
+The triggers pile up when the syncs are stalled, e.g. the storage of wal is out, the new triggers are shed once the cap is reached,
+and the force persisted triggers are favored over the non-persisted ones.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickMaxPendingTriggers.Init(base.mgr)
+
+	p.TimeTickSyncOnRegistration = ParamItem{
+		Key:     "streaming.timeTick.syncOnRegistration",
+		Version: "2.6.0",
+		Doc: `Whether to emit a time tick once a pchannel is registered on the streaming node, true by default.
+The readers of a pchannel without any write get a baseline time tick at once, rather than waiting for the first write or periodic sync.`,
+		DefaultValue: "true",
+		Export:       true,
+	}
+	p.TimeTickSyncOnRegistration.Init(base.mgr)
+
+	p.TimeTickTraceSync = ParamItem{
+		Key:     "streaming.timeTick.traceSync",
+		Version: "2.6.0",
+		Doc: `Whether to trace each time tick sync with a span, false by default.
+The spans are exported by the tracer of the streaming node, so the time tick behavior can be correlated with other traces.`,
+		DefaultValue: "false",
+		Export:       true,
+	}
+	p.TimeTickTraceSync.Init(base.mgr)
+
+	p.TimeTickDryRun = ParamItem{
+		Key:     "streaming.timeTick.dryRun",
+		Version: "2.6.0",
+		Doc: `Whether to only report the time tick syncs by log and metrics without performing them, false by default.
+It's used to validate the new scheduling configs of time tick sync, no time tick is written into the wal,
+so the readers of the pchannels on the streaming node stall while it's enabled.`,
+		DefaultValue: "false",
+		Export:       true,
+	}
+	p.TimeTickDryRun.Init(base.mgr)
+
+	p.TimeTickAuditInterval = ParamItem{
+		Key:     "streaming.timeTick.auditInterval",
+		Version: "2.6.0",
+		Doc: `The interval of the time tick audit on the streaming node, 0 by default means disabled.
+The emitted watermark of each pchannel is checked against its mvcc and write ahead buffer, any discrepancy is logged and counted.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickAuditInterval.Init(base.mgr)
+
+	p.TimeTickAuditTolerance = ParamItem{
+		Key:          "streaming.timeTick.auditTolerance",
+		Version:      "2.6.0",
+		Doc:          "The tolerated lag of the emitted watermark behind the mvcc of the pchannel by the time tick audit, 10s by default.",
+		DefaultValue: "10s",
+		Export:       true,
+	}
+	p.TimeTickAuditTolerance.Init(base.mgr)
+
+	p.TimeTickTriggerCoalesceWindow = ParamItem{
+		Key:     "streaming.timeTick.triggerCoalesceWindow",
+		Version: "2.6.0",
+		Doc: `The window to coalesce the triggered time tick syncs of a pchannel on the streaming node, 0 by default means disabled.
+The coalesced sync is performed once no trigger arrives within the window, so a burst of writes emits one time tick,
+the force persisted triggers are never coalesced.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickTriggerCoalesceWindow.Init(base.mgr)
+
+	p.TimeTickTriggerCoalesceMaxDelay = ParamItem{
+		Key:     "streaming.timeTick.triggerCoalesceMaxDelay",
+		Version: "2.6.0",
+		Doc: `The max delay of the coalesced time tick sync since its first trigger, 1s by default, the window is used if it's 0.
+A time tick is still emitted per max delay under continuous writes, so the readers never stall.`,
+		DefaultValue: "1s",
+		Export:       true,
+	}
+	p.TimeTickTriggerCoalesceMaxDelay.Init(base.mgr)
+
+	p.TimeTickMaxClockSkew = ParamItem{
+		Key:     "streaming.timeTick.maxClockSkew",
+		Version: "2.6.0",
+		Doc: `The max tolerated skew of the synced time tick ahead of the clock of the streaming node, 0 by default means no limit.
+The watermark of a pchannel is clamped to the clock plus the max skew, so it never jumps far ahead if the clock of the node is skewed,
+and the readers stall at most the max skew once the clock is corrected.`,
+		DefaultValue: "0",
+		Export:       true,
+	}
+	p.TimeTickMaxClockSkew.Init(base.mgr)
 }
 
 // runtimeConfig is just a private environment value table.
@@ -627,6 +627,23 @@ func TestComponentParam(t *testing.T) {
 		assert.Equal(t, 30*time.Second, params.StreamingCfg.WALWriteAheadBufferKeepalive.GetAsDurationByParse())
 		assert.Equal(t, int64(64*1024*1024), params.StreamingCfg.WALWriteAheadBufferCapacity.GetAsSize())
 		assert.Equal(t, 1*time.Second, params.StreamingCfg.LoggingAppendSlowThreshold.GetAsDurationByParse())
+		assert.Equal(t, time.Duration(0), params.StreamingCfg.TimeTickSyncInterval.GetAsDurationByParse())
+		assert.Equal(t, 0.0, params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.GetAsFloat())
+		assert.Empty(t, params.StreamingCfg.TimeTickTenantPersistedSyncRate.GetValue())
+		assert.Equal(t, time.Minute, params.StreamingCfg.TimeTickStallThreshold.GetAsDurationByParse())
+		assert.False(t, params.StreamingCfg.TimeTickCancelStalledSync.GetAsBool())
+		assert.Equal(t, int64(0), params.StreamingCfg.TimeTickBufferPressureHighWatermark.GetAsSize())
+		assert.Equal(t, int64(0), params.StreamingCfg.TimeTickBufferPressureLowWatermark.GetAsSize())
+		assert.Equal(t, 0, params.StreamingCfg.TimeTickMaxConcurrentPersistedSyncs.GetAsInt())
+		assert.Equal(t, 0, params.StreamingCfg.TimeTickMaxPendingTriggers.GetAsInt())
+		assert.True(t, params.StreamingCfg.TimeTickSyncOnRegistration.GetAsBool())
+		assert.False(t, params.StreamingCfg.TimeTickTraceSync.GetAsBool())
+		assert.False(t, params.StreamingCfg.TimeTickDryRun.GetAsBool())
+		assert.Equal(t, time.Duration(0), params.StreamingCfg.TimeTickAuditInterval.GetAsDurationByParse())
+		assert.Equal(t, 10*time.Second, params.StreamingCfg.TimeTickAuditTolerance.GetAsDurationByParse())
+		assert.Equal(t, time.Duration(0), params.StreamingCfg.TimeTickTriggerCoalesceWindow.GetAsDurationByParse())
+		assert.Equal(t, time.Second, params.StreamingCfg.TimeTickTriggerCoalesceMaxDelay.GetAsDurationByParse())
+		assert.Equal(t, time.Duration(0), params.StreamingCfg.TimeTickMaxClockSkew.GetAsDurationByParse())
 		params.Save(params.StreamingCfg.WALBalancerTriggerInterval.Key, "50s")
 		params.Save(params.StreamingCfg.WALBalancerBackoffInitialInterval.Key, "50s")
 		params.Save(params.StreamingCfg.WALBalancerBackoffMultiplier.Key, "3.5")
@@ -641,6 +658,23 @@ func TestComponentParam(t *testing.T) {
 		params.Save(params.StreamingCfg.WALBalancerPolicyVChannelFairRebalanceTolerance.Key, "0.02")
 		params.Save(params.StreamingCfg.WALBalancerPolicyVChannelFairRebalanceMaxStep.Key, "4")
 		params.Save(params.StreamingCfg.LoggingAppendSlowThreshold.Key, "3s")
+		params.Save(params.StreamingCfg.TimeTickSyncInterval.Key, "100ms")
+		params.Save(params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.Key, "10")
+		params.SaveGroup(map[string]string{params.StreamingCfg.TimeTickTenantPersistedSyncRate.KeyPrefix + "by-dev-rootcoord-dml": "2.5"})
+		params.Save(params.StreamingCfg.TimeTickStallThreshold.Key, "30s")
+		params.Save(params.StreamingCfg.TimeTickCancelStalledSync.Key, "true")
+		params.Save(params.StreamingCfg.TimeTickBufferPressureHighWatermark.Key, "256m")
+		params.Save(params.StreamingCfg.TimeTickBufferPressureLowWatermark.Key, "128m")
+		params.Save(params.StreamingCfg.TimeTickMaxConcurrentPersistedSyncs.Key, "4")
+		params.Save(params.StreamingCfg.TimeTickMaxPendingTriggers.Key, "1024")
+		params.Save(params.StreamingCfg.TimeTickSyncOnRegistration.Key, "false")
+		params.Save(params.StreamingCfg.TimeTickTraceSync.Key, "true")
+		params.Save(params.StreamingCfg.TimeTickDryRun.Key, "true")
+		params.Save(params.StreamingCfg.TimeTickAuditInterval.Key, "5m")
+		params.Save(params.StreamingCfg.TimeTickAuditTolerance.Key, "30s")
+		params.Save(params.StreamingCfg.TimeTickTriggerCoalesceWindow.Key, "20ms")
+		params.Save(params.StreamingCfg.TimeTickTriggerCoalesceMaxDelay.Key, "500ms")
+		params.Save(params.StreamingCfg.TimeTickMaxClockSkew.Key, "3s")
 		assert.Equal(t, 50*time.Second, params.StreamingCfg.WALBalancerTriggerInterval.GetAsDurationByParse())
 		assert.Equal(t, 50*time.Second, params.StreamingCfg.WALBalancerBackoffInitialInterval.GetAsDurationByParse())
 		assert.Equal(t, 3.5, params.StreamingCfg.WALBalancerBackoffMultiplier.GetAsFloat())
@@ -655,6 +689,23 @@ func TestComponentParam(t *testing.T) {
 		assert.Equal(t, 10*time.Second, params.StreamingCfg.WALWriteAheadBufferKeepalive.GetAsDurationByParse())
 		assert.Equal(t, int64(128*1024), params.StreamingCfg.WALWriteAheadBufferCapacity.GetAsSize())
 		assert.Equal(t, 3*time.Second, params.StreamingCfg.LoggingAppendSlowThreshold.GetAsDurationByParse())
+		assert.Equal(t, 100*time.Millisecond, params.StreamingCfg.TimeTickSyncInterval.GetAsDurationByParse())
+		assert.Equal(t, 10.0, params.StreamingCfg.TimeTickDefaultTenantPersistedSyncRate.GetAsFloat())
+		assert.Equal(t, map[string]string{"by-dev-rootcoord-dml": "2.5"}, params.StreamingCfg.TimeTickTenantPersistedSyncRate.GetValue())
+		assert.Equal(t, 30*time.Second, params.StreamingCfg.TimeTickStallThreshold.GetAsDurationByParse())
+		assert.True(t, params.StreamingCfg.TimeTickCancelStalledSync.GetAsBool())
+		assert.Equal(t, int64(256*1024*1024), params.StreamingCfg.TimeTickBufferPressureHighWatermark.GetAsSize())
+		assert.Equal(t, int64(128*1024*1024), params.StreamingCfg.TimeTickBufferPressureLowWatermark.GetAsSize())
+		assert.Equal(t, 4, params.StreamingCfg.TimeTickMaxConcurrentPersistedSyncs.GetAsInt())
+		assert.Equal(t, 1024, params.StreamingCfg.TimeTickMaxPendingTriggers.GetAsInt())
+		assert.False(t, params.StreamingCfg.TimeTickSyncOnRegistration.GetAsBool())
+		assert.True(t, params.StreamingCfg.TimeTickTraceSync.GetAsBool())
+		assert.True(t, params.StreamingCfg.TimeTickDryRun.GetAsBool())
+		assert.Equal(t, 5*time.Minute, params.StreamingCfg.TimeTickAuditInterval.GetAsDurationByParse())
+		assert.Equal(t, 30*time.Second, params.StreamingCfg.TimeTickAuditTolerance.GetAsDurationByParse())
+		assert.Equal(t, 20*time.Millisecond, params.StreamingCfg.TimeTickTriggerCoalesceWindow.GetAsDurationByParse())
+		assert.Equal(t, 500*time.Millisecond, params.StreamingCfg.TimeTickTriggerCoalesceMaxDelay.GetAsDurationByParse())
+		assert.Equal(t, 3*time.Second, params.StreamingCfg.TimeTickMaxClockSkew.GetAsDurationByParse())
 	})
 
 	t.Run("channel config priority", func(t *testing.T) {
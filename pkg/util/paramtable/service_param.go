@@ -1012,9 +1012,36 @@ type KafkaConfig struct {
 	KafkaTLSKey         ParamItem  `refreshable:"false"`
 	KafkaTLSCACert      ParamItem  `refreshable:"false"`
 	KafkaTLSKeyPassword ParamItem  `refreshable:"false"`
+	KafkaTLSCertPem     ParamItem  `refreshable:"false"`
+	KafkaTLSKeyPem      ParamItem  `refreshable:"false"`
+	KafkaTLSCACertPem   ParamItem  `refreshable:"false"`
 	ConsumerExtraConfig ParamGroup `refreshable:"false"`
 	ProducerExtraConfig ParamGroup `refreshable:"false"`
 	ReadTimeout         ParamItem  `refreshable:"true"`
+	ProducerLingerMs    ParamItem  `refreshable:"false"`
+
+	SubscribeRetryAttempts  ParamItem `refreshable:"true"`
+	SubscribeRetryBackoffMs ParamItem `refreshable:"true"`
+
+	ConsumerFetchWaitMaxMs ParamItem `refreshable:"false"`
+	ConsumerFetchMinBytes  ParamItem `refreshable:"false"`
+	ConsumerMaxPollRecords ParamItem `refreshable:"true"`
+	ConsumerClientRack     ParamItem `refreshable:"false"`
+
+	ConnectionsMaxIdleMs     ParamItem `refreshable:"false"`
+	ConnectionSetupTimeoutMs ParamItem `refreshable:"false"`
+	SocketKeepaliveEnable    ParamItem `refreshable:"false"`
+	SocketNagleDisable       ParamItem `refreshable:"false"`
+
+	ConsumerAsyncCommitEnabled    ParamItem `refreshable:"false"`
+	ConsumerAsyncCommitIntervalMs ParamItem `refreshable:"false"`
+	ConsumerAsyncCommitBatchSize  ParamItem `refreshable:"false"`
+	ConsumerManualOffsetStorage   ParamItem `refreshable:"false"`
+	ConsumerStatsIntervalMs       ParamItem `refreshable:"false"`
+
+	SaslKerberosServiceName ParamItem `refreshable:"false"`
+	SaslKerberosKeytab      ParamItem `refreshable:"false"`
+	SaslKerberosPrincipal   ParamItem `refreshable:"false"`
 }
 
 func (k *KafkaConfig) Init(base *BaseTable) {
@@ -1059,6 +1086,31 @@ func (k *KafkaConfig) Init(base *BaseTable) {
 	}
 	k.SecurityProtocol.Init(base.mgr)
 
+	k.SaslKerberosServiceName = ParamItem{
+		Key:          "kafka.saslKerberosServiceName",
+		DefaultValue: "kafka",
+		Version:      "2.6.0",
+		Doc:          "kerberos principal name that kafka runs as, only used when saslMechanisms is GSSAPI",
+		Export:       true,
+	}
+	k.SaslKerberosServiceName.Init(base.mgr)
+
+	k.SaslKerberosKeytab = ParamItem{
+		Key:     "kafka.saslKerberosKeytab",
+		Version: "2.6.0",
+		Doc:     "path to the kerberos keytab file of client, required when saslMechanisms is GSSAPI",
+		Export:  true,
+	}
+	k.SaslKerberosKeytab.Init(base.mgr)
+
+	k.SaslKerberosPrincipal = ParamItem{
+		Key:     "kafka.saslKerberosPrincipal",
+		Version: "2.6.0",
+		Doc:     "kerberos principal of client, required when saslMechanisms is GSSAPI",
+		Export:  true,
+	}
+	k.SaslKerberosPrincipal.Init(base.mgr)
+
 	k.KafkaUseSSL = ParamItem{
 		Key:          "kafka.ssl.enabled",
 		DefaultValue: "false",
@@ -1100,6 +1152,30 @@ func (k *KafkaConfig) Init(base *BaseTable) {
 	}
 	k.KafkaTLSKeyPassword.Init(base.mgr)
 
+	k.KafkaTLSCertPem = ParamItem{
+		Key:     "kafka.ssl.tlsCertPem",
+		Version: "2.6.0",
+		Doc:     "client's public key (PEM string) used for authentication, an alternative to tlsCert, e.g. injected by an environment variable",
+		Export:  true,
+	}
+	k.KafkaTLSCertPem.Init(base.mgr)
+
+	k.KafkaTLSKeyPem = ParamItem{
+		Key:     "kafka.ssl.tlsKeyPem",
+		Version: "2.6.0",
+		Doc:     "client's private key (PEM string) used for authentication, an alternative to tlsKey",
+		Export:  true,
+	}
+	k.KafkaTLSKeyPem.Init(base.mgr)
+
+	k.KafkaTLSCACertPem = ParamItem{
+		Key:     "kafka.ssl.tlsCaCertPem",
+		Version: "2.6.0",
+		Doc:     "CA certificate(s) (PEM string) for verifying the broker's key, an alternative to tlsCaCert",
+		Export:  true,
+	}
+	k.KafkaTLSCACertPem.Init(base.mgr)
+
 	k.ConsumerExtraConfig = ParamGroup{
 		KeyPrefix: "kafka.consumer.",
 		Version:   "2.2.0",
@@ -1119,6 +1195,150 @@ func (k *KafkaConfig) Init(base *BaseTable) {
 		Export:       true,
 	}
 	k.ReadTimeout.Init(base.mgr)
+
+	k.ProducerLingerMs = ParamItem{
+		Key:          "kafka.lingerMs",
+		DefaultValue: "2",
+		Version:      "2.6.0",
+		Doc:          "default linger time of producer in milliseconds, a larger value gives better batching, can be overridden by each producer",
+		Export:       true,
+	}
+	k.ProducerLingerMs.Init(base.mgr)
+
+	k.SubscribeRetryAttempts = ParamItem{
+		Key:          "kafka.subscribeRetryAttempts",
+		DefaultValue: "5",
+		Version:      "2.6.0",
+		Doc:          "max attempts to subscribe when kafka returns a transient error, 1 means no retry",
+		Export:       true,
+	}
+	k.SubscribeRetryAttempts.Init(base.mgr)
+
+	k.SubscribeRetryBackoffMs = ParamItem{
+		Key:          "kafka.subscribeRetryBackoffMs",
+		DefaultValue: "100",
+		Version:      "2.6.0",
+		Doc:          "initial backoff between subscribe retries in milliseconds, doubled on each retry",
+		Export:       true,
+	}
+	k.SubscribeRetryBackoffMs.Init(base.mgr)
+
+	k.ConsumerFetchWaitMaxMs = ParamItem{
+		Key:          "kafka.fetchWaitMaxMs",
+		DefaultValue: "500",
+		Version:      "2.6.0",
+		Doc:          "max time in milliseconds the broker may wait to fill the fetch response of consumer, a larger value reduces the fetch requests of low-traffic channels",
+		Export:       true,
+	}
+	k.ConsumerFetchWaitMaxMs.Init(base.mgr)
+
+	k.ConsumerFetchMinBytes = ParamItem{
+		Key:          "kafka.fetchMinBytes",
+		DefaultValue: "1",
+		Version:      "2.6.0",
+		Doc:          "min bytes the broker responds with to the fetch request of consumer, the broker waits up to fetchWaitMaxMs to accumulate the data",
+		Export:       true,
+	}
+	k.ConsumerFetchMinBytes.Init(base.mgr)
+
+	k.ConsumerMaxPollRecords = ParamItem{
+		Key:          "kafka.maxPollRecords",
+		DefaultValue: "0",
+		Version:      "2.6.0",
+		Doc:          "max messages the consumer hands over in a row before yielding to other goroutines, so a flood of available messages doesn't starve them, 0 means never yield",
+		Export:       true,
+	}
+	k.ConsumerMaxPollRecords.Init(base.mgr)
+
+	k.ConsumerClientRack = ParamItem{
+		Key:          "kafka.clientRack",
+		DefaultValue: "",
+		Version:      "2.6.0",
+		Doc:          "rack id of consumer to fetch from the closest replica in multi-AZ clusters, empty means fetching from the leader, requires kafka 2.4+ with broker.rack set on brokers and replica.selector.class=org.apache.kafka.common.replica.RackAwareReplicaSelector",
+		Export:       true,
+	}
+	k.ConsumerClientRack.Init(base.mgr)
+
+	k.ConnectionsMaxIdleMs = ParamItem{
+		Key:          "kafka.connectionsMaxIdleMs",
+		DefaultValue: "0",
+		Version:      "2.6.0",
+		Doc:          "close the idle broker connections of producer and consumer after the time in milliseconds, so the stale connections behind load balancer are reconnected, 0 means disabled",
+		Export:       true,
+	}
+	k.ConnectionsMaxIdleMs.Init(base.mgr)
+
+	k.ConnectionSetupTimeoutMs = ParamItem{
+		Key:          "kafka.connectionSetupTimeoutMs",
+		DefaultValue: "30000",
+		Version:      "2.6.0",
+		Doc:          "max time in milliseconds for the broker connection of producer and consumer to be set up, including the SASL/SSL handshake, so the connect attempt to a slow broker fails fast",
+		Export:       true,
+	}
+	k.ConnectionSetupTimeoutMs.Init(base.mgr)
+
+	k.SocketKeepaliveEnable = ParamItem{
+		Key:          "kafka.socketKeepaliveEnable",
+		DefaultValue: "false",
+		Version:      "2.6.0",
+		Doc:          "whether to enable TCP keepalive on the broker connections of producer and consumer, so the dead connections on high-latency networks are detected",
+		Export:       true,
+	}
+	k.SocketKeepaliveEnable.Init(base.mgr)
+
+	k.SocketNagleDisable = ParamItem{
+		Key:          "kafka.socketNagleDisable",
+		DefaultValue: "false",
+		Version:      "2.6.0",
+		Doc:          "whether to disable the Nagle algorithm on the broker connections of producer and consumer, so the small requests are sent without delay",
+		Export:       true,
+	}
+	k.SocketNagleDisable.Init(base.mgr)
+
+	k.ConsumerAsyncCommitEnabled = ParamItem{
+		Key:          "kafka.asyncCommit.enabled",
+		DefaultValue: "false",
+		Version:      "2.6.0",
+		Doc:          "whether to commit the acked offsets of consumer to broker asynchronously in batch",
+		Export:       true,
+	}
+	k.ConsumerAsyncCommitEnabled.Init(base.mgr)
+
+	k.ConsumerAsyncCommitIntervalMs = ParamItem{
+		Key:          "kafka.asyncCommit.intervalMs",
+		DefaultValue: "1000",
+		Version:      "2.6.0",
+		Doc:          "interval in milliseconds to flush the acked offsets of consumer, a larger value means more reprocessing after restart",
+		Export:       true,
+	}
+	k.ConsumerAsyncCommitIntervalMs.Init(base.mgr)
+
+	k.ConsumerAsyncCommitBatchSize = ParamItem{
+		Key:          "kafka.asyncCommit.batchSize",
+		DefaultValue: "1000",
+		Version:      "2.6.0",
+		Doc:          "flush the acked offsets of consumer once the count of acked messages reaches it, 0 means only flush by interval",
+		Export:       true,
+	}
+	k.ConsumerAsyncCommitBatchSize.Init(base.mgr)
+
+	k.ConsumerManualOffsetStorage = ParamItem{
+		Key:          "kafka.manualOffsetStorage",
+		DefaultValue: "false",
+		Version:      "2.6.0",
+		Doc:          "whether the offsets of consumer are stored by milvus only, nothing is committed to broker, neither auto, async nor explicit commit, the consumer seeks to the stored position on restart",
+		Export:       true,
+	}
+	k.ConsumerManualOffsetStorage.Init(base.mgr)
+
+	k.ConsumerStatsIntervalMs = ParamItem{
+		Key:          "kafka.consumerStatsIntervalMs",
+		DefaultValue: "0",
+		Version:      "2.6.0",
+		Doc:          "interval in milliseconds of the statistics of consumer, the throttle time by each broker is exported by metrics and signaled to the consumer, 0 means disabled",
+		Export:       true,
+	}
+	k.ConsumerStatsIntervalMs.Init(base.mgr)
 }
 
 // /////////////////////////////////////////////////////////////////////////////
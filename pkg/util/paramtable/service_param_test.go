@@ -209,12 +209,34 @@ func TestServiceParam(t *testing.T) {
 			assert.Equal(t, "localhost:9092", kc.Address.GetValue())
 			assert.Empty(t, kc.SaslMechanisms.GetValue())
 			assert.Empty(t, kc.SecurityProtocol.GetValue())
+			assert.Equal(t, "kafka", kc.SaslKerberosServiceName.GetValue())
+			assert.Empty(t, kc.SaslKerberosKeytab.GetValue())
+			assert.Empty(t, kc.SaslKerberosPrincipal.GetValue())
 			assert.Equal(t, kc.ReadTimeout.GetAsDuration(time.Second), 10*time.Second)
 			assert.Equal(t, kc.KafkaUseSSL.GetAsBool(), false)
 			assert.Empty(t, kc.KafkaTLSCACert.GetValue())
 			assert.Empty(t, kc.KafkaTLSCert.GetValue())
 			assert.Empty(t, kc.KafkaTLSKey.GetValue())
 			assert.Empty(t, kc.KafkaTLSKeyPassword.GetValue())
+			assert.Empty(t, kc.KafkaTLSCertPem.GetValue())
+			assert.Empty(t, kc.KafkaTLSKeyPem.GetValue())
+			assert.Empty(t, kc.KafkaTLSCACertPem.GetValue())
+			assert.Equal(t, 2, kc.ProducerLingerMs.GetAsInt())
+			assert.Equal(t, 5, kc.SubscribeRetryAttempts.GetAsInt())
+			assert.Equal(t, 100, kc.SubscribeRetryBackoffMs.GetAsInt())
+			assert.Equal(t, 500, kc.ConsumerFetchWaitMaxMs.GetAsInt())
+			assert.Equal(t, 1, kc.ConsumerFetchMinBytes.GetAsInt())
+			assert.Equal(t, 0, kc.ConsumerMaxPollRecords.GetAsInt())
+			assert.Empty(t, kc.ConsumerClientRack.GetValue())
+			assert.Equal(t, 0, kc.ConnectionsMaxIdleMs.GetAsInt())
+			assert.Equal(t, 30000, kc.ConnectionSetupTimeoutMs.GetAsInt())
+			assert.False(t, kc.SocketKeepaliveEnable.GetAsBool())
+			assert.False(t, kc.SocketNagleDisable.GetAsBool())
+			assert.False(t, kc.ConsumerAsyncCommitEnabled.GetAsBool())
+			assert.Equal(t, 1000, kc.ConsumerAsyncCommitIntervalMs.GetAsInt())
+			assert.Equal(t, 1000, kc.ConsumerAsyncCommitBatchSize.GetAsInt())
+			assert.False(t, kc.ConsumerManualOffsetStorage.GetAsBool())
+			assert.Equal(t, 0, kc.ConsumerStatsIntervalMs.GetAsInt())
 		}
 	})
 